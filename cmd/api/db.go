@@ -3,19 +3,30 @@ package main
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"log/slog"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"gofi/internal/config"
+	"gofi/internal/lib"
+	"gofi/internal/lib/dbtrace"
 )
 
-func connectDB(cfg *config.ConfigDB) (*sql.DB, error) {
-	db, err := sql.Open("postgres", cfg.DSN)
+func connectDB(cfg *config.ConfigDB, logger *slog.Logger) (*sql.DB, error) {
+	connector, err := pq.NewConnector(cfg.DSN)
 	if err != nil {
 		return nil, err
 	}
 
+	var c driver.Connector = connector
+	if cfg.SlowQueryThreshold > 0 {
+		c = dbtrace.Wrap(connector, slowQueryHook(cfg.SlowQueryThreshold, logger))
+	}
+
+	db := sql.OpenDB(c)
+
 	db.SetMaxOpenConns(cfg.MaxOpenConns)
 	db.SetMaxIdleConns(cfg.MaxIdleConns)
 	db.SetConnMaxIdleTime(cfg.MaxIdleTime)
@@ -29,3 +40,24 @@ func connectDB(cfg *config.ConfigDB) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// slowQueryHook logs the queries taking longer than threshold with the id of
+// the request that ran them.
+func slowQueryHook(threshold time.Duration, logger *slog.Logger) dbtrace.Hook {
+	return func(ctx context.Context, query string, duration time.Duration, err error) {
+		if duration < threshold {
+			return
+		}
+
+		attrs := []any{
+			"request_id", lib.RequestID(ctx),
+			"duration", duration.String(),
+			"query", query,
+		}
+		if err != nil {
+			attrs = append(attrs, "error", err.Error())
+		}
+
+		logger.Warn("slow query", attrs...)
+	}
+}
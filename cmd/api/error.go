@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"gofi/internal/app"
+	"gofi/internal/lib"
+	"gofi/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errorHandler renders the errors returned by the handlers. Constraint
+// violations and missing records get their own status, anything else is a
+// 500.
+func errorHandler(app *app.Application) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		var fiberErr *fiber.Error
+		var constraintErr *repositories.ConstraintError
+
+		switch {
+		// the body was refused before reaching the handler, point uploads to
+		// the route that has no size limit
+		case errors.Is(err, fiber.ErrRequestEntityTooLarge) && strings.HasPrefix(c.Path(), "/v1/uploads"):
+			return c.Status(http.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"message": "file is larger than 2MB, upload it with /v1/uploads/presign",
+			})
+		case errors.As(err, &fiberErr):
+			return c.Status(fiberErr.Code).JSON(fiber.Map{
+				"message": fiberErr.Message,
+			})
+		case errors.As(err, &constraintErr):
+			return constraintError(c, constraintErr)
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "record not found",
+			})
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "record already exists",
+			})
+		case errors.Is(err, repositories.ErrEditConflict):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "record has been modified by another request",
+			})
+		}
+
+		// the error may hold queries or internal details, it is only logged and
+		// the client gets the request id to report
+		requestID := lib.ContextGetRequestID(c)
+		app.Logger.Error("request failed", "request_id", requestID, "method", c.Method(), "path", c.Path(), "error", err.Error())
+
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"message":    "internal server error",
+			"request_id": requestID,
+		})
+	}
+}
+
+// constraintError answers with the column of the violation, in the shape of
+// a validation error when it is known. The detail of the database error is
+// left out, it holds the values of the row.
+func constraintError(c *fiber.Ctx, err *repositories.ConstraintError) error {
+	status := http.StatusUnprocessableEntity
+	var message, reason string
+
+	switch {
+	case err.Referenced:
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "record is still referenced",
+		})
+	case errors.Is(err, repositories.ErrInsertDuplicate):
+		status = http.StatusConflict
+		message, reason = "record already exists", "already exists"
+	case errors.Is(err, repositories.ErrForeignKeyViolation):
+		message, reason = "referenced record does not exist", "does not exist"
+	case errors.Is(err, repositories.ErrNotNullViolation):
+		message, reason = "missing required value", "is required"
+	default:
+		message, reason = "invalid value", "is invalid"
+	}
+
+	if err.Column == "" {
+		return c.Status(status).JSON(fiber.Map{
+			"message": message,
+		})
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message": err.Column + " " + reason,
+		"errors": fiber.Map{
+			err.Column: []string{reason},
+		},
+	})
+}
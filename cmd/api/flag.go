@@ -19,6 +19,8 @@ func parseFlag(cfg *config.Config) {
 	flag.StringVar(&cfg.App.Name, "app-name", "gofi", "App Name")
 	flag.StringVar(&cfg.App.Secret, "app-secret", "", "App Secret")
 	flag.StringVar(&cfg.App.JWTSecret, "jwt-secret", "", "JWT Secret")
+	flag.StringVar(&cfg.App.JWTKeysDir, "jwt-keys-dir", "", "Directory of PEM keys signing JWT (RS256, ES256, EdDSA), HS256 with jwt-secret when empty")
+	flag.StringVar(&cfg.App.JWTKeyID, "jwt-key-id", "", "JWT signing key ID, defaults to the last private key of jwt-keys-dir")
 	flag.StringVar(&cfg.App.ClientURL, "client-url", "", "Client URL")
 	flag.StringVar(&cfg.App.ServerURL, "server-url", "", "Server URL")
 
@@ -27,6 +29,8 @@ func parseFlag(cfg *config.Config) {
 	flag.IntVar(&cfg.DB.MaxOpenConns, "db-max-open-conns", 25, "Database max open connections")
 	flag.IntVar(&cfg.DB.MaxIdleConns, "db-max-idle-conns", 25, "Database max idle connections")
 	flag.DurationVar(&cfg.DB.MaxIdleTime, "db-max-idle-time", 15*time.Minute, "Database max idle time")
+	flag.DurationVar(&cfg.DB.QueryTimeout, "db-query-timeout", 3*time.Second, "Database query timeout")
+	flag.DurationVar(&cfg.DB.SlowQueryThreshold, "db-slow-query-threshold", 200*time.Millisecond, "Log the database queries slower than this, 0 disables it")
 
 	// Redis
 	flag.StringVar(&cfg.Redis.Addr, "redis-addr", "localhost:6379", "Redis address")
@@ -43,12 +47,36 @@ func parseFlag(cfg *config.Config) {
 	flag.StringVar(&cfg.Google.ClientSecret, "google-client-secret", "", "Google client secret")
 	flag.StringVar(&cfg.Google.RedirectURL, "google-redirect-url", "", "Google redirect URL")
 
+	// GitHub
+	flag.StringVar(&cfg.GitHub.ClientID, "github-client-id", "", "GitHub client ID")
+	flag.StringVar(&cfg.GitHub.ClientSecret, "github-client-secret", "", "GitHub client secret")
+
+	// Microsoft
+	flag.StringVar(&cfg.Microsoft.ClientID, "microsoft-client-id", "", "Microsoft client ID")
+	flag.StringVar(&cfg.Microsoft.ClientSecret, "microsoft-client-secret", "", "Microsoft client secret")
+	flag.StringVar(&cfg.Microsoft.Tenant, "microsoft-tenant", "common", "Microsoft tenant")
+
+	// OpenID Connect
+	flag.StringVar(&cfg.OIDC.Name, "oidc-name", "oidc", "OpenID Connect provider name")
+	flag.StringVar(&cfg.OIDC.Issuer, "oidc-issuer", "", "OpenID Connect issuer URL")
+	flag.StringVar(&cfg.OIDC.ClientID, "oidc-client-id", "", "OpenID Connect client ID")
+	flag.StringVar(&cfg.OIDC.ClientSecret, "oidc-client-secret", "", "OpenID Connect client secret")
+
 	// S3
 	flag.StringVar(&cfg.S3.ClientID, "s3-client-id", "", "S3 client ID")
 	flag.StringVar(&cfg.S3.ClientSecret, "s3-client-secret", "", "S3 client secret")
 	flag.StringVar(&cfg.S3.Region, "s3-region", "", "S3 region")
 	flag.StringVar(&cfg.S3.Endpoint, "s3-endpoint", "", "S3 endpoint")
 	flag.StringVar(&cfg.S3.Token, "s3-token", "", "S3 token")
+	flag.StringVar(&cfg.S3.Bucket, "s3-bucket", "", "S3 bucket storing the uploads")
+
+	// Storage
+	flag.StringVar(&cfg.Storage.Driver, "storage-driver", "s3", "Storage driver of the uploads (s3|local)")
+	flag.StringVar(&cfg.Storage.LocalDir, "storage-local-dir", "./storage", "Directory of the uploads with the local storage driver")
+
+	// WebAuthn
+	flag.StringVar(&cfg.WebAuthn.RPID, "webauthn-rp-id", "", "WebAuthn relying party ID, defaults to the client url host")
+	flag.StringVar(&cfg.WebAuthn.RPOrigin, "webauthn-rp-origin", "", "WebAuthn relying party origin, defaults to the client url")
 
 	flag.Parse()
 
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"gofi/internal/config"
+	"gofi/internal/lib/jwt"
+)
+
+// loadKeyring returns nil when no keys directory is configured, tokens are
+// then signed with HS256 and the JWT secret, and the OpenID Connect
+// provider is disabled.
+func loadKeyring(cfg *config.ConfigApp) (*jwt.Keyring, error) {
+	if cfg.JWTKeysDir == "" {
+		return nil, nil
+	}
+
+	return jwt.LoadKeyring(cfg.JWTKeysDir, cfg.JWTKeyID)
+}
@@ -24,7 +24,7 @@ func main() {
 		Level: loggerLevel,
 	}))
 
-	db, err := connectDB(&cfg.DB)
+	db, err := connectDB(&cfg.DB, logger)
 	if err != nil {
 		logger.Error("failed to connect to database", "error", err.Error())
 		os.Exit(1)
@@ -38,19 +38,49 @@ func main() {
 	}
 	defer redisClient.Close()
 
-	googleOAuthConfig := newGoogleOAuth(cfg.Google)
+	keyring, err := loadKeyring(&cfg.App)
+	if err != nil {
+		logger.Error("failed to load jwt keys", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if keyring == nil {
+		logger.Warn("no jwt keys configured, the OpenID Connect provider is disabled")
+	}
+
+	oauthProviders, err := newOAuthProviders(&cfg)
+	if err != nil {
+		logger.Error("failed to configure oauth providers", "error", err.Error())
+		os.Exit(1)
+	}
+
+	storage, err := newStorage(&cfg)
+	if err != nil {
+		logger.Error("failed to configure storage", "error", err.Error())
+		os.Exit(1)
+	}
 
-	s3Client := newS3Client(cfg.S3)
+	webAuthn, err := newWebAuthn(cfg.WebAuthn, cfg.App)
+	if err != nil {
+		logger.Error("failed to configure webauthn", "error", err.Error())
+		os.Exit(1)
+	}
 
 	// Dependencies Injection
 	app := &app.Application{
 		Config:       cfg,
 		Logger:       logger,
-		Repositories: repositories.New(db, &cfg.App),
+		Repositories: repositories.New(db, &cfg),
+		Keyring:      keyring,
 		Services: services.Services{
-			Email:  services.EmailService{Config: cfg.Resend},
-			Google: services.GoogleService{Config: googleOAuthConfig, RedisClient: redisClient},
-			S3:     services.S3Service{Client: s3Client},
+			Email:     services.EmailService{Config: cfg.Resend},
+			OAuth:     services.OAuthService{Providers: oauthProviders, RedisClient: redisClient},
+			Storage:   storage,
+			MFA:       services.MFAService{RedisClient: redisClient},
+			WebAuthn:  services.WebAuthnService{WebAuthn: webAuthn, RedisClient: redisClient},
+			MagicLink: services.MagicLinkService{RedisClient: redisClient},
+			OIDC:      services.OIDCService{RedisClient: redisClient},
+			Lockout:   services.LockoutService{RedisClient: redisClient},
 		},
 	}
 
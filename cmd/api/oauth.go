@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"gofi/internal/config"
+	"gofi/internal/services"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/microsoft"
+)
+
+// newOAuthProviders returns the providers users can sign in with, a provider
+// is enabled when its client ID is configured.
+func newOAuthProviders(cfg *config.Config) (map[string]services.OAuthProvider, error) {
+	providers := make(map[string]services.OAuthProvider)
+
+	if cfg.Google.ClientID != "" {
+		redirectURL := cfg.Google.RedirectURL
+		if redirectURL == "" {
+			redirectURL = cfg.App.ServerURL
+		}
+		// the callback predates the generic providers and is registered in
+		// the Google console, so it keeps its path.
+
+		providers["google"] = services.OIDCProvider{
+			Config: &oauth2.Config{
+				ClientID:     cfg.Google.ClientID,
+				ClientSecret: cfg.Google.ClientSecret,
+				RedirectURL:  fmt.Sprintf("%s/v1/auth/google/callback", redirectURL),
+				Scopes:       []string{"openid", "email", "profile"},
+				Endpoint:     google.Endpoint,
+			},
+			UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		}
+	}
+
+	if cfg.GitHub.ClientID != "" {
+		providers["github"] = services.GitHubProvider{
+			Config: &oauth2.Config{
+				ClientID:     cfg.GitHub.ClientID,
+				ClientSecret: cfg.GitHub.ClientSecret,
+				RedirectURL:  oauthRedirectURL(cfg.App.ServerURL, "github"),
+				Scopes:       []string{"read:user", "user:email"},
+				Endpoint:     github.Endpoint,
+			},
+		}
+	}
+
+	if cfg.Microsoft.ClientID != "" {
+		providers["microsoft"] = services.OIDCProvider{
+			Config: &oauth2.Config{
+				ClientID:     cfg.Microsoft.ClientID,
+				ClientSecret: cfg.Microsoft.ClientSecret,
+				RedirectURL:  oauthRedirectURL(cfg.App.ServerURL, "microsoft"),
+				Scopes:       []string{"openid", "email", "profile", "offline_access"},
+				Endpoint:     microsoft.AzureADEndpoint(cfg.Microsoft.Tenant),
+			},
+			UserInfoURL: "https://graph.microsoft.com/oidc/userinfo",
+		}
+	}
+
+	if cfg.OIDC.Issuer != "" {
+		if _, exists := providers[cfg.OIDC.Name]; exists {
+			return nil, fmt.Errorf("oauth provider %q is already configured", cfg.OIDC.Name)
+		}
+
+		provider, err := services.DiscoverOIDCProvider(context.Background(), cfg.OIDC.Issuer, &oauth2.Config{
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  oauthRedirectURL(cfg.App.ServerURL, cfg.OIDC.Name),
+			Scopes:       []string{"openid", "email", "profile"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		providers[cfg.OIDC.Name] = provider
+	}
+
+	return providers, nil
+}
+
+func oauthRedirectURL(baseURL string, provider string) string {
+	return fmt.Sprintf("%s/v1/auth/oauth/%s/callback", baseURL, provider)
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"gofi/internal/app"
 	"gofi/internal/handlers"
 	"gofi/internal/lib/constant"
@@ -20,42 +22,145 @@ func routes(r *fiber.App, app *app.Application) {
 	})
 
 	r.Get("/health-check", h.Health.Check)
+	r.Get("/.well-known/jwks.json", h.WellKnown.JWKS)
 
 	authRoutes := r.Group("/v1/auth")
 	authRoutes.Post("/sign-up", h.Auth.SignUp)
 	authRoutes.Post("/sign-in", h.Auth.SignIn)
 	authRoutes.Post("/verify-registration", h.Auth.VerifyRegistration)
+	authRoutes.Post("/forgot-password", h.Auth.ForgotPassword)
+	authRoutes.Post("/reset-password", h.Auth.ResetPassword)
+	authRoutes.Post("/magic-link", h.Auth.MagicLink)
+	authRoutes.Post("/magic-link/verify", h.Auth.MagicLinkVerify)
 	authRoutes.Get("/verify-session", m.Authorization(), h.Auth.VerifySession)
 	authRoutes.Post("/refresh-token", m.Authorization(), h.Auth.RefreshToken)
 	authRoutes.Post("/sign-out", m.Authorization(), h.Auth.SignOut)
-	authRoutes.Post("/google", h.Auth.GoogleAuthURL)
-	authRoutes.Get("/google/callback", h.Auth.GoogleAuthCallback)
+	authRoutes.Get("/oauth", h.Auth.OAuthProviders)
+	authRoutes.Post("/oauth/:provider", h.Auth.OAuthURL)
+	authRoutes.Get("/oauth/:provider/callback", m.OptionalAuthorization(), h.Auth.OAuthCallback)
+	// the Google sign in route from before OAuth providers were generic. To
+	// be removed in the next major version.
+	authRoutes.Post("/google", deprecatedAlias("/v1/auth/oauth/google"))
+	// Google keeps redirecting to the callback registered in its console, so
+	// it stays on the old path, see newOAuthProviders.
+	authRoutes.Get("/google/callback", alias("/v1/auth/oauth/google/callback"))
+	authRoutes.Post("/oauth/:provider/link", m.SessionAuthorization(), h.Auth.OAuthLink)
+	authRoutes.Get("/identities", m.SessionAuthorization(), h.Auth.IdentityIndex)
+	authRoutes.Delete("/identities/:identityID", m.SessionAuthorization(), h.Auth.IdentityDelete)
+	authRoutes.Post("/mfa/enroll", m.SessionAuthorization(), h.Auth.MFAEnroll)
+	authRoutes.Post("/mfa/confirm", m.SessionAuthorization(), h.Auth.MFAConfirm)
+	authRoutes.Post("/mfa/disable", m.SessionAuthorization(), h.Auth.MFADisable)
+	authRoutes.Post("/mfa/verify", h.Auth.MFAVerify)
+	authRoutes.Post("/passkey/register/begin", m.SessionAuthorization(), h.Auth.PasskeyRegisterBegin)
+	authRoutes.Post("/passkey/register/finish", m.SessionAuthorization(), h.Auth.PasskeyRegisterFinish)
+	authRoutes.Post("/passkey/login/begin", h.Auth.PasskeyLoginBegin)
+	authRoutes.Post("/passkey/login/finish", h.Auth.PasskeyLoginFinish)
+	authRoutes.Get("/passkeys", m.SessionAuthorization(), h.Auth.PasskeyIndex)
+	authRoutes.Delete("/passkeys/:credentialID", m.SessionAuthorization(), h.Auth.PasskeyDelete)
+
+	// the OpenID Connect provider needs a keyring, relying parties verify the
+	// ID tokens with the JWKS and can't be handed the JWT secret. An
+	// authorization code signs the user in to the client, so it is only
+	// issued to a session, never to an api key.
+	if app.Keyring != nil {
+		r.Get("/.well-known/openid-configuration", h.WellKnown.OpenIDConfiguration)
+
+		oauthRoutes := r.Group("/v1/oauth")
+		oauthRoutes.Get("/authorize", m.SessionAuthorization(), h.OAuth.Authorize)
+		oauthRoutes.Post("/authorize", m.SessionAuthorization(), h.OAuth.Consent)
+		oauthRoutes.Post("/token", h.OAuth.Token)
+		oauthRoutes.Get("/userinfo", h.OAuth.UserInfo)
+		oauthRoutes.Post("/userinfo", h.OAuth.UserInfo)
+		oauthRoutes.Get("/consents", m.SessionAuthorization(), h.OAuth.ConsentIndex)
+		oauthRoutes.Delete("/consents/:clientID", m.SessionAuthorization(), h.OAuth.ConsentDelete)
+	}
+
+	meRoutes := r.Group("/v1/me")
+	meRoutes.Put("/avatar", m.Authorization(), h.User.MyAvatarUpdate)
+	// sessions are credentials, api keys can't manage them
+	meRoutes.Get("/sessions", m.SessionAuthorization(), h.Session.MyIndex)
+	meRoutes.Delete("/sessions/:sessionID", m.SessionAuthorization(), h.Session.MyDelete)
+	meRoutes.Post("/sessions/sign-out-others", m.SessionAuthorization(), h.Session.MyDeleteOthers)
+	meRoutes.Get("/organizations", m.Authorization(), h.Organization.MyIndex)
+	meRoutes.Post("/organizations/:organizationID/switch", m.SessionAuthorization(), h.Auth.SwitchOrganization)
 
-	adminOnly := []string{constant.RoleAdmin}
+	apiKeyRoutes := r.Group("/v1/api-keys")
+	apiKeyRoutes.Use(m.SessionAuthorization())
+	apiKeyRoutes.Get("", h.APIKey.Index)
+	apiKeyRoutes.Post("", h.APIKey.Create)
+	apiKeyRoutes.Delete("/:apiKeyID", h.APIKey.Delete)
+
+	uploadRoutes := r.Group("/v1/uploads")
+	uploadRoutes.Use(m.Authorization())
+	uploadRoutes.Post("", h.Upload.Create)
+	uploadRoutes.Post("/presign", h.Upload.Presign)
+	uploadRoutes.Post("/:uploadID/complete", h.Upload.Complete)
+	uploadRoutes.Get("/:uploadID", h.Upload.Show)
+	uploadRoutes.Delete("/:uploadID", h.Upload.Delete)
+
+	// signed urls of the local storage, the signature is the authorization
+	r.Get("/v1/storage/*", h.Storage.Show)
+
+	oauthClientRoutes := r.Group("/v1/oauth-clients")
+	oauthClientRoutes.Use(m.Authorization())
+	oauthClientRoutes.Get("", m.RequirePermission(constant.PermissionOAuthClientsRead), h.OAuthClient.Index)
+	oauthClientRoutes.Get("/:oauthClientID", m.RequirePermission(constant.PermissionOAuthClientsRead), h.OAuthClient.Show)
+	oauthClientRoutes.Post("", m.RequirePermission(constant.PermissionOAuthClientsWrite), h.OAuthClient.Create)
+	oauthClientRoutes.Put("/:oauthClientID", m.RequirePermission(constant.PermissionOAuthClientsWrite), h.OAuthClient.Update)
+	oauthClientRoutes.Delete("/:oauthClientID", m.RequirePermission(constant.PermissionOAuthClientsWrite), h.OAuthClient.Delete)
 
 	sessionRoutes := r.Group("/v1/sessions")
-	sessionRoutes.Use(m.Authorization(), m.PermissionAccess(adminOnly))
+	sessionRoutes.Use(m.Authorization(), m.RequirePermission(constant.PermissionSessionsRead))
 	sessionRoutes.Get("", h.Session.Index)
 
+	auditLogRoutes := r.Group("/v1/audit-logs")
+	auditLogRoutes.Use(m.Authorization(), m.RequirePermission(constant.PermissionAuditLogsRead))
+	auditLogRoutes.Get("", h.AuditLog.Index)
+
+	permissionRoutes := r.Group("/v1/permissions")
+	permissionRoutes.Use(m.Authorization())
+	permissionRoutes.Get("", m.RequirePermission(constant.PermissionPermissionsRead), h.Permission.Index)
+	permissionRoutes.Get("/:permissionID", m.RequirePermission(constant.PermissionPermissionsRead), h.Permission.Show)
+	permissionRoutes.Post("", m.RequirePermission(constant.PermissionPermissionsWrite), h.Permission.Create)
+	permissionRoutes.Put("/:permissionID", m.RequirePermission(constant.PermissionPermissionsWrite), h.Permission.Update)
+	permissionRoutes.Delete("/:permissionID", m.RequirePermission(constant.PermissionPermissionsWrite), h.Permission.Delete)
+
+	organizationRoutes := r.Group("/v1/organizations")
+	organizationRoutes.Use(m.Authorization())
+	organizationRoutes.Post("/invitations/accept", h.Organization.InvitationAccept)
+	organizationRoutes.Post("/:organizationID/invitations", h.Organization.InvitationCreate)
+	organizationRoutes.Get("", m.RequirePermission(constant.PermissionOrganizationsRead), h.Organization.Index)
+	organizationRoutes.Get("/:organizationID", m.RequirePermission(constant.PermissionOrganizationsRead), h.Organization.Show)
+	organizationRoutes.Post("", m.RequirePermission(constant.PermissionOrganizationsWrite), h.Organization.Create)
+	organizationRoutes.Put("/:organizationID", m.RequirePermission(constant.PermissionOrganizationsWrite), h.Organization.Update)
+	organizationRoutes.Delete("/:organizationID", m.RequirePermission(constant.PermissionOrganizationsWrite), h.Organization.Delete)
+	organizationRoutes.Post("/:organizationID/transfer-ownership", m.RequirePermission(constant.PermissionOrganizationsWrite), h.Organization.TransferOwnership)
+	organizationRoutes.Get("/:organizationID/members", m.RequirePermission(constant.PermissionOrganizationsRead), h.Organization.MemberIndex)
+	organizationRoutes.Delete("/:organizationID/members/:userID", m.RequirePermission(constant.PermissionOrganizationsWrite), h.Organization.MemberDelete)
+
 	roleRoutes := r.Group("/v1/roles")
 	roleRoutes.Use(m.Authorization())
-	roleRoutes.Get("", h.Role.Index)
-	roleRoutes.Get("/:roleID", h.Role.Show)
-	roleRoutes.Post("", m.PermissionAccess(adminOnly), h.Role.Create)
-	roleRoutes.Put("/:roleID", m.PermissionAccess(adminOnly), h.Role.Update)
-	roleRoutes.Delete("/:roleID", m.PermissionAccess(adminOnly), h.Role.Delete)
-	roleRoutes.Delete("/:roleID/soft-delete", m.PermissionAccess(adminOnly), h.Role.SoftDelete)
-	roleRoutes.Patch("/:roleID/restore", m.PermissionAccess(adminOnly), h.Role.Restore)
+	roleRoutes.Get("", m.RequirePermission(constant.PermissionRolesRead), h.Role.Index)
+	roleRoutes.Get("/:roleID", m.RequirePermission(constant.PermissionRolesRead), h.Role.Show)
+	roleRoutes.Post("", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Create)
+	roleRoutes.Put("/:roleID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Update)
+	roleRoutes.Delete("/:roleID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Delete)
+	roleRoutes.Delete("/:roleID/soft-delete", m.RequirePermission(constant.PermissionRolesWrite), h.Role.SoftDelete)
+	roleRoutes.Patch("/:roleID/restore", m.RequirePermission(constant.PermissionRolesWrite), h.Role.Restore)
+	roleRoutes.Get("/:roleID/permissions", m.RequirePermission(constant.PermissionRolesRead), h.Role.PermissionIndex)
+	roleRoutes.Post("/:roleID/permissions", m.RequirePermission(constant.PermissionRolesWrite), h.Role.PermissionAttach)
+	roleRoutes.Delete("/:roleID/permissions/:permissionID", m.RequirePermission(constant.PermissionRolesWrite), h.Role.PermissionDetach)
 
 	userRoutes := r.Group("/v1/users")
 	userRoutes.Use(m.Authorization())
-	userRoutes.Get("", h.User.Index)
-	userRoutes.Get("/:userID", h.User.Show)
-	userRoutes.Post("", m.PermissionAccess(adminOnly), h.User.Create)
-	userRoutes.Put("/:userID", m.PermissionAccess(adminOnly), h.User.Update)
-	userRoutes.Delete("/:userID", m.PermissionAccess(adminOnly), h.User.Delete)
-	userRoutes.Delete("/:userID/soft-delete", m.PermissionAccess(adminOnly), h.User.SoftDelete)
-	userRoutes.Patch("/:userID/restore", m.PermissionAccess(adminOnly), h.User.Restore)
+	userRoutes.Get("", m.RequirePermission(constant.PermissionUsersRead), h.User.Index)
+	userRoutes.Get("/:userID", m.RequirePermission(constant.PermissionUsersRead), h.User.Show)
+	userRoutes.Post("", m.RequirePermission(constant.PermissionUsersWrite), h.User.Create)
+	userRoutes.Put("/:userID", m.RequirePermission(constant.PermissionUsersWrite), h.User.Update)
+	userRoutes.Delete("/:userID", m.RequirePermission(constant.PermissionUsersWrite), h.User.Delete)
+	userRoutes.Delete("/:userID/soft-delete", m.RequirePermission(constant.PermissionUsersWrite), h.User.SoftDelete)
+	userRoutes.Patch("/:userID/restore", m.RequirePermission(constant.PermissionUsersWrite), h.User.Restore)
+	userRoutes.Post("/:userID/unlock", m.RequirePermission(constant.PermissionUsersWrite), h.User.Unlock)
 
 	// Not found handler
 	r.Use("*", func(c *fiber.Ctx) error {
@@ -64,3 +169,22 @@ func routes(r *fiber.App, app *app.Application) {
 		})
 	})
 }
+
+// alias serves the request with the route at path.
+func alias(path string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Path(path)
+		return c.RestartRouting()
+	}
+}
+
+// deprecatedAlias serves the request with the route at path, the response
+// tells the client to move to it.
+func deprecatedAlias(path string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, path))
+
+		return alias(path)(c)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"gofi/internal/app"
 	"gofi/internal/docs"
+	"gofi/internal/lib"
 	"gofi/internal/lib/constant"
 
 	"github.com/gofiber/fiber/v2"
@@ -25,16 +26,12 @@ import (
 func serve(app *app.Application) error {
 	// Fiber Configuration
 	server := fiber.New(fiber.Config{
-		BodyLimit:               2 * 1024 * 1024, // 2MB
+		BodyLimit:               2 * 1024 * 1024, // 2MB, bigger files are uploaded with /v1/uploads/presign
 		IdleTimeout:             time.Minute,
 		ReadTimeout:             20 * time.Second,
 		WriteTimeout:            3 * time.Minute,
 		EnableTrustedProxyCheck: true,
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
+		ErrorHandler:            errorHandler(app),
 	})
 
 	// Middleware
@@ -42,14 +39,19 @@ func serve(app *app.Application) error {
 	server.Use(logger.New())
 	server.Use(helmet.New())
 	server.Use(requestid.New())
+	server.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(lib.WithRequestID(c.UserContext(), lib.ContextGetRequestID(c)))
+		return c.Next()
+	})
 	server.Use(compress.New())
 
 	// CORS
 	server.Use(cors.New(cors.Config{
-		AllowOrigins: strings.Join(constant.AllowedOrigins(app), ","),
-		AllowMethods: "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
-		MaxAge:       3600,
+		AllowOrigins:  strings.Join(constant.AllowedOrigins(app), ","),
+		AllowMethods:  "GET,POST,PUT,PATCH,DELETE,OPTIONS",
+		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,If-Match",
+		ExposeHeaders: "ETag",
+		MaxAge:        3600,
 	}))
 
 	// Rate Limit
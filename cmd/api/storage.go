@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"gofi/internal/config"
+	"gofi/internal/services"
+)
+
+// newStorage returns the storage of the uploads selected by the
+// storage-driver flag.
+func newStorage(cfg *config.Config) (services.Storage, error) {
+	switch cfg.Storage.Driver {
+	case services.StorageDriverS3:
+		return services.S3Service{Client: newS3Client(cfg.S3), Bucket: cfg.S3.Bucket}, nil
+	case services.StorageDriverLocal:
+		if cfg.App.Secret == "" {
+			return nil, errors.New("app-secret is required to sign the urls of the local storage")
+		}
+
+		return services.LocalStorage{
+			Dir:       cfg.Storage.LocalDir,
+			Secret:    cfg.App.Secret,
+			ServerURL: cfg.App.ServerURL,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Storage.Driver)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/url"
+
+	"gofi/internal/config"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+func newWebAuthn(cfg config.ConfigWebAuthn, app config.ConfigApp) (*webauthn.WebAuthn, error) {
+	rpID := cfg.RPID
+	rpOrigin := cfg.RPOrigin
+
+	// passkeys are used from the client, so it is the relying party by default
+	if rpID == "" || rpOrigin == "" {
+		clientURL, err := url.Parse(app.ClientURL)
+		if err != nil {
+			return nil, err
+		}
+
+		if rpID == "" {
+			rpID = clientURL.Hostname()
+		}
+
+		if rpOrigin == "" {
+			rpOrigin = clientURL.Scheme + "://" + clientURL.Host
+		}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: app.Name,
+		RPOrigins:     []string{rpOrigin},
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+}
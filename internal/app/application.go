@@ -4,6 +4,7 @@ import (
 	"log/slog"
 
 	"gofi/internal/config"
+	"gofi/internal/lib/jwt"
 	"gofi/internal/repositories"
 	"gofi/internal/services"
 )
@@ -13,4 +14,5 @@ type Application struct {
 	Logger       *slog.Logger
 	Repositories repositories.Repositories
 	Services     services.Services
+	Keyring      *jwt.Keyring // nil when tokens are signed with HS256
 }
@@ -3,24 +3,31 @@ package config
 import "time"
 
 type Config struct {
-	App    ConfigApp
-	DB     ConfigDB
-	Redis  ConfigRedis
-	Resend ConfigResend
-	Google ConfigGoogle
-	S3     ConfigS3
+	App       ConfigApp
+	DB        ConfigDB
+	Redis     ConfigRedis
+	Resend    ConfigResend
+	Google    ConfigGoogle
+	GitHub    ConfigGitHub
+	Microsoft ConfigMicrosoft
+	OIDC      ConfigOIDC
+	S3        ConfigS3
+	Storage   ConfigStorage
+	WebAuthn  ConfigWebAuthn
 }
 
 type ConfigApp struct {
-	Env       string
-	Debug     bool
-	Port      int
-	MachineID uint16
-	Name      string
-	Secret    string
-	JWTSecret string
-	ClientURL string
-	ServerURL string
+	Env        string
+	Debug      bool
+	Port       int
+	MachineID  uint16
+	Name       string
+	Secret     string
+	JWTSecret  string
+	JWTKeysDir string
+	JWTKeyID   string
+	ClientURL  string
+	ServerURL  string
 }
 
 type ConfigDB struct {
@@ -28,6 +35,10 @@ type ConfigDB struct {
 	MaxOpenConns int
 	MaxIdleConns int
 	MaxIdleTime  time.Duration
+	// QueryTimeout bounds each query of the repositories.
+	QueryTimeout time.Duration
+	// SlowQueryThreshold logs the queries taking longer, 0 disables it.
+	SlowQueryThreshold time.Duration
 }
 
 type ConfigRedis struct {
@@ -48,10 +59,43 @@ type ConfigGoogle struct {
 	RedirectURL  string
 }
 
+type ConfigGitHub struct {
+	ClientID     string
+	ClientSecret string
+}
+
+type ConfigMicrosoft struct {
+	ClientID     string
+	ClientSecret string
+	Tenant       string
+}
+
+// ConfigOIDC is a generic OpenID Connect issuer users can sign in with,
+// Name is the provider name used in the routes.
+type ConfigOIDC struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+}
+
 type ConfigS3 struct {
 	ClientID     string
 	ClientSecret string
 	Region       string
 	Endpoint     string
 	Token        string
+	Bucket       string
+}
+
+// ConfigStorage selects where uploads are stored, LocalDir is only used by
+// the local driver.
+type ConfigStorage struct {
+	Driver   string
+	LocalDir string
+}
+
+type ConfigWebAuthn struct {
+	RPID     string
+	RPOrigin string
 }
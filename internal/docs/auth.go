@@ -149,6 +149,87 @@ func (g *OpenAPIGenerator) generateAuthVerifyRegistrationRequest() map[string]in
 	}
 }
 
+func (g *OpenAPIGenerator) generateAuthForgotPasswordRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"email": map[string]interface{}{
+				"type":        "string",
+				"example":     "your@email.com",
+				"description": "Email of the user",
+			},
+		},
+		"required": []string{"email"},
+	}
+}
+
+func (g *OpenAPIGenerator) generateAuthResetPasswordRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"token": map[string]interface{}{
+				"type":        "string",
+				"example":     "your-token",
+				"description": "Reset password token sent by email",
+			},
+			"password": map[string]interface{}{
+				"type":        "string",
+				"format":      "password",
+				"example":     "********",
+				"description": "New password of the user",
+			},
+		},
+		"required": []string{"token", "password"},
+	}
+}
+
+func (g *OpenAPIGenerator) generateAuthMagicLinkRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"email": map[string]interface{}{
+				"type":        "string",
+				"example":     "your@email.com",
+				"description": "Email of the user",
+			},
+		},
+		"required": []string{"email"},
+	}
+}
+
+func (g *OpenAPIGenerator) generateAuthMagicLinkVerifyRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"token": map[string]interface{}{
+				"type":        "string",
+				"example":     "your-token",
+				"description": "Sign in token sent by email",
+			},
+		},
+		"required": []string{"token"},
+	}
+}
+
+func (g *OpenAPIGenerator) generateAuthMFAVerifyRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"mfa_token": map[string]interface{}{
+				"type":        "string",
+				"example":     "your-mfa-token",
+				"description": "MFA token returned by sign in",
+			},
+			"code": map[string]interface{}{
+				"type":        "string",
+				"example":     "123456",
+				"description": "Code of the authenticator app or a recovery code",
+			},
+		},
+		"required": []string{"mfa_token", "code"},
+	}
+}
+
 // Sign Up
 func (g *OpenAPIGenerator) generateAuthSignUp() map[string]interface{} {
 	return map[string]interface{}{
@@ -180,7 +261,7 @@ func (g *OpenAPIGenerator) generateAuthSignUp() map[string]interface{} {
 func (g *OpenAPIGenerator) generateAuthSignIn() map[string]interface{} {
 	return map[string]interface{}{
 		"summary":     "Sign In",
-		"description": "Sign In to an account",
+		"description": "Sign In to an account. When MFA is enabled, `mfa_required` and `mfa_token` are returned instead of the tokens and the sign in is completed with MFA Verify",
 		"tags":        []string{"Auth"},
 		"requestBody": map[string]interface{}{
 			"required": true,
@@ -285,3 +366,144 @@ func (g *OpenAPIGenerator) generateAuthSignOut() map[string]interface{} {
 		}),
 	}
 }
+
+// Forgot Password
+func (g *OpenAPIGenerator) generateAuthForgotPassword() map[string]interface{} {
+	return map[string]interface{}{
+		"summary":     "Forgot Password",
+		"description": "Send a reset password link to the email of an account",
+		"tags":        []string{"Auth"},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/ForgotPasswordRequest",
+					},
+				},
+			},
+		},
+		"responses": g.generateResponse(Response{
+			Properties: map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":    "string",
+					"example": "If the email is registered, a password reset link has been sent",
+				},
+			},
+		}),
+	}
+}
+
+// Reset Password
+func (g *OpenAPIGenerator) generateAuthResetPassword() map[string]interface{} {
+	return map[string]interface{}{
+		"summary":     "Reset Password",
+		"description": "Reset the password of an account with a token sent by email",
+		"tags":        []string{"Auth"},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/ResetPasswordRequest",
+					},
+				},
+			},
+		},
+		"responses": g.generateResponse(Response{
+			Properties: map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":    "string",
+					"example": "Reset password successfully",
+				},
+			},
+		}),
+	}
+}
+
+// Magic Link
+func (g *OpenAPIGenerator) generateAuthMagicLink() map[string]interface{} {
+	return map[string]interface{}{
+		"summary":     "Magic Link",
+		"description": "Send a single-use sign in link to the email of an account",
+		"tags":        []string{"Auth"},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/MagicLinkRequest",
+					},
+				},
+			},
+		},
+		"responses": g.generateResponse(Response{
+			Properties: map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":    "string",
+					"example": "If the email is registered, a sign in link has been sent",
+				},
+			},
+		}),
+	}
+}
+
+// Magic Link Verify
+func (g *OpenAPIGenerator) generateAuthMagicLinkVerify() map[string]interface{} {
+	return map[string]interface{}{
+		"summary":     "Magic Link Verify",
+		"description": "Sign In to an account with a token sent by email",
+		"tags":        []string{"Auth"},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/MagicLinkVerifyRequest",
+					},
+				},
+			},
+		},
+		"responses": g.generateResponse(Response{
+			Properties: map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":    "string",
+					"example": "Sign in successfully",
+				},
+				"data": map[string]interface{}{
+					"$ref": "#/components/schemas/SignInResponse",
+				},
+			},
+		}),
+	}
+}
+
+// MFA Verify
+func (g *OpenAPIGenerator) generateAuthMFAVerify() map[string]interface{} {
+	return map[string]interface{}{
+		"summary":     "MFA Verify",
+		"description": "Complete the sign in of an account with MFA enabled",
+		"tags":        []string{"Auth"},
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"$ref": "#/components/schemas/MFAVerifyRequest",
+					},
+				},
+			},
+		},
+		"responses": g.generateResponse(Response{
+			Properties: map[string]interface{}{
+				"message": map[string]interface{}{
+					"type":    "string",
+					"example": "Sign in successfully",
+				},
+				"data": map[string]interface{}{
+					"$ref": "#/components/schemas/SignInResponse",
+				},
+			},
+		}),
+	}
+}
@@ -57,6 +57,21 @@ func (g *OpenAPIGenerator) generatePaths() map[string]interface{} {
 		"/v1/auth/verify-registration": map[string]interface{}{
 			"post": g.generateAuthVerifyRegistration(),
 		},
+		"/v1/auth/forgot-password": map[string]interface{}{
+			"post": g.generateAuthForgotPassword(),
+		},
+		"/v1/auth/reset-password": map[string]interface{}{
+			"post": g.generateAuthResetPassword(),
+		},
+		"/v1/auth/magic-link": map[string]interface{}{
+			"post": g.generateAuthMagicLink(),
+		},
+		"/v1/auth/magic-link/verify": map[string]interface{}{
+			"post": g.generateAuthMagicLinkVerify(),
+		},
+		"/v1/auth/mfa/verify": map[string]interface{}{
+			"post": g.generateAuthMFAVerify(),
+		},
 		"/v1/auth/verify-session": map[string]interface{}{
 			"get": g.generateAuthVerifySession(),
 		},
@@ -97,6 +112,11 @@ func (g *OpenAPIGenerator) generateComponents() map[string]interface{} {
 			"SignInResponse":            g.generateAuthSignInResponse(),
 			"VerifyRegistrationRequest": g.generateAuthVerifyRegistrationRequest(),
 			"VerifySessionResponse":     g.generateAuthVerifySessionResponse(),
+			"ForgotPasswordRequest":     g.generateAuthForgotPasswordRequest(),
+			"ResetPasswordRequest":      g.generateAuthResetPasswordRequest(),
+			"MagicLinkRequest":          g.generateAuthMagicLinkRequest(),
+			"MagicLinkVerifyRequest":    g.generateAuthMagicLinkVerifyRequest(),
+			"MFAVerifyRequest":          g.generateAuthMFAVerifyRequest(),
 			// Role
 			"Role":              g.generateRoleModel(),
 			"CreateRoleRequest": g.generateCreateRoleRequest(),
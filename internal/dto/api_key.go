@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"time"
+
+	"gofi/internal/lib/validator"
+)
+
+type APIKeyCreate struct {
+	Name      string     `json:"name" form:"name"`
+	Scopes    []string   `json:"scopes" form:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at"`
+}
+
+func (dto APIKeyCreate) Validate(v *validator.MapValidator) {
+	v.Field("name").Required().String().MaxRune(255)
+	v.Field("scopes").Slice(func(v *validator.FieldValidator) {
+		v.Required().WithinS("read", "write")
+	})
+	v.Field("expires_at").Date()
+}
@@ -0,0 +1,25 @@
+package dto
+
+import "gofi/internal/lib/validator"
+
+type AuditLogPagination struct {
+	Offset     int64   `json:"offset" form:"offset"`
+	Limit      int64   `json:"limit" form:"limit"`
+	ActorID    *string `json:"actor_id" form:"actor_id"`
+	TargetType *string `json:"target_type" form:"target_type"`
+	TargetID   *string `json:"target_id" form:"target_id"`
+	Action     *string `json:"action" form:"action"`
+	From       *string `json:"from" form:"from"`
+	To         *string `json:"to" form:"to"`
+}
+
+func (dto AuditLogPagination) Validate(v *validator.MapValidator) {
+	v.Field("offset").Required().Num()
+	v.Field("limit").Required().Num()
+	v.Field("actor_id").UUID()
+	v.Field("target_type").String().MaxRune(64)
+	v.Field("target_id").UUID()
+	v.Field("action").String().MaxRune(64)
+	v.Field("from").Date()
+	v.Field("to").Date()
+}
@@ -36,6 +36,40 @@ func (dto AuthVerifyRegistration) Validate(v *validator.MapValidator) {
 	v.Field("token").Required().String()
 }
 
+type AuthForgotPassword struct {
+	Email string `json:"email" form:"email"`
+}
+
+func (dto AuthForgotPassword) Validate(v *validator.MapValidator) {
+	v.Field("email").Required().Email()
+}
+
+type AuthResetPassword struct {
+	Token    string `json:"token" form:"token"`
+	Password string `json:"password" form:"password"`
+}
+
+func (dto AuthResetPassword) Validate(v *validator.MapValidator) {
+	v.Field("token").Required().String()
+	v.Field("password").Required().String()
+}
+
+type AuthMagicLink struct {
+	Email string `json:"email" form:"email"`
+}
+
+func (dto AuthMagicLink) Validate(v *validator.MapValidator) {
+	v.Field("email").Required().Email()
+}
+
+type AuthMagicLinkVerify struct {
+	Token string `json:"token" form:"token"`
+}
+
+func (dto AuthMagicLinkVerify) Validate(v *validator.MapValidator) {
+	v.Field("token").Required().String()
+}
+
 type AuthRefreshToken struct {
 	Token string `json:"token" form:"token"`
 }
@@ -44,12 +78,30 @@ func (dto AuthRefreshToken) Validate(v *validator.MapValidator) {
 	v.Field("token").Required().String()
 }
 
-type AuthGoogle struct {
+type AuthOAuthCallback struct {
 	State string `json:"state" form:"state"`
 	Code  string `json:"code" form:"code"`
 }
 
-func (dto AuthGoogle) Validate(v *validator.MapValidator) {
+func (dto AuthOAuthCallback) Validate(v *validator.MapValidator) {
 	v.Field("state").Required().String()
 	v.Field("code").Required().String()
 }
+
+type AuthMFACode struct {
+	Code string `json:"code" form:"code"`
+}
+
+func (dto AuthMFACode) Validate(v *validator.MapValidator) {
+	v.Field("code").Required().String()
+}
+
+type AuthMFAVerify struct {
+	MFAToken string `json:"mfa_token" form:"mfa_token"`
+	Code     string `json:"code" form:"code"`
+}
+
+func (dto AuthMFAVerify) Validate(v *validator.MapValidator) {
+	v.Field("mfa_token").Required().String()
+	v.Field("code").Required().String()
+}
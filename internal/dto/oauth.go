@@ -0,0 +1,97 @@
+package dto
+
+import "gofi/internal/lib/validator"
+
+type OAuthAuthorize struct {
+	ResponseType        string `json:"response_type" form:"response_type" query:"response_type"`
+	ClientID            string `json:"client_id" form:"client_id" query:"client_id"`
+	RedirectURI         string `json:"redirect_uri" form:"redirect_uri" query:"redirect_uri"`
+	Scope               string `json:"scope" form:"scope" query:"scope"`
+	State               string `json:"state" form:"state" query:"state"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge" query:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method" query:"code_challenge_method"`
+	Nonce               string `json:"nonce" form:"nonce" query:"nonce"`
+}
+
+func (dto OAuthAuthorize) Validate(v *validator.MapValidator) {
+	v.Field("response_type").Required().WithinS("code")
+	v.Field("client_id").Required().String()
+	v.Field("redirect_uri").Required().String()
+	v.Field("scope").Required().String()
+	v.Field("state").String()
+	v.Field("code_challenge").Required().String()
+	v.Field("code_challenge_method").Required().WithinS("S256")
+	v.Field("nonce").String()
+}
+
+type OAuthAuthorizeConsent struct {
+	OAuthAuthorize
+	Approve *bool `json:"approve" form:"approve"`
+}
+
+func (dto OAuthAuthorizeConsent) Validate(v *validator.MapValidator) {
+	dto.OAuthAuthorize.Validate(v)
+	v.Field("approve").Required().Bool()
+}
+
+type OAuthToken struct {
+	GrantType    string `json:"grant_type" form:"grant_type"`
+	Code         string `json:"code" form:"code"`
+	RedirectURI  string `json:"redirect_uri" form:"redirect_uri"`
+	ClientID     string `json:"client_id" form:"client_id"`
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+	CodeVerifier string `json:"code_verifier" form:"code_verifier"`
+}
+
+func (dto OAuthToken) Validate(v *validator.MapValidator) {
+	v.Field("grant_type").Required().WithinS("authorization_code")
+	v.Field("code").Required().String()
+	v.Field("redirect_uri").Required().String()
+	v.Field("client_id").String()
+	v.Field("client_secret").String()
+	v.Field("code_verifier").Required().String()
+}
+
+type OAuthClientPagination struct {
+	Offset int64 `json:"offset" form:"offset"`
+	Limit  int64 `json:"limit" form:"limit"`
+}
+
+func (dto OAuthClientPagination) Validate(v *validator.MapValidator) {
+	v.Field("offset").Required().Num()
+	v.Field("limit").Required().Num()
+}
+
+type OAuthClientCreate struct {
+	Name         string   `json:"name" form:"name"`
+	RedirectURIs []string `json:"redirect_uris" form:"redirect_uris"`
+	Scopes       []string `json:"scopes" form:"scopes"`
+	Public       bool     `json:"public" form:"public"`
+}
+
+func (dto OAuthClientCreate) Validate(v *validator.MapValidator) {
+	v.Field("name").Required().String()
+	v.Field("redirect_uris").Required().Slice(func(v *validator.FieldValidator) {
+		v.Required().String()
+	})
+	v.Field("scopes").Required().Slice(func(v *validator.FieldValidator) {
+		v.Required().WithinS("openid", "profile", "email")
+	})
+	v.Field("public").Bool()
+}
+
+type OAuthClientUpdate struct {
+	Name         string   `json:"name" form:"name"`
+	RedirectURIs []string `json:"redirect_uris" form:"redirect_uris"`
+	Scopes       []string `json:"scopes" form:"scopes"`
+}
+
+func (dto OAuthClientUpdate) Validate(v *validator.MapValidator) {
+	v.Field("name").String()
+	v.Field("redirect_uris").Slice(func(v *validator.FieldValidator) {
+		v.Required().String()
+	})
+	v.Field("scopes").Slice(func(v *validator.FieldValidator) {
+		v.Required().WithinS("openid", "profile", "email")
+	})
+}
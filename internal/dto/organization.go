@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"gofi/internal/lib/validator"
+
+	"github.com/google/uuid"
+)
+
+type OrganizationPagination struct {
+	Offset int64 `json:"offset" form:"offset"`
+	Limit  int64 `json:"limit" form:"limit"`
+}
+
+func (dto OrganizationPagination) Validate(v *validator.MapValidator) {
+	v.Field("offset").Required().Num()
+	v.Field("limit").Required().Num()
+}
+
+type OrganizationCreate struct {
+	Name    string    `json:"name" form:"name"`
+	Slug    string    `json:"slug" form:"slug"`
+	OwnerID uuid.UUID `json:"owner_id" form:"owner_id"`
+}
+
+func (dto OrganizationCreate) Validate(v *validator.MapValidator) {
+	v.Field("name").Required().String().MaxRune(255)
+	v.Field("slug").Required().Regex(`^[a-z0-9]+(-[a-z0-9]+)*$`).MaxRune(255)
+	v.Field("owner_id").Required().UUID()
+}
+
+type OrganizationUpdate struct {
+	Name string `json:"name" form:"name"`
+	Slug string `json:"slug" form:"slug"`
+}
+
+func (dto OrganizationUpdate) Validate(v *validator.MapValidator) {
+	v.Field("name").String().MaxRune(255)
+	v.Field("slug").Regex(`^[a-z0-9]+(-[a-z0-9]+)*$`).MaxRune(255)
+}
+
+type OrganizationTransferOwnership struct {
+	UserID uuid.UUID `json:"user_id" form:"user_id"`
+}
+
+func (dto OrganizationTransferOwnership) Validate(v *validator.MapValidator) {
+	v.Field("user_id").Required().UUID()
+}
+
+type OrganizationInvitationCreate struct {
+	Email string `json:"email" form:"email"`
+	Role  string `json:"role" form:"role"`
+}
+
+func (dto OrganizationInvitationCreate) Validate(v *validator.MapValidator) {
+	v.Field("email").Required().Email()
+	v.Field("role").Required().WithinS("admin", "member")
+}
+
+type OrganizationInvitationAccept struct {
+	Token string `json:"token" form:"token"`
+}
+
+func (dto OrganizationInvitationAccept) Validate(v *validator.MapValidator) {
+	v.Field("token").Required().String()
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"gofi/internal/lib/validator"
+
+	"github.com/google/uuid"
+)
+
+type PermissionPagination struct {
+	Offset int64 `json:"offset" form:"offset"`
+	Limit  int64 `json:"limit" form:"limit"`
+}
+
+func (dto PermissionPagination) Validate(v *validator.MapValidator) {
+	v.Field("offset").Required().Num()
+	v.Field("limit").Required().Num()
+}
+
+type PermissionCreate struct {
+	Name        string  `json:"name" form:"name"`
+	Description *string `json:"description" form:"description"`
+}
+
+func (dto PermissionCreate) Validate(v *validator.MapValidator) {
+	v.Field("name").Required().Regex(`^[a-z0-9-]+:[a-z0-9-]+$`).MaxRune(255)
+	v.Field("description").String()
+}
+
+type PermissionUpdate struct {
+	Name        string  `json:"name" form:"name"`
+	Description *string `json:"description" form:"description"`
+}
+
+func (dto PermissionUpdate) Validate(v *validator.MapValidator) {
+	v.Field("name").Regex(`^[a-z0-9-]+:[a-z0-9-]+$`).MaxRune(255)
+	v.Field("description").String()
+}
+
+type RolePermissionAttach struct {
+	PermissionIDs []uuid.UUID `json:"permission_ids" form:"permission_ids"`
+}
+
+func (dto RolePermissionAttach) Validate(v *validator.MapValidator) {
+	v.Field("permission_ids").Required().Slice(func(v *validator.FieldValidator) {
+		v.Required().UUID()
+	})
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "gofi/internal/lib/validator"
+
+type UploadPresign struct {
+	FileName string `json:"file_name" form:"file_name"`
+	MimeType string `json:"mimetype" form:"mimetype"`
+	Size     int64  `json:"size" form:"size"`
+}
+
+func (dto UploadPresign) Validate(v *validator.MapValidator) {
+	v.Field("file_name").Required().String().MaxRune(255)
+	v.Field("mimetype").Required().Regex(`^[a-z0-9][a-z0-9!#$&^_.+-]*/[a-z0-9][a-z0-9!#$&^_.+-]*$`).MaxRune(255)
+	v.Field("size").Required().Num().Min(1)
+}
+
+type UploadCompletePart struct {
+	PartNumber int32  `json:"part_number" form:"part_number"`
+	ETag       string `json:"etag" form:"etag"`
+}
+
+type UploadComplete struct {
+	// Parts are required to complete uploads presigned in parts.
+	Parts []UploadCompletePart `json:"parts" form:"parts"`
+}
+
+func (dto UploadComplete) Validate(v *validator.MapValidator) {
+	v.Field("parts").Slice(func(v *validator.FieldValidator) {
+		v.Required().Map(func(v *validator.MapValidator) {
+			v.Field("part_number").Required().Num().Min(1).Max(10000)
+			v.Field("etag").Required().String()
+		})
+	})
+}
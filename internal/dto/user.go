@@ -7,13 +7,21 @@ import (
 )
 
 type UserPagination struct {
-	Offset int64 `json:"offset" form:"offset"`
-	Limit  int64 `json:"limit" form:"limit"`
+	Offset int64  `json:"offset" form:"offset"`
+	Limit  int64  `json:"limit" form:"limit"`
+	After  string `json:"after" form:"after"`
+	Before string `json:"before" form:"before"`
+	// IncludeTotal counts the rows of a cursor paginated list, offset
+	// pagination always counts them.
+	IncludeTotal bool `json:"include_total" form:"include_total"`
 }
 
 func (dto UserPagination) Validate(v *validator.MapValidator) {
 	v.Field("offset").Required().Num()
 	v.Field("limit").Required().Num()
+	v.Field("after").String().MaxRune(512)
+	v.Field("before").String().MaxRune(512)
+	v.Field("include_total").Bool()
 }
 
 type UserCreate struct {
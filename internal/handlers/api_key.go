@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/lib/jwt"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type apiKeyHandler struct {
+	app *app.Application
+}
+
+func (h *apiKeyHandler) Index(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	apiKeys, err := h.app.Repositories.APIKey.ListByUserID(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.APIKey]{
+			Message: "list data has been retrieved successfully",
+			Data:    apiKeys,
+			Meta: fiber.Map{
+				"total": len(apiKeys),
+			},
+		})
+}
+
+// Create issues an API key, the key is only returned once as it is stored
+// hashed.
+func (h *apiKeyHandler) Create(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var dto dto.APIKeyCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	if dto.ExpiresAt != nil && dto.ExpiresAt.Before(time.Now()) {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "expires_at must be in the future",
+		})
+	}
+
+	scopes := dto.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{models.APIKeyScopeRead, models.APIKeyScopeWrite}
+	}
+
+	key, err := jwt.GenerateAPIKey()
+	if err != nil {
+		return err
+	}
+
+	apiKey := &models.APIKey{
+		ID:        uuid.Must(uuid.NewV7()),
+		UserID:    uid,
+		Name:      dto.Name,
+		Prefix:    key[:len(jwt.APIKeyPrefix)+4],
+		Key:       lib.HashToken(key),
+		Scopes:    pq.StringArray(scopes),
+		ExpiresAt: dto.ExpiresAt,
+	}
+
+	// the key is scoped to the organization active when it is created
+	if organizationID := lib.ContextGetOrganizationID(c); organizationID != uuid.Nil {
+		apiKey.OrganizationID = &organizationID
+	}
+
+	err = h.app.Repositories.APIKey.Insert(c.UserContext(), apiKey)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[fiber.Map]{
+			Message: "data has been created successfully",
+			Data: fiber.Map{
+				"api_key": apiKey,
+				"key":     key,
+			},
+		})
+}
+
+func (h *apiKeyHandler) Delete(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	apiKeyID, err := lib.ContextParamUUID(c, "apiKeyID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid api key id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = h.app.Repositories.APIKey.Delete(c.UserContext(), uid, apiKeyID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "api key not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.APIKey]{
+			Message: "data has been deleted successfully",
+		})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type auditLogHandler struct {
+	app *app.Application
+}
+
+func (h *auditLogHandler) Index(c *fiber.Ctx) error {
+	var dto dto.AuditLogPagination
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	// the dto is validated, parsing can't fail anymore
+	var filter repositories.AuditLogFilter
+
+	if dto.ActorID != nil {
+		filter.ActorID = uuid.MustParse(*dto.ActorID)
+	}
+
+	if dto.TargetType != nil {
+		filter.TargetType = *dto.TargetType
+	}
+
+	if dto.TargetID != nil {
+		filter.TargetID = uuid.MustParse(*dto.TargetID)
+	}
+
+	if dto.Action != nil {
+		filter.Action = *dto.Action
+	}
+
+	if dto.From != nil {
+		from, _ := time.Parse(time.RFC3339, *dto.From)
+		filter.From = &from
+	}
+
+	if dto.To != nil {
+		to, _ := time.Parse(time.RFC3339, *dto.To)
+		filter.To = &to
+	}
+
+	opts := &repositories.QueryOptions{
+		Offset: dto.Offset,
+		Limit:  dto.Limit,
+	}
+
+	logs, meta, err := h.app.Repositories.AuditLog.List(c.UserContext(), filter, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.AuditLog]{
+			Message: "list data has been retrieved successfully",
+			Data:    logs,
+			Meta: fiber.Map{
+				"total": meta.Total,
+			},
+		})
+}
+
+// newAuditLog describes an action of the request on a target, before and
+// after are the snapshots of the target, pass nil for the missing side.
+func newAuditLog(c *fiber.Ctx, action string, targetType string, targetID uuid.UUID, before any, after any) (*models.AuditLog, error) {
+	log := &models.AuditLog{
+		ID:         uuid.Must(uuid.NewV7()),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   &targetID,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}
+
+	if uid, err := lib.ContextGetUID(c); err == nil {
+		log.ActorID = &uid
+	}
+
+	if organizationID := lib.ContextGetOrganizationID(c); organizationID != uuid.Nil {
+		log.OrganizationID = &organizationID
+	}
+
+	if requestID := lib.ContextGetRequestID(c); requestID != "" {
+		log.RequestID = &requestID
+	}
+
+	var err error
+
+	if before != nil {
+		log.Before, err = json.Marshal(before)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if after != nil {
+		log.After, err = json.Marshal(after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return log, nil
+}
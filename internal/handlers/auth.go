@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gofi/internal/app"
@@ -35,9 +39,7 @@ func (h *authHandler) SignUp(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
@@ -61,12 +63,12 @@ func (h *authHandler) SignUp(c *fiber.Ctx) error {
 			return err
 		}
 
-		err = h.app.Repositories.User.InsertExec(tx, user)
+		err = h.app.Repositories.User.InsertExec(c.UserContext(), tx, user)
 		if err != nil {
 			return err
 		}
 
-		jsonWebToken := jwt.New(&h.app.Config.App)
+		jsonWebToken := jwt.New(&h.app.Config.App, h.app.Keyring)
 		token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
 			UID:       user.ID.String(),
 			Secret:    h.app.Config.App.JWTSecret,
@@ -80,13 +82,11 @@ func (h *authHandler) SignUp(c *fiber.Ctx) error {
 		userVerifyAccount.Token = token
 		userVerifyAccount.ExpiresAt = time.Unix(expiresIn, 0)
 
-		return h.app.Repositories.UserVerifyAccount.InsertExec(tx, userVerifyAccount)
+		return h.app.Repositories.UserVerifyAccount.InsertExec(c.UserContext(), tx, userVerifyAccount)
 	})
 
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	link := fmt.Sprintf("%s/verify?token=%s", h.app.Config.App.ClientURL, userVerifyAccount.Token)
@@ -101,16 +101,14 @@ func (h *authHandler) SignUp(c *fiber.Ctx) error {
 		AppName:  h.app.Config.App.Name,
 	}
 
-	_, err = h.app.Services.Email.SendEmail(services.SendEmailParams{
+	_, err = h.app.Services.Email.SendEmail(c.UserContext(), services.SendEmailParams{
 		Subject:      "Verify your email address",
 		To:           dto.Email,
 		Data:         emailForm,
 		HtmlTemplate: "templates/emails/registration.html",
 	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{
@@ -118,6 +116,11 @@ func (h *authHandler) SignUp(c *fiber.Ctx) error {
 	})
 }
 
+// dummyPasswordHash is hashed once, on the first sign in.
+var dummyPasswordHash = sync.OnceValues(func() (string, error) {
+	return argon2.New().Generate("gofi-dummy-password")
+})
+
 func (h *authHandler) SignIn(c *fiber.Ctx) error {
 	var dto dto.AuthSignIn
 
@@ -126,101 +129,122 @@ func (h *authHandler) SignIn(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
-	user, err := h.app.Repositories.User.GetByEmail(dto.Email)
+	retryAfter, err := h.app.Services.Lockout.Reserve(c.UserContext(), dto.Email, c.IP())
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
+		return err
+	}
+
+	if retryAfter > 0 {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"message":     "Too many failed sign in attempts, please try again later",
+			"retry_after": seconds,
 		})
 	}
 
-	hash := argon2.New()
-	match, err := hash.Compare(*user.Password, dto.Password)
+	user, err := h.app.Repositories.User.GetByEmail(c.UserContext(), dto.Email)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
+
+	// unknown emails and accounts without a password get the same response
+	// as a wrong password, and are compared against a dummy hash so they take
+	// as long. This endpoint can't be used to enumerate accounts.
+	encodedHash, err := dummyPasswordHash()
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
+	}
+
+	hasPassword := user != nil && user.Password != nil
+	if hasPassword {
+		encodedHash = *user.Password
+	}
+
+	match, err := argon2.New().Compare(encodedHash, dto.Password)
+	if err != nil {
+		return err
 	}
 
+	match = match && hasPassword
+
 	if !match {
+		locked, err := h.app.Services.Lockout.RegisterFailure(c.UserContext(), dto.Email)
+		if err != nil {
+			return err
+		}
+
+		if locked && user != nil {
+			err = h.sendAccountLockedEmail(c.UserContext(), user)
+			if err != nil {
+				h.app.Logger.Error("failed to send account locked email", "error", err.Error())
+			}
+
+			err = h.auditAccountLocked(c, user)
+			if err != nil {
+				h.app.Logger.Error("failed to audit account lock", "error", err.Error())
+			}
+		}
+
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 			"message": "Invalid email or password",
 		})
 	}
 
-	jsonWebToken := jwt.New(&h.app.Config.App)
-	token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
-		UID:       user.ID.String(),
-		Secret:    h.app.Config.App.JWTSecret,
-		ExpiresAt: "1", // 1 day
-	})
+	err = h.app.Services.Lockout.Reset(c.UserContext(), dto.Email, c.IP())
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
-	session := &models.Session{
-		Base: models.Base{
-			ID: uuid.Must(uuid.NewV7()),
-		},
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Unix(expiresIn, 0),
-		IPAddress: c.IP(),
-		UserAgent: c.Get("User-Agent"),
+	return h.signIn(c, user, "Sign in successfully")
+}
+
+// auditAccountLocked records the lockout, the request is anonymous so the
+// log has no actor.
+func (h *authHandler) auditAccountLocked(c *fiber.Ctx, user *models.User) error {
+	log, err := newAuditLog(c, models.AuditActionUserLock, models.AuditTargetUser, user.ID, nil, nil)
+	if err != nil {
+		return err
 	}
 
-	expiresAt := time.Now().Add(time.Hour * 24 * 60) // 60 days
-	rt := lib.NewRefreshToken(&h.app.Config.App)
-	refToken := rt.Generate(user.ID.String(), expiresAt.Unix())
+	return h.app.Repositories.AuditLog.Insert(c.UserContext(), log)
+}
 
-	refreshToken := &models.RefreshToken{
-		ID:        uuid.Must(uuid.NewV7()),
-		UserID:    user.ID,
-		Token:     refToken,
-		ExpiresAt: expiresAt,
-		CreatedAt: time.Now(),
-	}
+// sendAccountLockedEmail lets the user know sign in has been locked after
+// too many failed attempts.
+func (h *authHandler) sendAccountLockedEmail(ctx context.Context, user *models.User) error {
+	link := fmt.Sprintf("%s/forgot-password", h.app.Config.App.ClientURL)
 
-	var displayName string
+	fullname := user.FirstName
 	if user.LastName != nil && *user.LastName != "" {
-		displayName = strings.Join([]string{user.FirstName, *user.LastName}, " ")
-	} else {
-		displayName = user.FirstName
+		fullname = strings.Join([]string{user.FirstName, *user.LastName}, " ")
 	}
 
-	err = lib.WithTransaction(h.app.Repositories.Session.DB, func(tx *sql.Tx) error {
-		err := h.app.Repositories.Session.InsertExec(tx, session)
-		if err != nil {
-			return err
-		}
-
-		return h.app.Repositories.RefreshToken.InsertExec(tx, refreshToken)
-	})
-
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+	emailForm := struct {
+		Fullname  string
+		Link      string
+		AppName   string
+		LockedFor string
+	}{
+		Fullname:  fullname,
+		Link:      link,
+		AppName:   h.app.Config.App.Name,
+		LockedFor: lib.HumanizeDuration(services.SignInLockDuration),
 	}
 
-	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
-		Message: "Sign in successfully",
-		Data: fiber.Map{
-			"uid":           user.ID.String(),
-			"email":         user.Email,
-			"display_name":  displayName,
-			"is_admin":      user.RoleID.String() == constant.RoleAdmin,
-			"access_token":  token,
-			"refresh_token": refToken,
-		},
+	_, err := h.app.Services.Email.SendEmail(ctx, services.SendEmailParams{
+		Subject:      "Your account has been locked",
+		To:           user.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/account-locked.html",
 	})
+
+	return err
 }
 
 func (h *authHandler) VerifyRegistration(c *fiber.Ctx) error {
@@ -231,13 +255,11 @@ func (h *authHandler) VerifyRegistration(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
-	jsonWebToken := jwt.New(&h.app.Config.App)
+	jsonWebToken := jwt.New(&h.app.Config.App, h.app.Keyring)
 	claims, err := jsonWebToken.Verify(dto.Token)
 	if err != nil {
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
@@ -247,11 +269,9 @@ func (h *authHandler) VerifyRegistration(c *fiber.Ctx) error {
 
 	userID := uuid.Must(uuid.Parse(claims.UID))
 
-	userVerifyAccount, err := h.app.Repositories.UserVerifyAccount.Get(userID, dto.Token)
+	userVerifyAccount, err := h.app.Repositories.UserVerifyAccount.Get(c.UserContext(), userID, dto.Token)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	if userVerifyAccount.ExpiresAt.Before(time.Now()) {
@@ -260,20 +280,16 @@ func (h *authHandler) VerifyRegistration(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.app.Repositories.User.Get(userVerifyAccount.ID)
+	user, err := h.app.Repositories.User.Get(c.UserContext(), userVerifyAccount.ID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	user.ActiveAt = lib.TimePtr(time.Now())
 
-	err = h.app.Repositories.User.Update(user.ID, user)
+	err = h.app.Repositories.User.Update(c.UserContext(), user.ID, user)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{
@@ -289,11 +305,14 @@ func (h *authHandler) VerifySession(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.app.Repositories.User.Get(uid)
+	user, err := h.app.Repositories.User.Get(c.UserContext(), uid)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
+	}
+
+	err = withAvatarURLs(c.UserContext(), h.app, user)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[*models.User]{
@@ -310,9 +329,7 @@ func (h *authHandler) RefreshToken(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
@@ -323,79 +340,131 @@ func (h *authHandler) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.app.Repositories.User.Get(uid)
+	user, err := h.app.Repositories.User.Get(c.UserContext(), uid)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
-	rt, err := h.app.Repositories.RefreshToken.Get(uid, dto.Token)
+	jsonWebToken := jwt.New(&h.app.Config.App, h.app.Keyring)
+	extractToken, err := jsonWebToken.ExtractToken(c)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
 		})
 	}
 
-	if rt.ExpiresAt.Before(time.Now()) {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"message": "Token expired",
-		})
+	session, err := h.app.Repositories.Session.GetByUserToken(c.UserContext(), uid, extractToken)
+	if err != nil {
+		return err
 	}
 
-	jsonWebToken := jwt.New(&h.app.Config.App)
-	token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
-		UID:       user.ID.String(),
-		Secret:    h.app.Config.App.JWTSecret,
-		ExpiresAt: "1", // 1 day
-	})
+	// the active organization is kept, the middleware has already checked
+	// the user is still a member
+	token, expiresIn, err := h.generateAccessToken(user.ID, session.OrganizationID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
-	extractToken, err := jsonWebToken.ExtractToken(c)
+	var refToken string
+	var reused bool
+	var expired bool
+
+	err = lib.WithTransaction(h.app.Repositories.RefreshToken.DB, func(tx *sql.Tx) error {
+		rt, err := h.app.Repositories.RefreshToken.GetByTokenExec(c.UserContext(), tx, lib.HashToken(dto.Token))
+		if err != nil {
+			return err
+		}
+
+		if rt.UserID != uid {
+			return repositories.ErrRecordNotFound
+		}
+
+		// a revoked token presented again means it was stolen, either by the
+		// attacker or the legitimate client, so the whole family is revoked
+		if rt.RevokedAt != nil {
+			reused = true
+
+			err = h.app.Repositories.Session.DeleteByRefreshTokenFamilyExec(c.UserContext(), tx, rt.FamilyID)
+			if err != nil {
+				return err
+			}
+
+			return h.app.Repositories.RefreshToken.RevokeFamilyExec(c.UserContext(), tx, rt.FamilyID)
+		}
+
+		if rt.ExpiresAt.Before(time.Now()) {
+			expired = true
+			return nil
+		}
+
+		rt.RevokedAt = lib.TimePtr(time.Now())
+
+		err = h.app.Repositories.RefreshToken.UpdateExec(c.UserContext(), tx, rt.ID, rt)
+		if err != nil {
+			return err
+		}
+
+		var refreshToken *models.RefreshToken
+		refreshToken, refToken = h.newRefreshToken(user.ID, session.ID, rt.FamilyID)
+
+		err = h.app.Repositories.RefreshToken.InsertExec(c.UserContext(), tx, refreshToken)
+		if err != nil {
+			return err
+		}
+
+		session.Token = token
+		session.ExpiresAt = time.Unix(expiresIn, 0)
+		session.IPAddress = c.IP()
+		session.UserAgent = c.Get("User-Agent")
+
+		return h.app.Repositories.Session.UpdateExec(c.UserContext(), tx, session.ID, session)
+	})
+
 	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Invalid refresh token",
+			})
+		}
+
+		return err
+	}
+
+	if reused {
 		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-			"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
+			"message": "Refresh token has been revoked, please sign in again",
 		})
 	}
 
-	session, err := h.app.Repositories.Session.GetByUserToken(uid, extractToken)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
+	if expired {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "Token expired",
 		})
 	}
 
-	session.Token = token
-	session.ExpiresAt = time.Unix(expiresIn, 0)
-	session.IPAddress = c.IP()
-	session.UserAgent = c.Get("User-Agent")
-
-	err = h.app.Repositories.Session.Update(session.ID, session)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+	var displayName string
+	if user.LastName != nil && *user.LastName != "" {
+		displayName = strings.Join([]string{user.FirstName, *user.LastName}, " ")
+	} else {
+		displayName = user.FirstName
 	}
 
 	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
 		Message: "Refresh token successfully",
 		Data: fiber.Map{
-			"uid":           user.ID.String(),
-			"email":         user.Email,
-			"display_name":  strings.Join([]string{user.FirstName, *user.LastName}, " "),
-			"is_admin":      user.RoleID.String() == constant.RoleAdmin,
-			"access_token":  token,
-			"refresh_token": dto.Token,
+			"uid":             user.ID.String(),
+			"email":           user.Email,
+			"display_name":    displayName,
+			"is_admin":        user.RoleID.String() == constant.RoleAdmin,
+			"organization_id": session.OrganizationID,
+			"access_token":    token,
+			"refresh_token":   refToken,
 		},
 	})
 }
 
 func (h *authHandler) SignOut(c *fiber.Ctx) error {
-	jwt := jwt.New(&h.app.Config.App)
+	jwt := jwt.New(&h.app.Config.App, h.app.Keyring)
 
 	extractToken, err := jwt.ExtractToken(c)
 	if err != nil {
@@ -411,11 +480,9 @@ func (h *authHandler) SignOut(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.Session.Delete(uid, extractToken)
+	err = h.app.Repositories.Session.Delete(c.UserContext(), uid, extractToken)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(fiber.Map{
@@ -423,243 +490,443 @@ func (h *authHandler) SignOut(c *fiber.Ctx) error {
 	})
 }
 
-func (h *authHandler) GoogleAuthURL(c *fiber.Ctx) error {
-	url, err := h.app.Services.Google.AuthCodeURL()
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
-	}
-
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"url": url,
-	})
-}
-
-func (h *authHandler) GoogleAuthCallback(c *fiber.Ctx) error {
-	var dto dto.AuthGoogle
+func (h *authHandler) ForgotPassword(c *fiber.Ctx) error {
+	var dto dto.AuthForgotPassword
 
-	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
 		switch e := err.(type) {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
-	rawURL := fmt.Sprintf("%s/v1/auth/google/callback?state=%s&code=%s", h.app.Config.App.ServerURL, dto.State, dto.Code)
-	state, code, err := h.app.Services.Google.URLParse(rawURL)
+	// the reset is sent in the background and the same response is returned
+	// right away whether the email exists or not, neither the body nor the
+	// timing can be used to enumerate accounts
+	email := strings.Clone(dto.Email)
+	go func() {
+		err := h.sendPasswordReset(context.WithoutCancel(c.UserContext()), email)
+		if err != nil {
+			h.app.Logger.Error("failed to send password reset email", "error", err.Error())
+		}
+	}()
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "If the email is registered, a password reset link has been sent",
+	})
+}
+
+// sendPasswordReset emails a password reset link to the user of the email,
+// unknown emails are ignored.
+func (h *authHandler) sendPasswordReset(ctx context.Context, email string) error {
+	user, err := h.app.Repositories.User.GetByEmail(ctx, email)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil
+		}
+
+		return err
 	}
 
-	result, err := h.app.Services.Google.Authenticate(state, code)
+	token, err := lib.GenerateRandomToken(32)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
-	var user *models.User
-	var accessToken string
-	var refreshToken string
-	var userID uuid.UUID
+	passwordReset := &models.PasswordReset{
+		ID:        uuid.Must(uuid.NewV7()),
+		UserID:    user.ID,
+		Token:     lib.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour), // 1 hour
+	}
 
-	user, err = h.app.Repositories.User.GetByEmail(result.UserInfo.Email)
-	// if errors
-	if err != nil {
-		// if users not found, create a new user
-		if errors.Is(err, repositories.ErrRecordNotFound) {
-			// create user from oauth google
-			userID, accessToken, refreshToken, err = h.createUserOAuthGoogle(c, result)
-			if err != nil {
-				return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-					"message": err.Error(),
-				})
-			}
-		} else {
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
-		}
-	} else {
-		// if user is exists, just insert session and update user oauth
-		accessToken, refreshToken, err = h.updateUserOAuthGoogle(c, user, result)
+	err = lib.WithTransaction(h.app.Repositories.PasswordReset.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.PasswordReset.DeleteUnusedByUserIDExec(ctx, tx, user.ID)
 		if err != nil {
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 
-		userID = user.ID
+		return h.app.Repositories.PasswordReset.InsertExec(ctx, tx, passwordReset)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
-		Message: "Google auth successfully",
-		Data: fiber.Map{
-			"uid":           userID,
-			"email":         result.UserInfo.Email,
-			"display_name":  result.UserInfo.Name,
-			"is_admin":      false,
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-		},
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.app.Config.App.ClientURL, token)
+
+	fullname := user.FirstName
+	if user.LastName != nil && *user.LastName != "" {
+		fullname = strings.Join([]string{user.FirstName, *user.LastName}, " ")
+	}
+
+	emailForm := struct {
+		Fullname  string
+		Link      string
+		AppName   string
+		ExpiresIn string
+	}{
+		Fullname:  fullname,
+		Link:      link,
+		AppName:   h.app.Config.App.Name,
+		ExpiresIn: "1 hour",
+	}
+
+	_, err = h.app.Services.Email.SendEmail(ctx, services.SendEmailParams{
+		Subject:      "Reset your password",
+		To:           user.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/reset-password.html",
 	})
+	return err
 }
 
-func (h *authHandler) createUserOAuthGoogle(c *fiber.Ctx, authResponse *services.AuthenticateResponse) (userID uuid.UUID, accessToken string, refreshToken string, err error) {
-	user := &models.User{
-		Base: models.Base{
-			ID:        uuid.Must(uuid.NewV7()),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		},
-		FirstName: authResponse.UserInfo.GivenName,
-		LastName:  lib.StringPtr(authResponse.UserInfo.FamilyName),
-		Email:     authResponse.UserInfo.Email,
-		ActiveAt:  lib.TimePtr(time.Now()),
-		RoleID:    uuid.MustParse(constant.RoleUser),
+func (h *authHandler) ResetPassword(c *fiber.Ctx) error {
+	var dto dto.AuthResetPassword
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
 	}
 
-	jsonWebToken := jwt.New(&h.app.Config.App)
-	token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
-		UID:       user.ID.String(),
-		Secret:    h.app.Config.App.JWTSecret,
-		ExpiresAt: "1", // 1 day
-	})
+	passwordReset, err := h.app.Repositories.PasswordReset.GetByToken(c.UserContext(), lib.HashToken(dto.Token))
 	if err != nil {
-		return uuid.Nil, "", "", err
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid or expired token",
+			})
+		}
+
+		return err
 	}
 
-	expiresAt := time.Now().Add(time.Hour * 24 * 60) // 60 days
-	rt := lib.NewRefreshToken(&h.app.Config.App)
-	refToken := rt.Generate(user.ID.String(), expiresAt.Unix())
+	hash := argon2.New()
+	password, err := hash.Generate(dto.Password)
+	if err != nil {
+		return err
+	}
 
-	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
-		err := h.app.Repositories.User.InsertExec(tx, user)
+	err = lib.WithTransaction(h.app.Repositories.PasswordReset.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.PasswordReset.ConsumeExec(c.UserContext(), tx, passwordReset.ID)
 		if err != nil {
 			return err
 		}
 
-		session := &models.Session{
-			Base: models.Base{
-				ID: uuid.Must(uuid.NewV7()),
-			},
-			UserID:    user.ID,
-			Token:     token,
-			ExpiresAt: time.Unix(expiresIn, 0),
-			IPAddress: c.IP(),
-			UserAgent: c.Get("User-Agent"),
+		err = h.app.Repositories.User.UpdatePasswordExec(c.UserContext(), tx, passwordReset.UserID, password)
+		if err != nil {
+			return err
 		}
 
-		err = h.app.Repositories.Session.InsertExec(tx, session)
+		// sign out every device, the old credentials may be compromised
+		err = h.app.Repositories.Session.DeleteByUserIDExec(c.UserContext(), tx, passwordReset.UserID)
 		if err != nil {
 			return err
 		}
 
-		refreshToken := &models.RefreshToken{
-			ID:        uuid.Must(uuid.NewV7()),
-			UserID:    user.ID,
-			Token:     refToken,
-			ExpiresAt: expiresAt,
-			CreatedAt: time.Now(),
+		return h.app.Repositories.RefreshToken.RevokeByUserIDExec(c.UserContext(), tx, passwordReset.UserID)
+	})
+
+	if err != nil {
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid or expired token",
+			})
 		}
 
-		err = h.app.Repositories.RefreshToken.InsertExec(tx, refreshToken)
-		if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "Reset password successfully",
+	})
+}
+
+func (h *authHandler) MagicLink(c *fiber.Ctx) error {
+	var dto dto.AuthMagicLink
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
 			return err
 		}
+	}
+
+	allowed, err := h.app.Services.MagicLink.Allow(c.UserContext(), dto.Email)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+			"message": "Too many requests, please try again later",
+		})
+	}
+
+	// the same response is returned whether the email exists or not,
+	// so this endpoint can't be used to enumerate accounts
+	response := fiber.Map{
+		"message": "If the email is registered, a sign in link has been sent",
+	}
 
-		userOAuth := &models.UserOAuth{
-			ID:           uuid.Must(uuid.NewV7()),
-			UserID:       user.ID,
-			IdentityID:   authResponse.UserInfo.ID,
-			Provider:     "google",
-			AccessToken:  authResponse.Token.AccessToken,
-			RefreshToken: lib.StringPtr(authResponse.Token.RefreshToken),
-			ExpiresAt:    time.Unix(authResponse.Token.Expiry.Unix(), 0),
+	user, err := h.app.Repositories.User.GetByEmail(c.UserContext(), dto.Email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusOK).JSON(response)
 		}
 
-		return h.app.Repositories.UserOAuth.InsertExec(tx, userOAuth)
-	})
+		return err
+	}
 
+	token, err := h.app.Services.MagicLink.CreateToken(c.UserContext(), user.ID)
 	if err != nil {
-		return uuid.Nil, "", "", err
+		return err
 	}
 
-	return user.ID, token, refToken, nil
-}
+	link := fmt.Sprintf("%s/magic-link?token=%s", h.app.Config.App.ClientURL, token)
 
-func (h *authHandler) updateUserOAuthGoogle(c *fiber.Ctx, user *models.User, authResponse *services.AuthenticateResponse) (accessToken string, refreshToken string, err error) {
-	jsonWebToken := jwt.New(&h.app.Config.App)
-	token, expiresIn, err := jsonWebToken.Generate(&jwt.JWTPayload{
-		UID:       user.ID.String(),
-		Secret:    h.app.Config.App.JWTSecret,
-		ExpiresAt: "1", // 1 day
+	fullname := user.FirstName
+	if user.LastName != nil && *user.LastName != "" {
+		fullname = strings.Join([]string{user.FirstName, *user.LastName}, " ")
+	}
+
+	emailForm := struct {
+		Fullname  string
+		Link      string
+		AppName   string
+		ExpiresIn string
+	}{
+		Fullname:  fullname,
+		Link:      link,
+		AppName:   h.app.Config.App.Name,
+		ExpiresIn: "15 minutes",
+	}
+
+	_, err = h.app.Services.Email.SendEmail(c.UserContext(), services.SendEmailParams{
+		Subject:      "Your sign in link",
+		To:           user.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/magic-link.html",
 	})
 	if err != nil {
-		return "", "", err
+		return err
 	}
 
-	expiresAt := time.Now().Add(time.Hour * 24 * 60) // 60 days
-	rt := lib.NewRefreshToken(&h.app.Config.App)
-	refToken := rt.Generate(user.ID.String(), expiresAt.Unix())
+	return c.Status(http.StatusOK).JSON(response)
+}
 
-	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
-		userOAuth, err := h.app.Repositories.UserOAuth.GetByUserProviderExec(tx, user.ID, "google")
-		if err != nil {
+func (h *authHandler) MagicLinkVerify(c *fiber.Ctx) error {
+	var dto dto.AuthMagicLinkVerify
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
 			return err
 		}
+	}
+
+	userID, err := h.app.Services.MagicLink.ConsumeToken(c.UserContext(), dto.Token)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid or expired token",
+		})
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid or expired token",
+			})
+		}
+
+		return err
+	}
 
-		userOAuth.AccessToken = authResponse.Token.AccessToken
-		userOAuth.RefreshToken = lib.StringPtr(authResponse.Token.RefreshToken)
-		userOAuth.ExpiresAt = time.Unix(authResponse.Token.Expiry.Unix(), 0)
+	return h.signIn(c, user, "Sign in successfully")
+}
+
+// signIn creates the session of an authenticated user, unless MFA is enabled
+// in which case a challenge to complete with MFAVerify is returned instead.
+// Every way of signing in goes through it, whatever proved the identity.
+func (h *authHandler) signIn(c *fiber.Ctx, user *models.User, message string) error {
+	if reason := signInRefusal(user); reason != "" {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": reason,
+		})
+	}
 
-		user.FirstName = authResponse.UserInfo.GivenName
-		user.LastName = lib.StringPtr(authResponse.UserInfo.FamilyName)
-		user.Email = authResponse.UserInfo.Email
+	mfa, err := h.app.Repositories.UserMFA.GetByUserID(c.UserContext(), user.ID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
 
-		err = h.app.Repositories.User.UpdateExec(tx, user.ID, user)
+	// the session is only created once the second factor is verified
+	if mfa != nil && mfa.Enabled() {
+		mfaToken, err := h.app.Services.MFA.CreateChallenge(c.UserContext(), user.ID)
 		if err != nil {
 			return err
 		}
 
-		session := &models.Session{
-			Base: models.Base{
-				ID: uuid.Must(uuid.NewV7()),
+		return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+			Message: "MFA verification required",
+			Data: fiber.Map{
+				"mfa_required": true,
+				"mfa_token":    mfaToken,
 			},
-			UserID:    user.ID,
-			Token:     token,
-			ExpiresAt: time.Unix(expiresIn, 0),
-			IPAddress: c.IP(),
-			UserAgent: c.Get("User-Agent"),
-		}
+		})
+	}
 
-		err = h.app.Repositories.Session.InsertExec(tx, session)
-		if err != nil {
-			return err
-		}
+	return h.createSession(c, user, message)
+}
 
-		refreshToken := &models.RefreshToken{
-			ID:        uuid.Must(uuid.NewV7()),
-			UserID:    user.ID,
-			Token:     refToken,
-			ExpiresAt: expiresAt,
-			CreatedAt: time.Now(),
-		}
+// signInRefusal is why a blocked or not verified account can't sign in, it
+// is empty when the user can.
+func signInRefusal(user *models.User) string {
+	switch {
+	case user.BlockedAt != nil:
+		return "Your account has been blocked"
+	case user.ActiveAt == nil:
+		return "Your account has not been verified"
+	}
+
+	return ""
+}
+
+// signInMethodCount counts the ways the user can sign in: the password, the
+// linked OAuth identities and the passkeys. The last one can't be removed.
+func (h *authHandler) signInMethodCount(ctx context.Context, uid uuid.UUID) (int, error) {
+	hasPassword, err := h.app.Repositories.User.HasPassword(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+
+	identities, err := h.app.Repositories.UserOAuth.ListByUserID(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+
+	credentials, err := h.app.Repositories.UserCredential.ListByUserID(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+
+	count := len(identities) + len(credentials)
+	if hasPassword {
+		count++
+	}
+
+	return count, nil
+}
+
+// createSession signs the user in, creating the Session and RefreshToken
+// and responding with the tokens.
+func (h *authHandler) createSession(c *fiber.Ctx, user *models.User, message string) error {
+	memberships, err := h.app.Repositories.OrganizationMember.ListByUserID(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	// the oldest membership is active until the user switches organization
+	var organizationID *uuid.UUID
+	if len(memberships) > 0 {
+		organizationID = &memberships[0].OrganizationID
+	}
+
+	token, expiresIn, err := h.generateAccessToken(user.ID, organizationID)
+	if err != nil {
+		return err
+	}
+
+	session := &models.Session{
+		Base: models.Base{
+			ID: uuid.Must(uuid.NewV7()),
+		},
+		UserID:    user.ID,
+		Token:     token,
+		ExpiresAt: time.Unix(expiresIn, 0),
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+
+		OrganizationID: organizationID,
+	}
+
+	refreshToken, refToken := h.newRefreshToken(user.ID, session.ID, uuid.Must(uuid.NewV7()))
+
+	var displayName string
+	if user.LastName != nil && *user.LastName != "" {
+		displayName = strings.Join([]string{user.FirstName, *user.LastName}, " ")
+	} else {
+		displayName = user.FirstName
+	}
 
-		err = h.app.Repositories.RefreshToken.InsertExec(tx, refreshToken)
+	err = lib.WithTransaction(h.app.Repositories.Session.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Session.InsertExec(c.UserContext(), tx, session)
 		if err != nil {
 			return err
 		}
 
-		return h.app.Repositories.UserOAuth.UpdateExec(tx, userOAuth.ID, userOAuth)
+		return h.app.Repositories.RefreshToken.InsertExec(c.UserContext(), tx, refreshToken)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+		Message: message,
+		Data: fiber.Map{
+			"uid":             user.ID.String(),
+			"email":           user.Email,
+			"display_name":    displayName,
+			"is_admin":        user.RoleID.String() == constant.RoleAdmin,
+			"organization_id": organizationID,
+			"access_token":    token,
+			"refresh_token":   refToken,
+		},
 	})
+}
+
+// generateAccessToken issues an access token for the user, organizationID is
+// the active organization or nil when there is none.
+func (h *authHandler) generateAccessToken(userID uuid.UUID, organizationID *uuid.UUID) (string, int64, error) {
+	payload := &jwt.JWTPayload{
+		UID:       userID.String(),
+		Secret:    h.app.Config.App.JWTSecret,
+		ExpiresAt: "1", // 1 day
+	}
+
+	if organizationID != nil {
+		payload.OrganizationID = organizationID.String()
+	}
+
+	jsonWebToken := jwt.New(&h.app.Config.App, h.app.Keyring)
+	return jsonWebToken.Generate(payload)
+}
 
-	return token, refToken, err
+// newRefreshToken issues a refresh token bound to the session and token family.
+// The returned model only holds the hash, the raw token is meant for the client.
+func (h *authHandler) newRefreshToken(userID uuid.UUID, sessionID uuid.UUID, familyID uuid.UUID) (*models.RefreshToken, string) {
+	expiresAt := time.Now().Add(time.Hour * 24 * 60) // 60 days
+	rt := lib.NewRefreshToken(&h.app.Config.App)
+	token := rt.Generate(userID.String(), expiresAt.Unix())
+
+	return &models.RefreshToken{
+		ID:        uuid.Must(uuid.NewV7()),
+		UserID:    userID,
+		FamilyID:  familyID,
+		SessionID: &sessionID,
+		Token:     lib.HashToken(token),
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}, token
 }
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/lib/argon2"
+	"gofi/internal/lib/totp"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/services"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const mfaRecoveryCodeCount = 10
+
+var errInvalidMFACode = errors.New("invalid mfa code")
+
+func (h *authHandler) MFAEnroll(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	mfa, err := h.app.Repositories.UserMFA.GetByUserID(c.UserContext(), uid)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
+
+	if mfa != nil && mfa.Enabled() {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "MFA is already enabled",
+		})
+	}
+
+	otp := totp.New()
+	secret, err := otp.GenerateSecret()
+	if err != nil {
+		return err
+	}
+
+	encryptedSecret, err := lib.NewEncryptor(&h.app.Config.App).Encrypt(secret)
+	if err != nil {
+		return err
+	}
+
+	// enrolling again replaces a pending secret that was never confirmed
+	err = lib.WithTransaction(h.app.Repositories.UserMFA.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.UserMFA.DeleteByUserIDExec(c.UserContext(), tx, uid)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserMFA.InsertExec(c.UserContext(), tx, &models.UserMFA{
+			Base: models.Base{
+				ID: uuid.Must(uuid.NewV7()),
+			},
+			UserID: uid,
+			Secret: encryptedSecret,
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+		Message: "Enroll MFA successfully",
+		Data: fiber.Map{
+			"secret": secret,
+			"uri":    otp.URI(secret, h.app.Config.App.Name, user.Email),
+		},
+	})
+}
+
+func (h *authHandler) MFAConfirm(c *fiber.Ctx) error {
+	var dto dto.AuthMFACode
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	mfa, err := h.app.Repositories.UserMFA.GetByUserID(c.UserContext(), uid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "MFA enrollment not found",
+			})
+		}
+
+		return err
+	}
+
+	if mfa.Enabled() {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "MFA is already enabled",
+		})
+	}
+
+	codes, err := totp.New().GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return err
+	}
+
+	hash := argon2.New()
+	recoveryCodes := make([]*models.UserRecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		hashedCode, err := hash.Generate(code)
+		if err != nil {
+			return err
+		}
+
+		recoveryCodes = append(recoveryCodes, &models.UserRecoveryCode{
+			ID:     uuid.Must(uuid.NewV7()),
+			UserID: uid,
+			Code:   hashedCode,
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.UserMFA.DB, func(tx *sql.Tx) error {
+		step, err := h.validateTOTP(mfa, dto.Code)
+		if err != nil {
+			return err
+		}
+
+		mfa.LastUsedStep = step
+		mfa.ConfirmedAt = lib.TimePtr(time.Now())
+
+		err = h.app.Repositories.UserMFA.UpdateExec(c.UserContext(), tx, mfa.ID, mfa)
+		if err != nil {
+			return err
+		}
+
+		err = h.app.Repositories.UserRecoveryCode.DeleteByUserIDExec(c.UserContext(), tx, uid)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserRecoveryCode.InsertExec(c.UserContext(), tx, recoveryCodes...)
+	})
+
+	if err != nil {
+		if errors.Is(err, errInvalidMFACode) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid code",
+			})
+		}
+
+		return err
+	}
+
+	// recovery codes are only shown once, only their hashes are stored
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+		Message: "Confirm MFA successfully",
+		Data: fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+func (h *authHandler) MFADisable(c *fiber.Ctx) error {
+	var dto dto.AuthMFACode
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	mfa, err := h.app.Repositories.UserMFA.GetByUserID(c.UserContext(), uid)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
+
+	if mfa == nil || !mfa.Enabled() {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "MFA is not enabled",
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.UserMFA.DB, func(tx *sql.Tx) error {
+		err := h.verifyMFACode(c.UserContext(), tx, mfa, dto.Code)
+		if err != nil {
+			return err
+		}
+
+		err = h.app.Repositories.UserRecoveryCode.DeleteByUserIDExec(c.UserContext(), tx, uid)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserMFA.DeleteByUserIDExec(c.UserContext(), tx, uid)
+	})
+
+	if err != nil {
+		if errors.Is(err, errInvalidMFACode) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid code",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "Disable MFA successfully",
+	})
+}
+
+func (h *authHandler) MFAVerify(c *fiber.Ctx) error {
+	var dto dto.AuthMFAVerify
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	userID, err := h.app.Services.MFA.GetChallenge(c.UserContext(), dto.MFAToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidChallenge):
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Invalid or expired MFA token",
+			})
+		case errors.Is(err, services.ErrTooManyAttempts):
+			return c.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+				"message": "Too many attempts, please sign in again",
+			})
+		default:
+			return err
+		}
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	// the account may have been blocked since the challenge was issued
+	if reason := signInRefusal(user); reason != "" {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": reason,
+		})
+	}
+
+	// the code and the challenge are consumed together, a replayed code or
+	// a second request with the same challenge is rejected
+	err = lib.WithTransaction(h.app.Repositories.UserMFA.DB, func(tx *sql.Tx) error {
+		mfa, err := h.app.Repositories.UserMFA.GetByUserIDExec(c.UserContext(), tx, userID)
+		if err != nil {
+			return err
+		}
+
+		err = h.verifyMFACode(c.UserContext(), tx, mfa, dto.Code)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Services.MFA.ConsumeChallenge(c.UserContext(), dto.MFAToken)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidMFACode):
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Invalid code",
+			})
+		case errors.Is(err, services.ErrInvalidChallenge):
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Invalid or expired MFA token",
+			})
+		default:
+			return err
+		}
+	}
+
+	return h.createSession(c, user, "Sign in successfully")
+}
+
+// verifyMFACode accepts either a TOTP code or an unused recovery code, both
+// are consumed so the same code can't be replayed.
+func (h *authHandler) verifyMFACode(ctx context.Context, tx *sql.Tx, mfa *models.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+
+	step, err := h.validateTOTP(mfa, code)
+	if err == nil {
+		err = h.app.Repositories.UserMFA.UseStepExec(ctx, tx, mfa.ID, step)
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return errInvalidMFACode
+		}
+		if err != nil {
+			return err
+		}
+
+		mfa.LastUsedStep = step
+		return nil
+	}
+
+	if !errors.Is(err, errInvalidMFACode) {
+		return err
+	}
+
+	recoveryCodes, err := h.app.Repositories.UserRecoveryCode.ListUnusedByUserIDExec(ctx, tx, mfa.UserID)
+	if err != nil {
+		return err
+	}
+
+	hash := argon2.New()
+	for _, recoveryCode := range recoveryCodes {
+		match, err := hash.Compare(recoveryCode.Code, strings.ToLower(code))
+		if err != nil {
+			return err
+		}
+
+		if match {
+			err = h.app.Repositories.UserRecoveryCode.ConsumeExec(ctx, tx, recoveryCode.ID)
+			if errors.Is(err, repositories.ErrEditConflict) {
+				return errInvalidMFACode
+			}
+
+			return err
+		}
+	}
+
+	return errInvalidMFACode
+}
+
+func (h *authHandler) validateTOTP(mfa *models.UserMFA, code string) (int64, error) {
+	secret, err := lib.NewEncryptor(&h.app.Config.App).Decrypt(mfa.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, valid, err := totp.New().Validate(secret, code, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if !valid {
+		return 0, errInvalidMFACode
+	}
+
+	return step, nil
+}
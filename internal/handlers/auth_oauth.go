@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/lib/constant"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/services"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var (
+	errOAuthEmailConflict   = errors.New("oauth email belongs to another account")
+	errOAuthIdentityLinked  = errors.New("oauth identity is linked to another user")
+	errOAuthProviderLinked  = errors.New("oauth provider is already linked")
+	errOAuthUserBlocked     = errors.New("user is blocked")
+	errOAuthEmailUnverified = errors.New("oauth email is not verified")
+)
+
+func (h *authHandler) OAuthURL(c *fiber.Ctx) error {
+	url, err := h.app.Services.OAuth.AuthCodeURL(c.UserContext(), c.Params("provider"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownOAuthProvider) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"url": url,
+	})
+}
+
+func (h *authHandler) OAuthCallback(c *fiber.Ctx) error {
+	var dto dto.AuthOAuthCallback
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	provider := c.Params("provider")
+
+	// set by OptionalAuthorization, links are completed by a signed in user
+	uid, _ := lib.ContextGetUID(c)
+
+	result, err := h.app.Services.OAuth.Authenticate(c.UserContext(), provider, dto.State, dto.Code, uid)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownOAuthProvider):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrOAuthLinkMismatch):
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrInvalidStateToken), errors.Is(err, services.ErrOAuthEmailUnavailable):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		default:
+			return err
+		}
+	}
+
+	if result.LinkUserID != uuid.Nil {
+		identity, err := h.linkOAuthIdentity(c.UserContext(), provider, result.LinkUserID, result)
+		if err != nil {
+			return h.oauthIdentityError(c, err)
+		}
+
+		return c.Status(http.StatusOK).JSON(types.ResponseSingleData[*models.UserOAuth]{
+			Message: "OAuth identity linked successfully",
+			Data:    identity,
+		})
+	}
+
+	user, err := h.provisionOAuthUser(c.UserContext(), provider, result)
+	if err != nil {
+		return h.oauthIdentityError(c, err)
+	}
+
+	return h.signIn(c, user, "OAuth sign in successfully")
+}
+
+// OAuthLink returns the authorization URL linking a provider to the account of
+// the signed in user. The provider redirects back to OAuthCallback, which
+// must be called with the token of the same user.
+func (h *authHandler) OAuthLink(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	url, err := h.app.Services.OAuth.LinkCodeURL(c.UserContext(), c.Params("provider"), uid)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownOAuthProvider) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"url": url,
+	})
+}
+
+func (h *authHandler) IdentityIndex(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	identities, err := h.app.Repositories.UserOAuth.ListByUserID(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.UserOAuth]{
+			Message: "list data has been retrieved successfully",
+			Data:    identities,
+			Meta: fiber.Map{
+				"total": len(identities),
+			},
+		})
+}
+
+// IdentityDelete unlinks an identity, unless it is the last way for the user
+// to sign in.
+func (h *authHandler) IdentityDelete(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	identityID, err := lib.ContextParamUUID(c, "identityID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid identity id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	count, err := h.signInMethodCount(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	// the identity being unlinked is one of the methods
+	if count <= 1 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "Can't unlink the last sign in method, set a password or add a passkey first",
+		})
+	}
+
+	err = h.app.Repositories.UserOAuth.Delete(c.UserContext(), uid, identityID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "identity not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[*models.UserOAuth]{
+		Message: "OAuth identity unlinked successfully",
+	})
+}
+
+func (h *authHandler) oauthIdentityError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errOAuthEmailConflict):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "An account with this email already exists, sign in and link the provider from your profile",
+		})
+	case errors.Is(err, errOAuthIdentityLinked):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "This provider account is already linked to another user",
+		})
+	case errors.Is(err, errOAuthProviderLinked):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "Another account of this provider is already linked, unlink it first",
+		})
+	case errors.Is(err, errOAuthEmailUnverified):
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": "The email of this provider account is not verified, verify it with the provider first",
+		})
+	case errors.Is(err, errOAuthUserBlocked):
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": "Your account has been blocked",
+		})
+	default:
+		return err
+	}
+}
+
+// provisionOAuthUser returns the user of an OAuth identity, creating the user
+// on first sign in. An existing account with the same email is only linked
+// when both the provider and the account have verified the email.
+func (h *authHandler) provisionOAuthUser(ctx context.Context, provider string, authResponse *services.AuthenticateResponse) (*models.User, error) {
+	identity, err := h.app.Repositories.UserOAuth.GetByProviderIdentity(ctx, provider, authResponse.UserInfo.ID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if identity != nil {
+		err = h.updateUserOAuth(ctx, identity, authResponse)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := h.app.Repositories.User.Get(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if user.BlockedAt != nil {
+			return nil, errOAuthUserBlocked
+		}
+
+		return user, nil
+	}
+
+	user, err := h.app.Repositories.User.GetByEmail(ctx, authResponse.UserInfo.Email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			// the account would be verified with an email the provider didn't
+			// verify, anyone could claim someone else's email
+			if !authResponse.UserInfo.EmailVerified {
+				return nil, errOAuthEmailUnverified
+			}
+
+			return h.createUserOAuth(ctx, provider, authResponse)
+		}
+
+		return nil, err
+	}
+
+	if !authResponse.UserInfo.EmailVerified || user.ActiveAt == nil {
+		return nil, errOAuthEmailConflict
+	}
+
+	_, err = h.linkOAuthIdentity(ctx, provider, user.ID, authResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createUserOAuth creates a verified user, the provider must have verified
+// the email.
+func (h *authHandler) createUserOAuth(ctx context.Context, provider string, authResponse *services.AuthenticateResponse) (*models.User, error) {
+	user := &models.User{
+		Base: models.Base{
+			ID:        uuid.Must(uuid.NewV7()),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		FirstName: authResponse.UserInfo.GivenName,
+		LastName:  lib.StringPtr(authResponse.UserInfo.FamilyName),
+		Email:     authResponse.UserInfo.Email,
+		ActiveAt:  lib.TimePtr(time.Now()),
+		RoleID:    uuid.MustParse(constant.RoleUser),
+	}
+
+	err := lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.InsertExec(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.UserOAuth.InsertExec(ctx, tx, newUserOAuth(provider, user.ID, authResponse))
+	})
+
+	if err != nil {
+		// the email belongs to an account which isn't verified yet
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return nil, errOAuthEmailConflict
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// linkOAuthIdentity links the identity to the user, refreshing its tokens when
+// it is already linked to them.
+func (h *authHandler) linkOAuthIdentity(ctx context.Context, provider string, userID uuid.UUID, authResponse *services.AuthenticateResponse) (*models.UserOAuth, error) {
+	identity, err := h.app.Repositories.UserOAuth.GetByProviderIdentity(ctx, provider, authResponse.UserInfo.ID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if identity != nil {
+		if identity.UserID != userID {
+			return nil, errOAuthIdentityLinked
+		}
+
+		return identity, h.updateUserOAuth(ctx, identity, authResponse)
+	}
+
+	identity = newUserOAuth(provider, userID, authResponse)
+
+	err = h.app.Repositories.UserOAuth.Insert(ctx, identity)
+	if err != nil {
+		// the user has another identity of the provider
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return nil, errOAuthProviderLinked
+		}
+
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (h *authHandler) updateUserOAuth(ctx context.Context, identity *models.UserOAuth, authResponse *services.AuthenticateResponse) error {
+	identity.AccessToken = authResponse.Token.AccessToken
+	identity.RefreshToken = lib.StringPtr(authResponse.Token.RefreshToken)
+	identity.ExpiresAt = time.Unix(authResponse.Token.Expiry.Unix(), 0)
+
+	return h.app.Repositories.UserOAuth.Update(ctx, identity.ID, identity)
+}
+
+func newUserOAuth(provider string, userID uuid.UUID, authResponse *services.AuthenticateResponse) *models.UserOAuth {
+	return &models.UserOAuth{
+		ID:           uuid.Must(uuid.NewV7()),
+		UserID:       userID,
+		IdentityID:   authResponse.UserInfo.ID,
+		Provider:     provider,
+		AccessToken:  authResponse.Token.AccessToken,
+		RefreshToken: lib.StringPtr(authResponse.Token.RefreshToken),
+		ExpiresAt:    time.Unix(authResponse.Token.Expiry.Unix(), 0),
+	}
+}
+
+// OAuthProviders lists the enabled providers so the sign in page can offer them.
+func (h *authHandler) OAuthProviders(c *fiber.Ctx) error {
+	providers := make([]string, 0, len(h.app.Services.OAuth.Providers))
+	for name := range h.app.Services.OAuth.Providers {
+		providers = append(providers, name)
+	}
+
+	slices.Sort(providers)
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[[]string]{
+		Message: "list data has been retrieved successfully",
+		Data:    providers,
+	})
+}
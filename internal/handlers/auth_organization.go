@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gofi/internal/lib"
+	"gofi/internal/lib/jwt"
+	"gofi/internal/repositories"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SwitchOrganization makes another organization of the user the active one,
+// the session gets a new access token carrying it while the refresh token
+// stays the same.
+func (h *authHandler) SwitchOrganization(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	// api keys have no session to switch
+	if _, ok := lib.ContextGetSessionID(c); !ok {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "organization can only be switched from a session",
+		})
+	}
+
+	_, err = h.app.Repositories.OrganizationMember.Get(c.UserContext(), organizationID, uid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{
+				"message": "Forbidden, you are not a member of the organization",
+			})
+		}
+
+		return err
+	}
+
+	extractToken, err := jwt.New(&h.app.Config.App, h.app.Keyring).ExtractToken(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
+		})
+	}
+
+	session, err := h.app.Repositories.Session.GetByUserToken(c.UserContext(), uid, extractToken)
+	if err != nil {
+		return err
+	}
+
+	token, expiresIn, err := h.generateAccessToken(uid, &organizationID)
+	if err != nil {
+		return err
+	}
+
+	session.Token = token
+	session.ExpiresAt = time.Unix(expiresIn, 0)
+	session.IPAddress = c.IP()
+	session.UserAgent = c.Get("User-Agent")
+	session.OrganizationID = &organizationID
+
+	err = h.app.Repositories.Session.Update(c.UserContext(), session.ID, session)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+		Message: "Switch organization successfully",
+		Data: fiber.Map{
+			"organization_id": organizationID,
+			"access_token":    token,
+		},
+	})
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"gofi/internal/lib"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/services"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func (h *authHandler) PasskeyRegisterBegin(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	user, err := h.webAuthnUser(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	creation, err := h.app.Services.WebAuthn.BeginRegistration(c.UserContext(), user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+		Message: "Begin passkey registration successfully",
+		Data:    creation,
+	})
+}
+
+// PasskeyRegisterFinish expects the credential created by the browser as the
+// request body, an optional name for the passkey is read from the query.
+func (h *authHandler) PasskeyRegisterFinish(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	user, err := h.webAuthnUser(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	credential, err := h.app.Services.WebAuthn.FinishRegistration(c.UserContext(), user, c.Body())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebAuthnSession) || errors.Is(err, services.ErrInvalidPasskey) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return err
+	}
+
+	transports := make(pq.StringArray, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+
+	userCredential := &models.UserCredential{
+		ID:              uuid.Must(uuid.NewV7()),
+		UserID:          uid,
+		Name:            c.Query("name", "Passkey"),
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		UserPresent:     credential.Flags.UserPresent,
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+
+	err = h.app.Repositories.UserCredential.Insert(c.UserContext(), userCredential)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "Passkey is already registered",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(types.ResponseSingleData[*models.UserCredential]{
+		Message: "Register passkey successfully",
+		Data:    userCredential,
+	})
+}
+
+func (h *authHandler) PasskeyLoginBegin(c *fiber.Ctx) error {
+	assertion, err := h.app.Services.WebAuthn.BeginLogin(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(types.ResponseSingleData[any]{
+		Message: "Begin passkey login successfully",
+		Data:    assertion,
+	})
+}
+
+// PasskeyLoginFinish expects the assertion returned by the browser as the
+// request body and signs the user in like SignIn does, the MFA challenge
+// included.
+func (h *authHandler) PasskeyLoginFinish(c *fiber.Ctx) error {
+	var userCredential *models.UserCredential
+
+	user, credential, err := h.app.Services.WebAuthn.FinishLogin(c.UserContext(), c.Body(), func(userID uuid.UUID, credentialID []byte) (*services.WebAuthnUser, error) {
+		var err error
+
+		userCredential, err = h.app.Repositories.UserCredential.GetByCredentialID(c.UserContext(), credentialID)
+		if err != nil {
+			return nil, err
+		}
+
+		if userCredential.UserID != userID {
+			return nil, services.ErrInvalidPasskey
+		}
+
+		return h.webAuthnUser(c.UserContext(), userID)
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebAuthnSession) || errors.Is(err, services.ErrInvalidPasskey) {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Invalid passkey",
+			})
+		}
+
+		return err
+	}
+
+	// a signature counter that didn't grow means the private key may exist
+	// on another authenticator
+	if credential.Authenticator.CloneWarning {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Invalid passkey",
+		})
+	}
+
+	userCredential.SignCount = int64(credential.Authenticator.SignCount)
+	userCredential.BackupState = credential.Flags.BackupState
+
+	err = h.app.Repositories.UserCredential.UpdateSignCount(c.UserContext(), userCredential.ID, userCredential)
+	if err != nil {
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Invalid passkey",
+			})
+		}
+
+		return err
+	}
+
+	return h.signIn(c, user.User, "Sign in successfully")
+}
+
+func (h *authHandler) PasskeyIndex(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	credentials, err := h.app.Repositories.UserCredential.ListByUserID(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.UserCredential]{
+			Message: "list data has been retrieved successfully",
+			Data:    credentials,
+			Meta: fiber.Map{
+				"total": len(credentials),
+			},
+		})
+}
+
+func (h *authHandler) PasskeyDelete(c *fiber.Ctx) error {
+	credentialID, err := lib.ContextParamUUID(c, "credentialID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid credential id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	count, err := h.signInMethodCount(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	// the passkey being deleted is one of the methods
+	if count <= 1 {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "Can't delete the last sign in method, set a password or link a provider first",
+		})
+	}
+
+	err = h.app.Repositories.UserCredential.Delete(c.UserContext(), uid, credentialID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "Passkey not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "data has been deleted successfully",
+	})
+}
+
+func (h *authHandler) webAuthnUser(ctx context.Context, userID uuid.UUID) (*services.WebAuthnUser, error) {
+	user, err := h.app.Repositories.User.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := h.app.Repositories.UserCredential.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &services.WebAuthnUser{User: user, Credentials: credentials}, nil
+}
@@ -3,19 +3,37 @@ package handlers
 import "gofi/internal/app"
 
 type Handlers struct {
-	Health  healthHandler
-	Role    roleHandler
-	User    userHandler
-	Auth    authHandler
-	Session sessionHandler
+	Health       healthHandler
+	Role         roleHandler
+	User         userHandler
+	Auth         authHandler
+	Session      sessionHandler
+	WellKnown    wellKnownHandler
+	OAuth        oauthHandler
+	OAuthClient  oauthClientHandler
+	APIKey       apiKeyHandler
+	Permission   permissionHandler
+	Organization organizationHandler
+	AuditLog     auditLogHandler
+	Upload       uploadHandler
+	Storage      storageHandler
 }
 
 func New(app *app.Application) Handlers {
 	return Handlers{
-		Health:  healthHandler{app: app},
-		Role:    roleHandler{app: app},
-		User:    userHandler{app: app},
-		Auth:    authHandler{app: app},
-		Session: sessionHandler{app: app},
+		Health:       healthHandler{app: app},
+		Role:         roleHandler{app: app},
+		User:         userHandler{app: app},
+		Auth:         authHandler{app: app},
+		Session:      sessionHandler{app: app},
+		WellKnown:    wellKnownHandler{app: app},
+		OAuth:        oauthHandler{app: app},
+		OAuthClient:  oauthClientHandler{app: app},
+		APIKey:       apiKeyHandler{app: app},
+		Permission:   permissionHandler{app: app},
+		Organization: organizationHandler{app: app},
+		AuditLog:     auditLogHandler{app: app},
+		Upload:       uploadHandler{app: app},
+		Storage:      storageHandler{app: app},
 	}
 }
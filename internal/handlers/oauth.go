@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/lib/argon2"
+	"gofi/internal/lib/jwt"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/services"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// oidcScopes are the scopes gofi can grant as an OpenID Connect provider.
+var oidcScopes = []string{"openid", "profile", "email"}
+
+type oauthHandler struct {
+	app *app.Application
+}
+
+// oauthError is the error response of the token and userinfo endpoints as
+// described by RFC 6749 section 5.2.
+func oauthError(c *fiber.Ctx, status int, code string, description string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+// Authorize is called by the consent screen of the client app with the
+// authorization request it received. When the user already granted the
+// requested scopes the authorization code is issued right away, otherwise
+// the screen has to ask the user and post the answer to Consent.
+func (h *oauthHandler) Authorize(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var dto dto.OAuthAuthorize
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	client, scopes, err := h.authorizationRequest(c.UserContext(), &dto)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	consent, err := h.app.Repositories.OAuthConsent.GetByUserClient(c.UserContext(), uid, client.ID)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
+
+	if consent == nil || !consent.Covers(scopes) {
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"message":          "Consent is required",
+			"consent_required": true,
+			"client": fiber.Map{
+				"name":      client.Name,
+				"client_id": client.ClientID,
+			},
+			"scopes": scopes,
+		})
+	}
+
+	redirectTo, err := h.issueAuthorizationCode(c.UserContext(), uid, &dto, scopes)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":     "Authorization granted",
+		"redirect_to": redirectTo,
+	})
+}
+
+// Consent records the answer of the user to the authorization request, the
+// client app redirects the browser to the returned location either way.
+func (h *oauthHandler) Consent(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var dto dto.OAuthAuthorizeConsent
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	client, scopes, err := h.authorizationRequest(c.UserContext(), &dto.OAuthAuthorize)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if !*dto.Approve {
+		params := url.Values{}
+		params.Set("error", "access_denied")
+		if dto.State != "" {
+			params.Set("state", dto.State)
+		}
+
+		return c.Status(http.StatusOK).JSON(fiber.Map{
+			"message":     "Authorization denied",
+			"redirect_to": redirectURL(dto.RedirectURI, params),
+		})
+	}
+
+	consentID, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	consent := &models.OAuthConsent{
+		ID:       consentID,
+		UserID:   uid,
+		ClientID: client.ID,
+		Scopes:   scopes,
+	}
+
+	err = h.app.Repositories.OAuthConsent.Upsert(c.UserContext(), consent)
+	if err != nil {
+		return err
+	}
+
+	redirectTo, err := h.issueAuthorizationCode(c.UserContext(), uid, &dto.OAuthAuthorize, scopes)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message":     "Authorization granted",
+		"redirect_to": redirectTo,
+	})
+}
+
+// Token exchanges an authorization code for an access token and an ID token.
+// Confidential clients authenticate with their secret, public clients with
+// the PKCE code verifier alone.
+func (h *oauthHandler) Token(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+
+	var dto dto.OAuthToken
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return oauthError(c, http.StatusBadRequest, "invalid_request", e.Error())
+		default:
+			return oauthError(c, http.StatusBadRequest, "invalid_request", err.Error())
+		}
+	}
+
+	clientID, clientSecret := dto.ClientID, dto.ClientSecret
+	if username, password, ok := basicAuth(c); ok {
+		clientID, clientSecret = username, password
+	}
+
+	client, err := h.app.Repositories.OAuthClient.GetByClientID(c.UserContext(), clientID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return oauthError(c, http.StatusUnauthorized, "invalid_client", "unknown client")
+		}
+
+		return oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	if !client.IsPublic() {
+		match, err := argon2.New().Compare(*client.ClientSecret, clientSecret)
+		if err != nil || !match {
+			return oauthError(c, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+		}
+	}
+
+	code, err := h.app.Services.OIDC.ConsumeAuthorizationCode(c.UserContext(), dto.Code)
+	if err != nil {
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", err.Error())
+	}
+
+	if code.ClientID != client.ClientID || code.RedirectURI != dto.RedirectURI {
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", services.ErrInvalidAuthorizationCode.Error())
+	}
+
+	if !lib.VerifyCodeChallenge(dto.CodeVerifier, code.CodeChallenge) {
+		return oauthError(c, http.StatusBadRequest, "invalid_grant", "invalid code verifier")
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), code.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return oauthError(c, http.StatusBadRequest, "invalid_grant", "user not found")
+		}
+
+		return oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	accessToken, err := h.app.Services.OIDC.CreateAccessToken(c.UserContext(), &services.AccessToken{
+		ClientID: client.ClientID,
+		UserID:   user.ID,
+		Scopes:   code.Scopes,
+	})
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	now := time.Now()
+	claims := userInfoClaims(user, code.Scopes)
+	claims["iss"] = h.app.Config.App.ServerURL
+	claims["aud"] = client.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(services.AccessTokenTTL).Unix()
+	claims["auth_time"] = code.AuthTime
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+
+	idToken, err := jwt.New(&h.app.Config.App, h.app.Keyring).SignIDToken(claims)
+	if err != nil {
+		return oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int64(services.AccessTokenTTL.Seconds()),
+		"id_token":     idToken,
+		"scope":        strings.Join(code.Scopes, " "),
+	})
+}
+
+// UserInfo returns the claims about the user allowed by the scopes of the
+// access token issued at the token endpoint.
+func (h *oauthHandler) UserInfo(c *fiber.Ctx) error {
+	token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !found || token == "" {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return oauthError(c, http.StatusUnauthorized, "invalid_token", "access token not found")
+	}
+
+	accessToken, err := h.app.Services.OIDC.GetAccessToken(c.UserContext(), token)
+	if err != nil {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+		return oauthError(c, http.StatusUnauthorized, "invalid_token", err.Error())
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), accessToken.UserID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return oauthError(c, http.StatusUnauthorized, "invalid_token", "user not found")
+		}
+
+		return oauthError(c, http.StatusInternalServerError, "server_error", err.Error())
+	}
+
+	return c.Status(http.StatusOK).JSON(userInfoClaims(user, accessToken.Scopes))
+}
+
+func (h *oauthHandler) ConsentIndex(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	consents, err := h.app.Repositories.OAuthConsent.ListByUserID(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.OAuthConsent]{
+			Message: "list data has been retrieved successfully",
+			Data:    consents,
+			Meta: fiber.Map{
+				"total": len(consents),
+			},
+		})
+}
+
+// ConsentDelete revokes the consent given to a client, the client has to ask
+// the user again on its next authorization request.
+func (h *oauthHandler) ConsentDelete(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	clientID, err := lib.ContextParamUUID(c, "clientID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid client id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = h.app.Repositories.OAuthConsent.Delete(c.UserContext(), uid, clientID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "consent not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.OAuthConsent]{
+			Message: "data has been deleted successfully",
+		})
+}
+
+// authorizationRequest checks the client, redirect URI and scopes of an
+// authorization request. The redirect URI must be checked before anything
+// is sent back to it.
+func (h *oauthHandler) authorizationRequest(ctx context.Context, dto *dto.OAuthAuthorize) (*models.OAuthClient, []string, error) {
+	client, err := h.app.Repositories.OAuthClient.GetByClientID(ctx, dto.ClientID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return nil, nil, errors.New("unknown client")
+		}
+
+		return nil, nil, err
+	}
+
+	if !client.AllowsRedirectURI(dto.RedirectURI) {
+		return nil, nil, errors.New("redirect uri is not registered for this client")
+	}
+
+	scopes := strings.Fields(dto.Scope)
+	if !slices.Contains(scopes, "openid") {
+		return nil, nil, errors.New("scope must include openid")
+	}
+
+	if !client.AllowsScopes(scopes) {
+		return nil, nil, errors.New("scope is not allowed for this client")
+	}
+
+	return client, scopes, nil
+}
+
+func (h *oauthHandler) issueAuthorizationCode(ctx context.Context, uid uuid.UUID, dto *dto.OAuthAuthorize, scopes []string) (string, error) {
+	code, err := h.app.Services.OIDC.CreateAuthorizationCode(ctx, &services.AuthorizationCode{
+		ClientID:      dto.ClientID,
+		UserID:        uid,
+		RedirectURI:   dto.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: dto.CodeChallenge,
+		Nonce:         dto.Nonce,
+		AuthTime:      time.Now().Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("code", code)
+	if dto.State != "" {
+		params.Set("state", dto.State)
+	}
+
+	return redirectURL(dto.RedirectURI, params), nil
+}
+
+// redirectURL appends params to the query of a registered redirect URI.
+func redirectURL(uri string, params url.Values) string {
+	if strings.Contains(uri, "?") {
+		return uri + "&" + params.Encode()
+	}
+
+	return uri + "?" + params.Encode()
+}
+
+// userInfoClaims returns the standard claims about user granted by scopes.
+func userInfoClaims(user *models.User, scopes []string) map[string]any {
+	claims := map[string]any{
+		"sub": user.ID.String(),
+	}
+
+	if slices.Contains(scopes, "email") {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.ActiveAt != nil
+	}
+
+	if slices.Contains(scopes, "profile") {
+		name := user.FirstName
+		claims["given_name"] = user.FirstName
+		if user.LastName != nil {
+			name += " " + *user.LastName
+			claims["family_name"] = *user.LastName
+		}
+
+		claims["name"] = name
+	}
+
+	return claims
+}
+
+// basicAuth reads client credentials sent with HTTP Basic authentication,
+// the form encoded client_secret_basic method of RFC 6749 section 2.3.1.
+func basicAuth(c *fiber.Ctx) (string, string, bool) {
+	req := http.Request{Header: http.Header{}}
+	req.Header.Set(fiber.HeaderAuthorization, c.Get(fiber.HeaderAuthorization))
+
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return "", "", false
+	}
+
+	username, err := url.QueryUnescape(username)
+	if err != nil {
+		return "", "", false
+	}
+
+	password, err = url.QueryUnescape(password)
+	if err != nil {
+		return "", "", false
+	}
+
+	return username, password, true
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/lib/argon2"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type oauthClientHandler struct {
+	app *app.Application
+}
+
+func (h *oauthClientHandler) Index(c *fiber.Ctx) error {
+	var dto dto.OAuthClientPagination
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	opts := &repositories.QueryOptions{
+		Offset: dto.Offset,
+		Limit:  dto.Limit,
+	}
+
+	clients, meta, err := h.app.Repositories.OAuthClient.List(c.UserContext(), opts)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.OAuthClient]{
+			Message: "list data has been retrieved successfully",
+			Data:    clients,
+			Meta: fiber.Map{
+				"total": meta.Total,
+			},
+		})
+}
+
+func (h *oauthClientHandler) Show(c *fiber.Ctx) error {
+	oauthClientID, err := lib.ContextParamUUID(c, "oauthClientID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid oauth client id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	client, err := h.app.Repositories.OAuthClient.Get(c.UserContext(), oauthClientID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.OAuthClient]{
+			Message: "get data has been retrieved successfully",
+			Data:    client,
+		})
+}
+
+// Create registers a client, the secret of a confidential client is only
+// returned once as it is stored hashed.
+func (h *oauthClientHandler) Create(c *fiber.Ctx) error {
+	var dto dto.OAuthClientCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	clientID, err := lib.GenerateRandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	client := &models.OAuthClient{
+		Base: models.Base{
+			ID: id,
+		},
+		Name:         dto.Name,
+		ClientID:     clientID,
+		RedirectURIs: pq.StringArray(dto.RedirectURIs),
+		Scopes:       pq.StringArray(dto.Scopes),
+	}
+
+	var clientSecret string
+	if !dto.Public {
+		clientSecret, err = lib.GenerateRandomToken(32)
+		if err != nil {
+			return err
+		}
+
+		hash, err := argon2.New().Generate(clientSecret)
+		if err != nil {
+			return err
+		}
+
+		client.ClientSecret = &hash
+	}
+
+	err = h.app.Repositories.OAuthClient.Insert(c.UserContext(), client)
+	if err != nil {
+		return err
+	}
+
+	data := fiber.Map{"client": client}
+	if clientSecret != "" {
+		data["client_secret"] = clientSecret
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[fiber.Map]{
+			Message: "data has been created successfully",
+			Data:    data,
+		})
+}
+
+func (h *oauthClientHandler) Update(c *fiber.Ctx) error {
+	oauthClientID, err := lib.ContextParamUUID(c, "oauthClientID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid oauth client id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.OAuthClientUpdate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	client, err := h.app.Repositories.OAuthClient.Get(c.UserContext(), oauthClientID)
+	if err != nil {
+		return err
+	}
+
+	if dto.Name != "" {
+		client.Name = dto.Name
+	}
+
+	if dto.RedirectURIs != nil {
+		client.RedirectURIs = pq.StringArray(dto.RedirectURIs)
+	}
+
+	if dto.Scopes != nil {
+		client.Scopes = pq.StringArray(dto.Scopes)
+	}
+
+	err = h.app.Repositories.OAuthClient.Update(c.UserContext(), oauthClientID, client)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.OAuthClient]{
+			Message: "data has been updated successfully",
+			Data:    client,
+		})
+}
+
+func (h *oauthClientHandler) Delete(c *fiber.Ctx) error {
+	oauthClientID, err := lib.ContextParamUUID(c, "oauthClientID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid oauth client id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = h.app.Repositories.OAuthClient.Delete(c.UserContext(), oauthClientID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.OAuthClient]{
+			Message: "data has been deleted successfully",
+		})
+}
@@ -0,0 +1,599 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/services"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const organizationInvitationTTL = 7 * 24 * time.Hour
+
+var (
+	errOrganizationNotMember   = errors.New("user is not a member of the organization")
+	errOrganizationOwner       = errors.New("the owner can't be removed, transfer the ownership first")
+	errInvitationEmailMismatch = errors.New("invitation was sent to another email")
+)
+
+type organizationHandler struct {
+	app *app.Application
+}
+
+func (h *organizationHandler) Index(c *fiber.Ctx) error {
+	var dto dto.OrganizationPagination
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	opts := &repositories.QueryOptions{
+		Offset: dto.Offset,
+		Limit:  dto.Limit,
+	}
+
+	organizations, meta, err := h.app.Repositories.Organization.List(c.UserContext(), opts)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.Organization]{
+			Message: "list data has been retrieved successfully",
+			Data:    organizations,
+			Meta: fiber.Map{
+				"total": meta.Total,
+			},
+		})
+}
+
+func (h *organizationHandler) Show(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	organization, err := h.app.Repositories.Organization.Get(c.UserContext(), organizationID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "organization not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Organization]{
+			Message: "get data has been retrieved successfully",
+			Data:    organization,
+		})
+}
+
+// Create adds an organization with the given user as its owner.
+func (h *organizationHandler) Create(c *fiber.Ctx) error {
+	var dto dto.OrganizationCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	owner, err := h.app.Repositories.User.Get(c.UserContext(), dto.OwnerID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "owner not found",
+			})
+		}
+
+		return err
+	}
+
+	organization := &models.Organization{
+		Base: models.Base{
+			ID: uuid.Must(uuid.NewV7()),
+		},
+		Name:    dto.Name,
+		Slug:    dto.Slug,
+		OwnerID: owner.ID,
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Organization.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Organization.InsertExec(c.UserContext(), tx, organization)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.OrganizationMember.InsertExec(c.UserContext(), tx, &models.OrganizationMember{
+			ID:             uuid.Must(uuid.NewV7()),
+			OrganizationID: organization.ID,
+			UserID:         owner.ID,
+			Role:           models.OrganizationRoleOwner,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "organization slug is already taken",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Organization]{
+			Message: "data has been created successfully",
+			Data:    organization,
+		})
+}
+
+func (h *organizationHandler) Update(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.OrganizationUpdate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	organization, err := h.app.Repositories.Organization.Get(c.UserContext(), organizationID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "organization not found",
+			})
+		}
+
+		return err
+	}
+
+	if dto.Name != "" {
+		organization.Name = dto.Name
+	}
+
+	if dto.Slug != "" {
+		organization.Slug = dto.Slug
+	}
+
+	err = h.app.Repositories.Organization.Update(c.UserContext(), organizationID, organization)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "organization slug is already taken",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Organization]{
+			Message: "data has been updated successfully",
+			Data:    organization,
+		})
+}
+
+func (h *organizationHandler) Delete(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = h.app.Repositories.Organization.Delete(c.UserContext(), organizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Organization]{
+			Message: "data has been deleted successfully",
+		})
+}
+
+// TransferOwnership makes another member the owner, the previous owner stays
+// in the organization as an admin.
+func (h *organizationHandler) TransferOwnership(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.OrganizationTransferOwnership
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	var organization *models.Organization
+
+	err = lib.WithTransaction(h.app.Repositories.Organization.DB, func(tx *sql.Tx) error {
+		var err error
+		organization, err = h.app.Repositories.Organization.GetExec(c.UserContext(), tx, organizationID)
+		if err != nil {
+			return err
+		}
+
+		_, err = h.app.Repositories.OrganizationMember.GetExec(c.UserContext(), tx, organizationID, dto.UserID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrRecordNotFound) {
+				return errOrganizationNotMember
+			}
+			return err
+		}
+
+		if organization.OwnerID == dto.UserID {
+			return nil
+		}
+
+		err = h.app.Repositories.OrganizationMember.UpdateRoleExec(c.UserContext(), tx, organizationID, organization.OwnerID, models.OrganizationRoleAdmin)
+		if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+			return err
+		}
+
+		err = h.app.Repositories.OrganizationMember.UpdateRoleExec(c.UserContext(), tx, organizationID, dto.UserID, models.OrganizationRoleOwner)
+		if err != nil {
+			return err
+		}
+
+		organization.OwnerID = dto.UserID
+		return h.app.Repositories.Organization.UpdateExec(c.UserContext(), tx, organizationID, organization)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "organization not found",
+			})
+		case errors.Is(err, errOrganizationNotMember):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "the new owner must be a member of the organization",
+			})
+		default:
+			return err
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Organization]{
+			Message: "ownership has been transferred successfully",
+			Data:    organization,
+		})
+}
+
+func (h *organizationHandler) MemberIndex(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	members, err := h.app.Repositories.OrganizationMember.ListByOrganizationID(c.UserContext(), organizationID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.OrganizationMember]{
+			Message: "list data has been retrieved successfully",
+			Data:    members,
+			Meta: fiber.Map{
+				"total": len(members),
+			},
+		})
+}
+
+// MemberDelete removes a member, the owner can only leave once the
+// ownership has been transferred.
+func (h *organizationHandler) MemberDelete(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	userID, err := lib.ContextParamUUID(c, "userID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid user id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Organization.DB, func(tx *sql.Tx) error {
+		member, err := h.app.Repositories.OrganizationMember.GetExec(c.UserContext(), tx, organizationID, userID)
+		if err != nil {
+			return err
+		}
+
+		if member.Role == models.OrganizationRoleOwner {
+			return errOrganizationOwner
+		}
+
+		return h.app.Repositories.OrganizationMember.DeleteExec(c.UserContext(), tx, organizationID, userID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "member not found",
+			})
+		case errors.Is(err, errOrganizationOwner):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		default:
+			return err
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "member has been removed successfully",
+	})
+}
+
+// MyIndex lists the organizations the signed in user is a member of.
+func (h *organizationHandler) MyIndex(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	memberships, err := h.app.Repositories.OrganizationMember.ListByUserID(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.OrganizationMember]{
+			Message: "list data has been retrieved successfully",
+			Data:    memberships,
+			Meta: fiber.Map{
+				"total":                  len(memberships),
+				"active_organization_id": lib.ContextGetOrganizationID(c),
+			},
+		})
+}
+
+// InvitationCreate emails an invitation to join the organization, only its
+// owner and admins can invite.
+func (h *organizationHandler) InvitationCreate(c *fiber.Ctx) error {
+	organizationID, err := lib.ContextParamUUID(c, "organizationID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid organization id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.OrganizationInvitationCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	member, err := h.app.Repositories.OrganizationMember.Get(c.UserContext(), organizationID, uid)
+	if err != nil && !errors.Is(err, repositories.ErrRecordNotFound) {
+		return err
+	}
+
+	if member == nil || !member.CanManage() {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": "Forbidden, only the owner and admins can invite members",
+		})
+	}
+
+	organization, err := h.app.Repositories.Organization.Get(c.UserContext(), organizationID)
+	if err != nil {
+		return err
+	}
+
+	inviter, err := h.app.Repositories.User.Get(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	token, err := lib.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	invitation := &models.OrganizationInvitation{
+		ID:             uuid.Must(uuid.NewV7()),
+		OrganizationID: organizationID,
+		InvitedBy:      uid,
+		Email:          strings.ToLower(dto.Email),
+		Role:           dto.Role,
+		Token:          lib.HashToken(token),
+		ExpiresAt:      time.Now().Add(organizationInvitationTTL),
+	}
+
+	err = h.app.Repositories.OrganizationInvitation.Insert(c.UserContext(), invitation)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", h.app.Config.App.ClientURL, token)
+
+	inviterName := inviter.FirstName
+	if inviter.LastName != nil && *inviter.LastName != "" {
+		inviterName = strings.Join([]string{inviter.FirstName, *inviter.LastName}, " ")
+	}
+
+	emailForm := struct {
+		InviterName      string
+		OrganizationName string
+		Link             string
+		AppName          string
+		ExpiresIn        string
+	}{
+		InviterName:      inviterName,
+		OrganizationName: organization.Name,
+		Link:             link,
+		AppName:          h.app.Config.App.Name,
+		ExpiresIn:        "7 days",
+	}
+
+	_, err = h.app.Services.Email.SendEmail(c.UserContext(), services.SendEmailParams{
+		Subject:      fmt.Sprintf("You have been invited to join %s", organization.Name),
+		To:           invitation.Email,
+		Data:         emailForm,
+		HtmlTemplate: "templates/emails/organization-invitation.html",
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.OrganizationInvitation]{
+			Message: "invitation has been sent successfully",
+			Data:    invitation,
+		})
+}
+
+// InvitationAccept adds the signed in user to the organization of the
+// invitation, which must have been sent to their email.
+func (h *organizationHandler) InvitationAccept(c *fiber.Ctx) error {
+	var dto dto.OrganizationInvitationAccept
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	member := &models.OrganizationMember{
+		ID:     uuid.Must(uuid.NewV7()),
+		UserID: user.ID,
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.OrganizationMember.DB, func(tx *sql.Tx) error {
+		invitation, err := h.app.Repositories.OrganizationInvitation.GetByTokenExec(c.UserContext(), tx, lib.HashToken(dto.Token))
+		if err != nil {
+			return err
+		}
+
+		if invitation.IsExpired() {
+			return repositories.ErrRecordNotFound
+		}
+
+		if !strings.EqualFold(invitation.Email, user.Email) {
+			return errInvitationEmailMismatch
+		}
+
+		member.OrganizationID = invitation.OrganizationID
+		member.Role = invitation.Role
+
+		err = h.app.Repositories.OrganizationMember.InsertExec(c.UserContext(), tx, member)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.OrganizationInvitation.AcceptExec(c.UserContext(), tx, invitation.ID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound), errors.Is(err, repositories.ErrEditConflict):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid or expired invitation",
+			})
+		case errors.Is(err, errInvitationEmailMismatch):
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case errors.Is(err, repositories.ErrInsertDuplicate):
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "you are already a member of the organization",
+			})
+		default:
+			return err
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.OrganizationMember]{
+			Message: "invitation has been accepted successfully",
+			Data:    member,
+		})
+}
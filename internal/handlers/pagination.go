@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"gofi/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// paginationMeta is the meta of a list, the total is left out when it wasn't
+// counted and the cursors when there is no page on their side.
+func paginationMeta(meta repositories.PaginationMetadata, opts *repositories.QueryOptions) fiber.Map {
+	m := fiber.Map{}
+
+	if !opts.SkipTotal {
+		m["total"] = meta.Total
+	}
+
+	if meta.NextCursor != "" {
+		m["next_cursor"] = meta.NextCursor
+	}
+
+	if meta.PrevCursor != "" {
+		m["prev_cursor"] = meta.PrevCursor
+	}
+
+	return m
+}
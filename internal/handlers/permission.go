@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type permissionHandler struct {
+	app *app.Application
+}
+
+func (h *permissionHandler) Index(c *fiber.Ctx) error {
+	var dto dto.PermissionPagination
+
+	if err := lib.ValidateRequestQuery(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	opts := &repositories.QueryOptions{
+		Offset: dto.Offset,
+		Limit:  dto.Limit,
+	}
+
+	permissions, meta, err := h.app.Repositories.Permission.List(c.UserContext(), opts)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.Permission]{
+			Message: "list data has been retrieved successfully",
+			Data:    permissions,
+			Meta: fiber.Map{
+				"total": meta.Total,
+			},
+		})
+}
+
+func (h *permissionHandler) Show(c *fiber.Ctx) error {
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	permission, err := h.app.Repositories.Permission.Get(c.UserContext(), permissionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "permission not found",
+			})
+		}
+
+		return err
+	}
+
+	lib.ContextSetETag(c, permission.UpdatedAt)
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Permission]{
+			Message: "get data has been retrieved successfully",
+			Data:    permission,
+		})
+}
+
+func (h *permissionHandler) Create(c *fiber.Ctx) error {
+	var dto dto.PermissionCreate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	permissionID, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	permission := &models.Permission{
+		Base: models.Base{
+			ID: permissionID,
+		},
+		Name:        dto.Name,
+		Description: dto.Description,
+	}
+
+	err = h.app.Repositories.Permission.Insert(c.UserContext(), permission)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "permission already exists",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Permission]{
+			Message: "data has been created successfully",
+			Data:    permission,
+		})
+}
+
+func (h *permissionHandler) Update(c *fiber.Ctx) error {
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.PermissionUpdate
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	permission, err := h.app.Repositories.Permission.Get(c.UserContext(), permissionID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "permission not found",
+			})
+		}
+
+		return err
+	}
+
+	if !lib.ContextIfMatch(c, permission.UpdatedAt) {
+		return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+			"message": "permission has been modified since it was fetched",
+		})
+	}
+
+	if dto.Name != "" {
+		permission.Name = dto.Name
+	}
+
+	if dto.Description != nil {
+		permission.Description = dto.Description
+	}
+
+	err = h.app.Repositories.Permission.Update(c.UserContext(), permissionID, permission)
+	if err != nil {
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "permission has been modified by another request",
+			})
+		}
+
+		if errors.Is(err, repositories.ErrInsertDuplicate) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "permission already exists",
+			})
+		}
+
+		return err
+	}
+
+	lib.ContextSetETag(c, permission.UpdatedAt)
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Permission]{
+			Message: "data has been updated successfully",
+			Data:    permission,
+		})
+}
+
+func (h *permissionHandler) Delete(c *fiber.Ctx) error {
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	if err := h.checkPrecondition(c, permissionID); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "permission not found",
+			})
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": "permission has been modified since it was fetched",
+			})
+		}
+
+		return err
+	}
+
+	err = h.app.Repositories.Permission.Delete(c.UserContext(), permissionID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Permission]{
+			Message: "data has been deleted successfully",
+		})
+}
+
+// checkPrecondition reads the permission to compare its version when the request
+// has an If-Match header.
+func (h *permissionHandler) checkPrecondition(c *fiber.Ctx, permissionID uuid.UUID) error {
+	if c.Get(fiber.HeaderIfMatch) == "" {
+		return nil
+	}
+
+	permission, err := h.app.Repositories.Permission.Get(c.UserContext(), permissionID)
+	if err != nil {
+		return err
+	}
+
+	if !lib.ContextIfMatch(c, permission.UpdatedAt) {
+		return errPreconditionFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,7 @@
+package handlers
+
+import "errors"
+
+// errPreconditionFailed is returned when the If-Match header of a request
+// doesn't match the current version of the record, see lib.ContextIfMatch.
+var errPreconditionFailed = errors.New("precondition failed")
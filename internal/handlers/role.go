@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"gofi/internal/app"
@@ -26,22 +28,29 @@ func (h *roleHandler) Index(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
+		}
+	}
+
+	filter, err := lib.ValidateRequestFilter(c, repositories.RoleFilterFields)
+	if err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
 		}
 	}
 
 	opts := &repositories.QueryOptions{
 		Offset: dto.Offset,
 		Limit:  dto.Limit,
+		Filter: filter,
 	}
 
-	roles, meta, err := h.app.Repositories.Role.List(opts)
+	roles, meta, err := h.app.Repositories.Role.List(c.UserContext(), opts)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -63,13 +72,13 @@ func (h *roleHandler) Show(c *fiber.Ctx) error {
 		})
 	}
 
-	role, err := h.app.Repositories.Role.Get(roleID)
+	role, err := h.app.Repositories.Role.Get(c.UserContext(), roleID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
+	lib.ContextSetETag(c, role.UpdatedAt)
+
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseSingleData[*models.Role]{
 			Message: "get data has been retrieved successfully",
@@ -85,17 +94,13 @@ func (h *roleHandler) Create(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
 	roleID, err := uuid.NewV7()
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	role := &models.Role{
@@ -105,11 +110,21 @@ func (h *roleHandler) Create(c *fiber.Ctx) error {
 		Name: dto.Name,
 	}
 
-	err = h.app.Repositories.Role.Insert(role)
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Role.InsertExec(c.UserContext(), tx, role)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRoleCreate, models.AuditTargetRole, role.ID, nil, role)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -135,30 +150,52 @@ func (h *roleHandler) Update(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
-	role, err := h.app.Repositories.Role.Get(roleID)
+	role, err := h.app.Repositories.Role.Get(c.UserContext(), roleID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
+		return err
+	}
+
+	if !lib.ContextIfMatch(c, role.UpdatedAt) {
+		return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+			"message": "role has been modified since it was fetched",
 		})
 	}
 
+	before := *role
+
 	if dto.Name != "" {
 		role.Name = dto.Name
 	}
 
-	err = h.app.Repositories.Role.Update(roleID, role)
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Role.UpdateExec(c.UserContext(), tx, roleID, role)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRoleUpdate, models.AuditTargetRole, roleID, before, role)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "role has been modified by another request",
+			})
+		}
+
+		return err
 	}
 
+	lib.ContextSetETag(c, role.UpdatedAt)
+
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseSingleData[*models.Role]{
 			Message: "data has been updated successfully",
@@ -175,11 +212,37 @@ func (h *roleHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.Role.Delete(roleID)
+	if err := h.checkPrecondition(c, roleID); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "role not found",
+			})
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": "role has been modified since it was fetched",
+			})
+		}
+
+		return err
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Role.DeleteExec(c.UserContext(), tx, roleID)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRoleDelete, models.AuditTargetRole, roleID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -197,11 +260,37 @@ func (h *roleHandler) SoftDelete(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.Role.SoftDelete(roleID)
+	if err := h.checkPrecondition(c, roleID); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "role not found",
+			})
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": "role has been modified since it was fetched",
+			})
+		}
+
+		return err
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Role.SoftDeleteExec(c.UserContext(), tx, roleID)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRoleSoftDelete, models.AuditTargetRole, roleID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -219,11 +308,21 @@ func (h *roleHandler) Restore(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.Role.Restore(roleID)
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Role.RestoreExec(c.UserContext(), tx, roleID)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRoleRestore, models.AuditTargetRole, roleID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -231,3 +330,141 @@ func (h *roleHandler) Restore(c *fiber.Ctx) error {
 			Message: "data has been restored successfully",
 		})
 }
+
+func (h *roleHandler) PermissionIndex(c *fiber.Ctx) error {
+	roleID, err := lib.ContextParamUUID(c, "roleID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid role id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	permissions, err := h.app.Repositories.Permission.ListByRoleID(c.UserContext(), roleID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.Permission]{
+			Message: "list data has been retrieved successfully",
+			Data:    permissions,
+			Meta: fiber.Map{
+				"total": len(permissions),
+			},
+		})
+}
+
+// PermissionAttach grants permissions to the role.
+func (h *roleHandler) PermissionAttach(c *fiber.Ctx) error {
+	roleID, err := lib.ContextParamUUID(c, "roleID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid role id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.RolePermissionAttach
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.RolePermission.AttachExec(c.UserContext(), tx, roleID, dto.PermissionIDs...)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRolePermissionAttach, models.AuditTargetRole, roleID, nil, fiber.Map{"permission_ids": dto.PermissionIDs})
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "role or permission not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "permissions have been attached successfully",
+	})
+}
+
+// PermissionDetach revokes a permission from the role.
+func (h *roleHandler) PermissionDetach(c *fiber.Ctx) error {
+	roleID, err := lib.ContextParamUUID(c, "roleID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid role id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	permissionID, err := lib.ContextParamUUID(c, "permissionID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid permission id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Role.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.RolePermission.DetachExec(c.UserContext(), tx, roleID, permissionID)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionRolePermissionDetach, models.AuditTargetRole, roleID, fiber.Map{"permission_id": permissionID}, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "permission is not attached to the role",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "permission has been detached successfully",
+	})
+}
+
+// checkPrecondition reads the role to compare its version when the request
+// has an If-Match header.
+func (h *roleHandler) checkPrecondition(c *fiber.Ctx, roleID uuid.UUID) error {
+	if c.Get(fiber.HeaderIfMatch) == "" {
+		return nil
+	}
+
+	role, err := h.app.Repositories.Role.Get(c.UserContext(), roleID)
+	if err != nil {
+		return err
+	}
+
+	if !lib.ContextIfMatch(c, role.UpdatedAt) {
+		return errPreconditionFailed
+	}
+
+	return nil
+}
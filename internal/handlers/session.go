@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+
 	"gofi/internal/app"
 	"gofi/internal/dto"
 	"gofi/internal/lib"
@@ -24,30 +27,158 @@ func (h *sessionHandler) Index(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
+		}
+	}
+
+	filter, err := lib.ValidateRequestFilter(c, repositories.SessionFilterFields)
+	if err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
 		}
 	}
 
+	tenant, err := requestTenant(c, h.app)
+	if err != nil {
+		return err
+	}
+
 	opts := &repositories.QueryOptions{
-		Offset: dto.Offset,
-		Limit:  dto.Limit,
+		Offset:    dto.Offset,
+		Limit:     dto.Limit,
+		After:     dto.After,
+		Before:    dto.Before,
+		SkipTotal: (dto.After != "" || dto.Before != "") && !dto.IncludeTotal,
+		Tenant:    tenant,
+		Filter:    filter,
+	}
+
+	sessions, meta, err := h.app.Repositories.Session.List(c.UserContext(), opts)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return err
 	}
 
-	sessions, meta, err := h.app.Repositories.Session.List(opts)
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseMultiData[*models.Session]{
+			Message: "list data has been retrieved successfully",
+			Data:    sessions,
+			Meta:    paginationMeta(meta, opts),
+		})
+}
+
+// MyIndex lists the sessions of the signed in user, flagging the session of
+// the request.
+func (h *sessionHandler) MyIndex(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
 
+	sessions, err := h.app.Repositories.Session.ListByUserID(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	currentID, _ := lib.ContextGetSessionID(c)
+	for _, session := range sessions {
+		session.Device = lib.DeviceFromUserAgent(session.UserAgent)
+		session.Current = session.ID == currentID
+	}
+
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseMultiData[*models.Session]{
 			Message: "list data has been retrieved successfully",
 			Data:    sessions,
 			Meta: fiber.Map{
-				"total": meta.Total,
+				"total": len(sessions),
 			},
 		})
 }
+
+// MyDelete revokes a session of the signed in user along with its refresh
+// tokens.
+func (h *sessionHandler) MyDelete(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	sessionID, err := lib.ContextParamUUID(c, "sessionID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid session id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Session.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.RefreshToken.RevokeBySessionIDExec(c.UserContext(), tx, uid, sessionID)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteByIDExec(c.UserContext(), tx, uid, sessionID)
+	})
+
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "session not found",
+			})
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Session]{
+			Message: "data has been deleted successfully",
+		})
+}
+
+// MyDeleteOthers signs the user out everywhere but the session of the request.
+func (h *sessionHandler) MyDeleteOthers(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	currentID, ok := lib.ContextGetSessionID(c)
+	if !ok {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": "other sessions can only be signed out from a session",
+		})
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Session.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.RefreshToken.RevokeOthersExec(c.UserContext(), tx, uid, currentID)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.Session.DeleteOthersExec(c.UserContext(), tx, uid, currentID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "Signed out of every other session successfully",
+	})
+}
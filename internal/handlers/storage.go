@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"gofi/internal/app"
+	"gofi/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// storageInlineContentTypes are served with their type, the files are on the
+// origin of the API so anything a browser could run, like html or svg, is
+// served as binary instead.
+var storageInlineContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type storageHandler struct {
+	app *app.Application
+}
+
+// Show serves the signed URLs of the storages without URLs of their own,
+// like the local one.
+func (h *storageHandler) Show(c *fiber.Ctx) error {
+	verifier, ok := h.app.Services.Storage.(services.SignedURLVerifier)
+	if !ok {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"message": "file not found",
+		})
+	}
+
+	keyFile := "/" + c.Params("*")
+
+	err := verifier.Verify(keyFile, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	body, object, err := h.app.Services.Storage.Get(c.UserContext(), keyFile)
+	if err != nil {
+		if errors.Is(err, services.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "file not found",
+			})
+		}
+
+		return err
+	}
+
+	contentType := object.ContentType
+	if !storageInlineContentTypes[contentType] {
+		contentType = fiber.MIMEOctetStream
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, "attachment")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// the body is closed once sent
+	return c.Status(http.StatusOK).SendStream(body, int(object.Size))
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"gofi/internal/app"
+	"gofi/internal/lib"
+	"gofi/internal/lib/constant"
+	"gofi/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// requestTenant is the scope of the queries of the request, its active
+// organization. Without one only the callers granted
+// constant.PermissionGlobalAccess see every organization, the others see no
+// rows.
+func requestTenant(c *fiber.Ctx, app *app.Application) (repositories.Tenant, error) {
+	if organizationID := lib.ContextGetOrganizationID(c); organizationID != uuid.Nil {
+		return repositories.Tenant{OrganizationID: organizationID}, nil
+	}
+
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return repositories.Tenant{}, err
+	}
+
+	user, err := app.Repositories.User.GetByID(c.UserContext(), uid)
+	if err != nil {
+		return repositories.Tenant{}, err
+	}
+
+	global, err := app.Repositories.Permission.RoleHasAll(c.UserContext(), user.RoleID, constant.PermissionGlobalAccess)
+	if err != nil {
+		return repositories.Tenant{}, err
+	}
+
+	return repositories.Tenant{Global: global}, nil
+}
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gofi/internal/app"
+	"gofi/internal/dto"
+	"gofi/internal/lib"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+	"gofi/internal/services"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	uploadSignedURLDuration = 24 * time.Hour
+	uploadPresignDuration   = time.Hour
+
+	uploadMaxSize = 5 << 30 // 5GB
+	// files above uploadMultipartThreshold are presigned in parts of
+	// uploadPartSize, S3 requires parts of at least 5MB.
+	uploadMultipartThreshold = 64 << 20 // 64MB
+	uploadPartSize           = 16 << 20 // 16MB
+
+	// uploadSignedURLMargin refreshes signed urls a bit before they lapse so
+	// clients don't receive one about to expire.
+	uploadSignedURLMargin = 5 * time.Minute
+)
+
+// uploadExtensions are the extensions of the key files by sniffed content
+// type, other files are stored without one and served as binary.
+var uploadExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+	"audio/mpeg":      ".mp3",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+}
+
+func uploadExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	return uploadExtensions[mediaType]
+}
+
+type uploadHandler struct {
+	app *app.Application
+}
+
+// Create stores the multipart `file` and records it. The whole request body
+// is read in memory first so it is capped by the 2MB body limit of the
+// server, bigger files are uploaded with Presign.
+func (h *uploadHandler) Create(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "file is required",
+			"error":   err.Error(),
+		})
+	}
+
+	if fileHeader.Size == 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "file must not be empty",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// the content type sent by the client isn't trusted, sniff it instead
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	mimeType := http.DetectContentType(head[:n])
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	uploadID := uuid.Must(uuid.NewV7())
+	// the extension decides the content type the file is served with, it
+	// comes from the content and never from the name sent by the client
+	key := fmt.Sprintf("uploads/%s%s", uploadID, uploadExtension(mimeType))
+
+	keyFile := h.app.Services.Storage.KeyFile(key)
+
+	err = h.app.Services.Storage.Put(c.UserContext(), keyFile, file, fileHeader.Size, mimeType)
+	if err != nil {
+		return err
+	}
+
+	signedURL, expiresAt, err := h.app.Services.Storage.SignedURL(c.UserContext(), keyFile, uploadSignedURLDuration)
+	if err != nil {
+		return err
+	}
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID: uploadID,
+		},
+		UserID:    &uid,
+		KeyFile:   keyFile,
+		FileName:  filepath.Base(fileHeader.Filename),
+		MimeType:  mimeType,
+		Size:      fileHeader.Size,
+		SignedURL: signedURL,
+		ExpiresAt: expiresAt,
+		Status:    models.UploadStatusReady,
+	}
+
+	err = h.app.Repositories.Upload.Insert(c.UserContext(), upload)
+	if err != nil {
+		// don't leave an object nothing refers to
+		if err := h.app.Services.Storage.Delete(c.UserContext(), keyFile); err != nil {
+			h.app.Logger.Error("failed to delete orphan upload", "key_file", keyFile, "error", err.Error())
+		}
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Upload]{
+			Message: "data has been created successfully",
+			Data:    upload,
+		})
+}
+
+// Presign records a pending upload and returns the URLs the client uploads
+// the file to directly, a single PUT URL or one URL per part for big files.
+// The upload is only usable once completed.
+func (h *uploadHandler) Presign(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	var dto dto.UploadPresign
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	if dto.Size > uploadMaxSize {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": fmt.Sprintf("size must not exceed %d bytes", uploadMaxSize),
+		})
+	}
+
+	uploader, ok := h.app.Services.Storage.(services.DirectUploader)
+	if !ok {
+		return c.Status(http.StatusNotImplemented).JSON(fiber.Map{
+			"message": "direct uploads aren't supported by the storage driver",
+		})
+	}
+
+	uploadID := uuid.Must(uuid.NewV7())
+	key := fmt.Sprintf("uploads/%s%s", uploadID, strings.ToLower(filepath.Ext(dto.FileName)))
+	keyFile := h.app.Services.Storage.KeyFile(key)
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID: uploadID,
+		},
+		UserID:    &uid,
+		KeyFile:   keyFile,
+		FileName:  filepath.Base(dto.FileName),
+		MimeType:  dto.MimeType,
+		Size:      dto.Size,
+		ExpiresAt: time.Now().Add(uploadPresignDuration),
+		Status:    models.UploadStatusPending,
+	}
+
+	if dto.Size <= uploadMultipartThreshold {
+		url, expiresAt, err := uploader.PresignPut(c.UserContext(), keyFile, dto.Size, dto.MimeType, uploadPresignDuration)
+		if err != nil {
+			return err
+		}
+
+		upload.ExpiresAt = expiresAt
+
+		err = h.app.Repositories.Upload.Insert(c.UserContext(), upload)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusOK).JSON(
+			types.ResponseSingleData[fiber.Map]{
+				Message: "upload has been presigned successfully",
+				Data: fiber.Map{
+					"upload": upload,
+					"url":    url,
+				},
+			})
+	}
+
+	multipartUploadID, err := uploader.CreateMultipartUpload(c.UserContext(), keyFile, dto.MimeType)
+	if err != nil {
+		return err
+	}
+
+	upload.MultipartUploadID = &multipartUploadID
+
+	partCount := int32((dto.Size + uploadPartSize - 1) / uploadPartSize)
+	parts := make([]fiber.Map, 0, partCount)
+
+	for partNumber := int32(1); partNumber <= partCount; partNumber++ {
+		url, err := uploader.PresignUploadPart(c.UserContext(), keyFile, multipartUploadID, partNumber, uploadPresignDuration)
+		if err != nil {
+			h.abortMultipartUpload(c.UserContext(), uploader, upload)
+
+			return err
+		}
+
+		parts = append(parts, fiber.Map{
+			"part_number": partNumber,
+			"url":         url,
+		})
+	}
+
+	err = h.app.Repositories.Upload.Insert(c.UserContext(), upload)
+	if err != nil {
+		h.abortMultipartUpload(c.UserContext(), uploader, upload)
+
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[fiber.Map]{
+			Message: "upload has been presigned successfully",
+			Data: fiber.Map{
+				"upload":    upload,
+				"part_size": uploadPartSize,
+				"parts":     parts,
+			},
+		})
+}
+
+// Complete checks the object uploaded to the presigned URLs matches what was
+// declared and marks the upload ready. A mismatching object is discarded
+// along with the upload, the client has to presign again.
+func (h *uploadHandler) Complete(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	var dto dto.UploadComplete
+
+	if err := lib.ValidateRequestBody(c, &dto); err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
+		}
+	}
+
+	upload, err := h.getOwned(c.UserContext(), uploadID, uid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "upload not found",
+			})
+		}
+
+		return err
+	}
+
+	if upload.IsReady() {
+		return c.Status(http.StatusConflict).JSON(fiber.Map{
+			"message": "upload is already completed",
+		})
+	}
+
+	if upload.MultipartUploadID != nil {
+		uploader, ok := h.app.Services.Storage.(services.DirectUploader)
+		if !ok {
+			return c.Status(http.StatusNotImplemented).JSON(fiber.Map{
+				"message": "direct uploads aren't supported by the storage driver",
+			})
+		}
+
+		if len(dto.Parts) == 0 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "parts are required to complete an upload sent in parts",
+			})
+		}
+
+		parts := make([]services.S3Part, 0, len(dto.Parts))
+		for _, part := range dto.Parts {
+			parts = append(parts, services.S3Part{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+
+		err = uploader.CompleteMultipartUpload(c.UserContext(), upload.KeyFile, *upload.MultipartUploadID, parts)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "parts can't be assembled, check every part has been uploaded",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	object, err := h.app.Services.Storage.Stat(c.UserContext(), upload.KeyFile)
+	if err != nil {
+		if errors.Is(err, services.ErrObjectNotFound) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": "file has not been uploaded yet",
+			})
+		}
+
+		return err
+	}
+
+	if object.Size != upload.Size || object.ContentType != upload.MimeType {
+		err = lib.WithTransaction(h.app.Repositories.Upload.DB, func(tx *sql.Tx) error {
+			err := h.app.Repositories.Upload.DeleteExec(c.UserContext(), tx, upload.ID)
+			if err != nil {
+				return err
+			}
+
+			return h.app.Services.Storage.Delete(c.UserContext(), upload.KeyFile)
+		})
+		if err != nil {
+			return err
+		}
+
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "uploaded file doesn't match the declared size and mimetype, the upload has been discarded",
+		})
+	}
+
+	signedURL, expiresAt, err := h.app.Services.Storage.SignedURL(c.UserContext(), upload.KeyFile, uploadSignedURLDuration)
+	if err != nil {
+		return err
+	}
+
+	err = h.app.Repositories.Upload.MarkReady(c.UserContext(), upload.ID, signedURL, expiresAt)
+	if err != nil {
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "upload is already completed",
+			})
+		}
+
+		return err
+	}
+
+	upload.Status = models.UploadStatusReady
+	upload.SignedURL = signedURL
+	upload.ExpiresAt = expiresAt
+	upload.MultipartUploadID = nil
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Upload]{
+			Message: "upload has been completed successfully",
+			Data:    upload,
+		})
+}
+
+func (h *uploadHandler) Show(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	upload, err := h.getOwned(c.UserContext(), uploadID, uid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "upload not found",
+			})
+		}
+
+		return err
+	}
+
+	err = refreshSignedURL(c.UserContext(), h.app, upload)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Upload]{
+			Message: "get data has been retrieved successfully",
+			Data:    upload,
+		})
+}
+
+// Delete removes the record and the object, the record is kept when the
+// object can't be deleted.
+func (h *uploadHandler) Delete(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	uploadID, err := lib.ContextParamUUID(c, "uploadID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid upload id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	upload, err := h.getOwned(c.UserContext(), uploadID, uid)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "upload not found",
+			})
+		}
+
+		return err
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.Upload.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Upload.DeleteExec(c.UserContext(), tx, upload.ID)
+		if err != nil {
+			return err
+		}
+
+		// parts of an upload never completed are only dropped by aborting it
+		if uploader, ok := h.app.Services.Storage.(services.DirectUploader); ok && upload.MultipartUploadID != nil {
+			return uploader.AbortMultipartUpload(c.UserContext(), upload.KeyFile, *upload.MultipartUploadID)
+		}
+
+		return h.app.Services.Storage.Delete(c.UserContext(), upload.KeyFile)
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.Upload]{
+			Message: "data has been deleted successfully",
+		})
+}
+
+// refreshSignedURL signs the url of the upload again when it lapses soon,
+// pending uploads have nothing to download yet.
+func refreshSignedURL(ctx context.Context, app *app.Application, upload *models.Upload) error {
+	if !upload.IsReady() || !upload.SignedURLExpired(uploadSignedURLMargin) {
+		return nil
+	}
+
+	signedURL, expiresAt, err := app.Services.Storage.SignedURL(ctx, upload.KeyFile, uploadSignedURLDuration)
+	if err != nil {
+		return err
+	}
+
+	err = app.Repositories.Upload.UpdateSignedURL(ctx, upload.ID, signedURL, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	upload.SignedURL = signedURL
+	upload.ExpiresAt = expiresAt
+
+	return nil
+}
+
+// abortMultipartUpload drops the parts of an upload that can't go on, a
+// failure is only logged as the upload is already failing.
+func (h *uploadHandler) abortMultipartUpload(ctx context.Context, uploader services.DirectUploader, upload *models.Upload) {
+	err := uploader.AbortMultipartUpload(ctx, upload.KeyFile, *upload.MultipartUploadID)
+	if err != nil {
+		h.app.Logger.Error("failed to abort multipart upload", "key_file", upload.KeyFile, "error", err.Error())
+	}
+}
+
+// getOwned returns ErrRecordNotFound for uploads of other users, they are
+// hidden rather than forbidden.
+func (h *uploadHandler) getOwned(ctx context.Context, uploadID uuid.UUID, userID uuid.UUID) (*models.Upload, error) {
+	upload, err := h.app.Repositories.Upload.Get(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !upload.IsOwnedBy(userID) {
+		return nil, repositories.ErrRecordNotFound
+	}
+
+	return upload, nil
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
+	"time"
 
 	"gofi/internal/app"
 	"gofi/internal/dto"
@@ -26,31 +29,51 @@ func (h *userHandler) Index(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
+		}
+	}
+
+	filter, err := lib.ValidateRequestFilter(c, repositories.UserFilterFields)
+	if err != nil {
+		switch e := err.(type) {
+		case *lib.ErrValidationFailed:
+			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
+		default:
+			return err
 		}
 	}
 
+	tenant, err := requestTenant(c, h.app)
+	if err != nil {
+		return err
+	}
+
 	opts := &repositories.QueryOptions{
-		Offset: dto.Offset,
-		Limit:  dto.Limit,
+		Offset:    dto.Offset,
+		Limit:     dto.Limit,
+		After:     dto.After,
+		Before:    dto.Before,
+		SkipTotal: (dto.After != "" || dto.Before != "") && !dto.IncludeTotal,
+		Tenant:    tenant,
+		Filter:    filter,
 	}
 
-	users, meta, err := h.app.Repositories.User.List(opts)
+	users, meta, err := h.app.Repositories.User.List(c.UserContext(), opts)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		if errors.Is(err, repositories.ErrInvalidCursor) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseMultiData[*models.User]{
 			Message: "list data has been retrieved successfully",
 			Data:    users,
-			Meta: fiber.Map{
-				"total": meta.Total,
-			},
+			Meta:    paginationMeta(meta, opts),
 		})
 }
 
@@ -63,13 +86,29 @@ func (h *userHandler) Show(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.app.Repositories.User.Get(userID)
+	tenant, err := requestTenant(c, h.app)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
+	}
+
+	user, err := h.app.Repositories.User.GetInTenant(c.UserContext(), userID, tenant)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		}
+
+		return err
 	}
 
+	err = withAvatarURLs(c.UserContext(), h.app, user)
+	if err != nil {
+		return err
+	}
+
+	lib.ContextSetETag(c, user.UpdatedAt)
+
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseSingleData[*models.User]{
 			Message: "get data has been retrieved successfully",
@@ -85,17 +124,13 @@ func (h *userHandler) Create(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
 	userID, err := uuid.NewV7()
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	user := &models.User{
@@ -111,13 +146,57 @@ func (h *userHandler) Create(c *fiber.Ctx) error {
 		UploadID:  dto.UploadID,
 	}
 
-	err = h.app.Repositories.User.Insert(user)
+	// users created from an organization become its members, the others
+	// would be out of reach of their creator
+	tenant, err := requestTenant(c, h.app)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
+		return err
+	}
+
+	if !tenant.Global && tenant.OrganizationID == uuid.Nil {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": "Forbidden, an active organization is required",
 		})
 	}
 
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := user.BeforeCreate()
+		if err != nil {
+			return err
+		}
+
+		err = h.app.Repositories.User.InsertExec(c.UserContext(), tx, user)
+		if err != nil {
+			return err
+		}
+
+		if tenant.OrganizationID != uuid.Nil {
+			err = h.app.Repositories.OrganizationMember.InsertExec(c.UserContext(), tx, &models.OrganizationMember{
+				ID:             uuid.Must(uuid.NewV7()),
+				OrganizationID: tenant.OrganizationID,
+				UserID:         user.ID,
+				Role:           models.OrganizationRoleMember,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// the password hash has no place in the audit log
+		after := *user
+		after.Password = nil
+
+		log, err := newAuditLog(c, models.AuditActionUserCreate, models.AuditTargetUser, user.ID, nil, after)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
+	if err != nil {
+		return err
+	}
+
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseSingleData[*models.User]{
 			Message: "data has been created successfully",
@@ -141,19 +220,34 @@ func (h *userHandler) Update(c *fiber.Ctx) error {
 		case *lib.ErrValidationFailed:
 			return c.Status(http.StatusBadRequest).JSON(lib.WrapValidationError(e.MessageRecord))
 		default:
-			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-				"message": err.Error(),
-			})
+			return err
 		}
 	}
 
-	user, err := h.app.Repositories.User.Get(userID)
+	tenant, err := requestTenant(c, h.app)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.app.Repositories.User.GetInTenant(c.UserContext(), userID, tenant)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		}
+
+		return err
+	}
+
+	if !lib.ContextIfMatch(c, user.UpdatedAt) {
+		return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+			"message": "user has been modified since it was fetched",
 		})
 	}
 
+	before := *user
+
 	if dto.FirstName != "" {
 		user.FirstName = dto.FirstName
 	}
@@ -170,13 +264,31 @@ func (h *userHandler) Update(c *fiber.Ctx) error {
 		user.UploadID = dto.UploadID
 	}
 
-	err = h.app.Repositories.User.Update(userID, user)
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.UpdateExec(c.UserContext(), tx, userID, user)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionUserUpdate, models.AuditTargetUser, userID, before, user)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		if errors.Is(err, repositories.ErrEditConflict) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{
+				"message": "user has been modified by another request",
+			})
+		}
+
+		return err
 	}
 
+	lib.ContextSetETag(c, user.UpdatedAt)
+
 	return c.Status(http.StatusOK).JSON(
 		types.ResponseSingleData[*models.User]{
 			Message: "data has been updated successfully",
@@ -193,11 +305,82 @@ func (h *userHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.User.Delete(userID)
+	version, err := h.checkPrecondition(c, userID)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": "user has been modified since it was fetched",
+			})
+		}
+
+		return err
+	}
+
+	// inside an organization the user only leaves it, the account is shared
+	// with the other organizations
+	organizationID := lib.ContextGetOrganizationID(c)
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		action := models.AuditActionUserDelete
+		if organizationID != uuid.Nil {
+			action = models.AuditActionUserRemove
+
+			member, err := h.app.Repositories.OrganizationMember.GetExec(c.UserContext(), tx, organizationID, userID)
+			if err != nil {
+				return err
+			}
+
+			if member.Role == models.OrganizationRoleOwner {
+				return errOrganizationOwner
+			}
+
+			err = h.app.Repositories.OrganizationMember.DeleteExec(c.UserContext(), tx, organizationID, userID)
+			if err != nil {
+				return err
+			}
+		} else if version != nil {
+			// the user may have changed since its version was compared
+			err := h.app.Repositories.User.DeleteVersionExec(c.UserContext(), tx, userID, *version)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := h.app.Repositories.User.DeleteExec(c.UserContext(), tx, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		log, err := newAuditLog(c, action, models.AuditTargetUser, userID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrRecordNotFound):
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		case errors.Is(err, repositories.ErrEditConflict):
+			return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": "user has been modified since it was fetched",
+			})
+		case errors.Is(err, errOrganizationOwner):
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		default:
+			return err
+		}
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -215,11 +398,37 @@ func (h *userHandler) SoftDelete(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.User.SoftDelete(userID)
+	if _, err := h.checkPrecondition(c, userID); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		}
+
+		if errors.Is(err, errPreconditionFailed) {
+			return c.Status(http.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": "user has been modified since it was fetched",
+			})
+		}
+
+		return err
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.SoftDeleteExec(c.UserContext(), tx, userID)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionUserSoftDelete, models.AuditTargetUser, userID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -237,11 +446,31 @@ func (h *userHandler) Restore(c *fiber.Ctx) error {
 		})
 	}
 
-	err = h.app.Repositories.User.Restore(userID)
+	if err := h.checkOrganization(c, userID); err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		}
+
+		return err
+	}
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.User.RestoreExec(c.UserContext(), tx, userID)
+		if err != nil {
+			return err
+		}
+
+		log, err := newAuditLog(c, models.AuditActionUserRestore, models.AuditTargetUser, userID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.AuditLog.InsertExec(c.UserContext(), tx, log)
+	})
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(http.StatusOK).JSON(
@@ -249,3 +478,91 @@ func (h *userHandler) Restore(c *fiber.Ctx) error {
 			Message: "data has been restored successfully",
 		})
 }
+
+// Unlock lifts a sign in lockout caused by too many failed attempts.
+func (h *userHandler) Unlock(c *fiber.Ctx) error {
+	userID, err := lib.ContextParamUUID(c, "userID")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid user id must be uuid format",
+			"error":   err.Error(),
+		})
+	}
+
+	tenant, err := requestTenant(c, h.app)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.app.Repositories.User.GetInTenant(c.UserContext(), userID, tenant)
+	if err != nil {
+		if errors.Is(err, repositories.ErrRecordNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"message": "user not found",
+			})
+		}
+
+		return err
+	}
+
+	err = h.app.Services.Lockout.Unlock(c.UserContext(), user.Email)
+	if err != nil {
+		return err
+	}
+
+	log, err := newAuditLog(c, models.AuditActionUserUnlock, models.AuditTargetUser, user.ID, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	err = h.app.Repositories.AuditLog.Insert(c.UserContext(), log)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.User]{
+			Message: "user has been unlocked successfully",
+		})
+}
+
+// checkOrganization returns ErrRecordNotFound when the user isn't a member
+// of the tenant of the request.
+func (h *userHandler) checkOrganization(c *fiber.Ctx, userID uuid.UUID) error {
+	tenant, err := requestTenant(c, h.app)
+	if err != nil {
+		return err
+	}
+
+	if tenant.Global {
+		return nil
+	}
+
+	_, err = h.app.Repositories.OrganizationMember.Get(c.UserContext(), tenant.OrganizationID, userID)
+	return err
+}
+
+// checkPrecondition is checkOrganization for requests without an If-Match
+// header, the user is read to compare its version otherwise. The matched
+// version is returned, nil without If-Match.
+func (h *userHandler) checkPrecondition(c *fiber.Ctx, userID uuid.UUID) (*time.Time, error) {
+	if c.Get(fiber.HeaderIfMatch) == "" {
+		return nil, h.checkOrganization(c, userID)
+	}
+
+	tenant, err := requestTenant(c, h.app)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := h.app.Repositories.User.GetInTenant(c.UserContext(), userID, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if !lib.ContextIfMatch(c, user.UpdatedAt) {
+		return nil, errPreconditionFailed
+	}
+
+	return &user.UpdatedAt, nil
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gofi/internal/app"
+	"gofi/internal/lib"
+	"gofi/internal/lib/imaging"
+	"gofi/internal/models"
+	"gofi/internal/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	avatarJPEGQuality = 85
+
+	// avatarMaxSize leaves room for the multipart encoding within the 2MB
+	// body limit of the server.
+	avatarMaxSize = 1 << 20 // 1MB
+)
+
+// avatarSizes are the sizes in pixels of the square variants generated for
+// every avatar.
+var avatarSizes = []int{64, 256, 512}
+
+// avatarVariantKeyFile returns the key file of a variant, next to the
+// original avatar.
+func avatarVariantKeyFile(keyFile string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", strings.TrimSuffix(keyFile, filepath.Ext(keyFile)), size)
+}
+
+func isAvatarKeyFile(keyFile string) bool {
+	return strings.Contains(keyFile, "/avatars/")
+}
+
+// MyAvatarUpdate replaces the avatar of the user. The image is decoded from
+// its real content and encoded again, which drops its EXIF metadata, then
+// stored along with its resized variants.
+func (h *userHandler) MyAvatarUpdate(c *fiber.Ctx) error {
+	uid, err := lib.ContextGetUID(c)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "avatar is required",
+			"error":   err.Error(),
+		})
+	}
+
+	if fileHeader.Size > avatarMaxSize {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "avatar must not be larger than 1MB",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// the size of the header is the one sent by the client, the read is
+	// capped as well
+	data, err := io.ReadAll(io.LimitReader(file, avatarMaxSize+1))
+	if err != nil {
+		return err
+	}
+
+	if len(data) > avatarMaxSize {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "avatar must not be larger than 1MB",
+		})
+	}
+
+	// the decoder error is not shown, it may describe the file in details
+	img, contentType, err := imaging.Decode(data)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) || errors.Is(err, imaging.ErrTooLarge) {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"message": "avatar is not a valid image",
+		})
+	}
+
+	user, err := h.app.Repositories.User.Get(c.UserContext(), uid)
+	if err != nil {
+		return err
+	}
+
+	previous := user.Upload
+
+	var original bytes.Buffer
+	mimeType, err := imaging.Encode(&original, img, contentType)
+	if err != nil {
+		return err
+	}
+
+	uploadID := uuid.Must(uuid.NewV7())
+	extension := ".png"
+	if mimeType == "image/jpeg" {
+		extension = ".jpg"
+	}
+
+	keyFile := h.app.Services.Storage.KeyFile(fmt.Sprintf("avatars/%s%s", uploadID, extension))
+
+	err = h.putAvatar(c.UserContext(), keyFile, img, original.Bytes(), mimeType)
+	if err != nil {
+		return err
+	}
+
+	signedURL, expiresAt, err := h.app.Services.Storage.SignedURL(c.UserContext(), keyFile, uploadSignedURLDuration)
+	if err != nil {
+		h.deleteAvatar(c.UserContext(), keyFile)
+
+		return err
+	}
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID: uploadID,
+		},
+		UserID:    &uid,
+		KeyFile:   keyFile,
+		FileName:  filepath.Base(fileHeader.Filename),
+		MimeType:  mimeType,
+		Size:      int64(original.Len()),
+		SignedURL: signedURL,
+		ExpiresAt: expiresAt,
+		Status:    models.UploadStatusReady,
+	}
+
+	user.UploadID = &uploadID
+
+	err = lib.WithTransaction(h.app.Repositories.User.DB, func(tx *sql.Tx) error {
+		err := h.app.Repositories.Upload.InsertExec(c.UserContext(), tx, upload)
+		if err != nil {
+			return err
+		}
+
+		return h.app.Repositories.User.UpdateExec(c.UserContext(), tx, uid, user)
+	})
+	if err != nil {
+		h.deleteAvatar(c.UserContext(), keyFile)
+
+		return err
+	}
+
+	// uploads linked by hand aren't avatars, they are left alone
+	if previous != nil && isAvatarKeyFile(previous.KeyFile) {
+		h.deleteAvatar(c.UserContext(), previous.KeyFile)
+
+		if err := h.app.Repositories.Upload.Delete(c.UserContext(), previous.ID); err != nil {
+			h.app.Logger.Error("failed to delete previous avatar", "upload_id", previous.ID, "error", err.Error())
+		}
+	}
+
+	user.Upload = upload
+
+	err = withAvatarURLs(c.UserContext(), h.app, user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(
+		types.ResponseSingleData[*models.User]{
+			Message: "avatar has been updated successfully",
+			Data:    user,
+		})
+}
+
+// putAvatar stores the original avatar and its variants, nothing is left
+// stored when one of them fails.
+func (h *userHandler) putAvatar(ctx context.Context, keyFile string, img image.Image, original []byte, mimeType string) error {
+	err := h.app.Services.Storage.Put(ctx, keyFile, bytes.NewReader(original), int64(len(original)), mimeType)
+	if err != nil {
+		return err
+	}
+
+	for _, size := range avatarSizes {
+		var variant bytes.Buffer
+
+		err := imaging.EncodeJPEG(&variant, imaging.Thumbnail(img, size), avatarJPEGQuality)
+		if err == nil {
+			err = h.app.Services.Storage.Put(ctx, avatarVariantKeyFile(keyFile, size), &variant, int64(variant.Len()), "image/jpeg")
+		}
+
+		if err != nil {
+			h.deleteAvatar(ctx, keyFile)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteAvatar deletes the original avatar and its variants, failures are
+// only logged as missing objects are harmless.
+func (h *userHandler) deleteAvatar(ctx context.Context, keyFile string) {
+	keyFiles := []string{keyFile}
+	for _, size := range avatarSizes {
+		keyFiles = append(keyFiles, avatarVariantKeyFile(keyFile, size))
+	}
+
+	for _, keyFile := range keyFiles {
+		if err := h.app.Services.Storage.Delete(ctx, keyFile); err != nil {
+			h.app.Logger.Error("failed to delete avatar", "key_file", keyFile, "error", err.Error())
+		}
+	}
+}
+
+// withAvatarURLs refreshes the signed url of the user avatar and signs the
+// urls of its variants.
+func withAvatarURLs(ctx context.Context, app *app.Application, user *models.User) error {
+	if user.Upload == nil {
+		return nil
+	}
+
+	err := refreshSignedURL(ctx, app, user.Upload)
+	if err != nil {
+		return err
+	}
+
+	if !isAvatarKeyFile(user.Upload.KeyFile) {
+		return nil
+	}
+
+	user.Upload.Variants = make(map[string]string, len(avatarSizes))
+
+	for _, size := range avatarSizes {
+		signedURL, _, err := app.Services.Storage.SignedURL(ctx, avatarVariantKeyFile(user.Upload.KeyFile, size), uploadSignedURLDuration)
+		if err != nil {
+			return err
+		}
+
+		user.Upload.Variants[strconv.Itoa(size)] = signedURL
+	}
+
+	return nil
+}
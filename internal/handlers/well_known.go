@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"gofi/internal/app"
+	"gofi/internal/lib/jwt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type wellKnownHandler struct {
+	app *app.Application
+}
+
+// JWKS publishes the public keys verifying our access tokens, so other
+// services can verify them without sharing a secret.
+func (h *wellKnownHandler) JWKS(c *fiber.Ctx) error {
+	jwks := jwt.JWKS{Keys: []jwt.JWK{}}
+	if h.app.Keyring != nil {
+		jwks = h.app.Keyring.JWKS()
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.Status(fiber.StatusOK).JSON(jwks)
+}
+
+// OpenIDConfiguration is the OpenID Connect discovery document. The
+// authorization endpoint is the consent screen of the client app, it signs
+// the user in and calls the authorize API on their behalf. It is only routed
+// with a keyring, ID tokens are signed with its key.
+func (h *wellKnownHandler) OpenIDConfiguration(c *fiber.Ctx) error {
+	issuer := h.app.Config.App.ServerURL
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"issuer":                                issuer,
+		"authorization_endpoint":                h.app.Config.App.ClientURL + "/oauth/authorize",
+		"token_endpoint":                        issuer + "/v1/oauth/token",
+		"userinfo_endpoint":                     issuer + "/v1/oauth/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{h.app.Keyring.SigningKey().Method.Alg()},
+		"scopes_supported":                      oidcScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified", "name", "given_name", "family_name"},
+	})
+}
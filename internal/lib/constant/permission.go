@@ -0,0 +1,17 @@
+package constant
+
+const (
+	PermissionRolesRead          = "roles:read"
+	PermissionRolesWrite         = "roles:write"
+	PermissionPermissionsRead    = "permissions:read"
+	PermissionPermissionsWrite   = "permissions:write"
+	PermissionUsersRead          = "users:read"
+	PermissionUsersWrite         = "users:write"
+	PermissionSessionsRead       = "sessions:read"
+	PermissionOAuthClientsRead   = "oauth-clients:read"
+	PermissionOAuthClientsWrite  = "oauth-clients:write"
+	PermissionOrganizationsRead  = "organizations:read"
+	PermissionOrganizationsWrite = "organizations:write"
+	PermissionAuditLogsRead      = "audit-logs:read"
+	PermissionGlobalAccess       = "global:access" // lifts the organization scope
+)
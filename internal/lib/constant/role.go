@@ -3,4 +3,10 @@ package constant
 const (
 	RoleAdmin = "019a626e-f0a0-74c9-bee7-51aaeeec4b00"
 	RoleUser  = "019a626e-f0a0-780b-a643-a1ee0220d079"
+
+	// the roles granting the members of an organization their permissions
+	// within it, by the role of their membership.
+	RoleOrganizationOwner  = "019a626e-f0a0-7d3e-9c51-2f6b8e0a4c11"
+	RoleOrganizationAdmin  = "019a626e-f0a0-7e52-8a17-6c3d9b1f5e22"
+	RoleOrganizationMember = "019a626e-f0a0-7f68-b2c4-8e7a1d3c6f33"
 )
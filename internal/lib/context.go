@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"errors"
 
 	"github.com/gofiber/fiber/v2"
@@ -24,3 +25,57 @@ func ContextParamUUID(c *fiber.Ctx, key string) (uuid.UUID, error) {
 	str := c.Params(key)
 	return uuid.Parse(str)
 }
+
+// ContextSetSessionID stores the session of the request, requests made with
+// an API key have none.
+func ContextSetSessionID(c *fiber.Ctx, sessionID uuid.UUID) {
+	c.Locals("sessionID", sessionID.String())
+}
+
+func ContextGetSessionID(c *fiber.Ctx) (uuid.UUID, bool) {
+	if c.Locals("sessionID") != nil {
+		sessionID, err := uuid.Parse(c.Locals("sessionID").(string))
+		return sessionID, err == nil
+	}
+
+	return uuid.Nil, false
+}
+
+// ContextSetOrganizationID stores the active organization of the request,
+// used to scope queries to the tenant.
+func ContextSetOrganizationID(c *fiber.Ctx, organizationID uuid.UUID) {
+	c.Locals("organizationID", organizationID.String())
+}
+
+// ContextGetOrganizationID returns uuid.Nil when no organization is active.
+func ContextGetOrganizationID(c *fiber.Ctx) uuid.UUID {
+	if c.Locals("organizationID") != nil {
+		organizationID, err := uuid.Parse(c.Locals("organizationID").(string))
+		if err == nil {
+			return organizationID
+		}
+	}
+
+	return uuid.Nil
+}
+
+// ContextGetRequestID returns the id set by the requestid middleware, empty
+// when the middleware isn't registered.
+func ContextGetRequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals("requestid").(string)
+	return requestID
+}
+
+type requestIDKey struct{}
+
+// WithRequestID carries the request id to the layers only given a
+// context.Context, e.g. the database query hook.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the id set by WithRequestID, empty outside of a request.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
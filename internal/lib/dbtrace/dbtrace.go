@@ -0,0 +1,110 @@
+// Package dbtrace wraps a database/sql connector to observe the queries run
+// through its connections, the ones of transactions included.
+package dbtrace
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// Hook is called after each query with how long the database took to
+// answer it. ctx is the context the query was run with.
+type Hook func(ctx context.Context, query string, duration time.Duration, err error)
+
+// Wrap returns a connector calling hook after the queries and execs of its
+// connections. Prepared statements aren't observed.
+func Wrap(connector driver.Connector, hook Hook) driver.Connector {
+	return tracedConnector{Connector: connector, hook: hook}
+}
+
+type tracedConnector struct {
+	driver.Connector
+	hook Hook
+}
+
+func (c tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tracedConn{Conn: conn, hook: c.hook}, nil
+}
+
+// tracedConn forwards the optional interfaces of database/sql to the wrapped
+// connection, falling back to what database/sql does without them.
+type tracedConn struct {
+	driver.Conn
+	hook Hook
+}
+
+func (c tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.hook(ctx, query, time.Since(start), err)
+	}
+
+	return rows, err
+}
+
+func (c tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.hook(ctx, query, time.Since(start), err)
+	}
+
+	return result, err
+}
+
+func (c tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+func (c tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
@@ -0,0 +1,100 @@
+package dbtrace
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+func (fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                        { return nil }
+func (fakeConn) Begin() (driver.Tx, error)           { return fakeTx{}, nil }
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query == "fail" {
+		return nil, errors.New("failed")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"n"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type ctxKey struct{}
+
+func TestWrap(t *testing.T) {
+	type call struct {
+		value any
+		query string
+		err   error
+	}
+	var calls []call
+
+	db := sql.OpenDB(Wrap(fakeConnector{}, func(ctx context.Context, query string, duration time.Duration, err error) {
+		calls = append(calls, call{value: ctx.Value(ctxKey{}), query: query, err: err})
+	}))
+	defer db.Close()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	rows, err := db.QueryContext(ctx, "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+
+	if _, err := db.ExecContext(ctx, "fail"); err == nil {
+		t.Fatal("expected the exec to fail")
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("hook called %d times, want 3", len(calls))
+	}
+
+	for i, query := range []string{"SELECT 1", "fail", "UPDATE"} {
+		if calls[i].query != query {
+			t.Errorf("call %d query = %q, want %q", i, calls[i].query, query)
+		}
+		if calls[i].value != "request" {
+			t.Errorf("call %d didn't get the context of the query", i)
+		}
+	}
+
+	if calls[1].err == nil {
+		t.Error("hook didn't get the error of the exec")
+	}
+}
@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gofi/internal/config"
+)
+
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// Encryptor encrypts small secrets at rest with AES-256-GCM, using a key
+// derived from the app secret.
+type Encryptor struct {
+	config config.ConfigApp
+}
+
+func NewEncryptor(config *config.ConfigApp) *Encryptor {
+	return &Encryptor{
+		config: *config,
+	}
+}
+
+func (e Encryptor) Encrypt(plaintext string) (string, error) {
+	gcm, err := e.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (e Encryptor) Decrypt(ciphertext string) (string, error) {
+	gcm, err := e.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+func (e Encryptor) cipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(e.config.Secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"testing"
+
+	"gofi/internal/config"
+)
+
+func TestEncryptor(t *testing.T) {
+	encryptor := NewEncryptor(&config.ConfigApp{Secret: "test-secret"})
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"empty string", ""},
+		{"totp secret", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"},
+		{"unicode", "rahasia 🔒"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := encryptor.Encrypt(tt.plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+
+			if tt.plaintext != "" && ciphertext == tt.plaintext {
+				t.Error("Encrypt() returned the plaintext")
+			}
+
+			got, err := encryptor.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+
+			if got != tt.plaintext {
+				t.Errorf("Decrypt() = %v, expected %v", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptorDecryptErrors(t *testing.T) {
+	encryptor := NewEncryptor(&config.ConfigApp{Secret: "test-secret"})
+	other := NewEncryptor(&config.ConfigApp{Secret: "other-secret"})
+
+	ciphertext, err := other.Encrypt("value")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		ciphertext string
+	}{
+		{"encrypted with another secret", ciphertext},
+		{"invalid base64", "not base64!"},
+		{"too short", "AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encryptor.Decrypt(tt.ciphertext)
+			if err != ErrInvalidCiphertext {
+				t.Errorf("Decrypt() error = %v, expected %v", err, ErrInvalidCiphertext)
+			}
+		})
+	}
+}
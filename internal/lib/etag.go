@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag is the strong entity tag of a record, its updated_at is its version.
+func ETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 36) + `"`
+}
+
+// MatchETag reports whether the If-Match header matches etag. Weak tags never
+// match, RFC 9110 requires a strong comparison for If-Match.
+func MatchETag(header string, etag string) bool {
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ContextSetETag(c *fiber.Ctx, updatedAt time.Time) {
+	c.Set(fiber.HeaderETag, ETag(updatedAt))
+}
+
+// ContextIfMatch reports whether the record at updatedAt satisfies the
+// If-Match precondition of the request, requests without one always do.
+func ContextIfMatch(c *fiber.Ctx, updatedAt time.Time) bool {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return true
+	}
+
+	return MatchETag(header, ETag(updatedAt))
+}
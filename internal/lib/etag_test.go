@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchETag(t *testing.T) {
+	etag := ETag(time.Date(2025, 1, 1, 0, 0, 0, 123456000, time.UTC))
+	other := ETag(time.Date(2025, 1, 1, 0, 0, 0, 123457000, time.UTC))
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"Same tag", etag, true},
+		{"Other version", other, false},
+		{"Any tag", "*", true},
+		{"One of a list", other + ", " + etag, true},
+		{"Weak tag", "W/" + etag, false},
+		{"Unquoted tag", etag[1 : len(etag)-1], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchETag(tt.header, etag); got != tt.want {
+				t.Errorf("MatchETag(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
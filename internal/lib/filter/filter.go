@@ -0,0 +1,294 @@
+// Package filter parses the filters and sorting of list endpoints, e.g.
+// `?filter[email][ilike]=doe&sort=-created_at,first_name`, against a
+// whitelist of fields and compiles them into parameterized SQL.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gofi/internal/lib/validator"
+
+	"github.com/google/uuid"
+)
+
+type Type int
+
+const (
+	String Type = iota
+	Number
+	Bool
+	UUID
+	Time
+)
+
+type Operator string
+
+const (
+	Eq    Operator = "eq"
+	Ne    Operator = "ne"
+	Gt    Operator = "gt"
+	Gte   Operator = "gte"
+	Lt    Operator = "lt"
+	Lte   Operator = "lte"
+	Like  Operator = "like"
+	Ilike Operator = "ilike"
+	In    Operator = "in"
+	// Null takes "true" for IS NULL and "false" for IS NOT NULL.
+	Null Operator = "null"
+)
+
+// MaxInValues caps the values of an "in" filter.
+const MaxInValues = 100
+
+// Field whitelists a field of a resource.
+type Field struct {
+	// Column is the SQL expression the field compiles to, e.g. `"u"."email"`.
+	// It is never taken from the request.
+	Column    string
+	Type      Type
+	Operators []Operator
+	Sortable  bool
+	// Nullable fields can't be the sort of a cursor paginated list, NULLs
+	// don't compare.
+	Nullable bool
+}
+
+// Fields is the whitelist of a resource, keyed by the name used in the
+// query string.
+type Fields map[string]Field
+
+type Condition struct {
+	Column   string
+	Operator Operator
+	// Value is []any for In and bool for Null.
+	Value any
+}
+
+type Sort struct {
+	Column   string
+	Desc     bool
+	Nullable bool
+}
+
+// Query is a parsed and validated filter, only whitelisted columns end up in
+// it.
+type Query struct {
+	Conditions []Condition
+	Sorts      []Sort
+}
+
+var filterKey = regexp.MustCompile(`^filter\[([a-z0-9_]+)\](?:\[([a-z]+)\])?$`)
+
+// Parse reads the `filter[<field>][<operator>]` and `sort` parameters of the
+// query string, the operator defaults to eq. Parameters that aren't filters
+// are ignored.
+func Parse(values url.Values, fields Fields) (Query, validator.MessageRecord) {
+	var q Query
+	mr := make(validator.MessageRecord)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "filter[") {
+			continue
+		}
+
+		matches := filterKey.FindStringSubmatch(key)
+		if matches == nil {
+			mr["filter"] = append(mr["filter"], fmt.Sprintf("%s is not a valid filter", key))
+			continue
+		}
+
+		name, operator := matches[1], Operator(matches[2])
+		if operator == "" {
+			operator = Eq
+		}
+
+		path := "filter." + name
+
+		field, ok := fields[name]
+		if !ok {
+			mr[path] = append(mr[path], fmt.Sprintf("%s is not filterable", name))
+			continue
+		}
+
+		if !slices.Contains(field.Operators, operator) {
+			mr[path] = append(mr[path], fmt.Sprintf("%s does not support the %s operator", name, operator))
+			continue
+		}
+
+		for _, raw := range values[key] {
+			value, err := parseValue(field.Type, operator, raw)
+			if err != nil {
+				mr[path] = append(mr[path], fmt.Sprintf("%s %s", name, err.Error()))
+				continue
+			}
+
+			q.Conditions = append(q.Conditions, Condition{
+				Column:   field.Column,
+				Operator: operator,
+				Value:    value,
+			})
+		}
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		for name := range strings.SplitSeq(sort, ",") {
+			name = strings.TrimSpace(name)
+			desc := strings.HasPrefix(name, "-")
+			name = strings.TrimPrefix(name, "-")
+
+			field, ok := fields[name]
+			if !ok || !field.Sortable {
+				mr["sort"] = append(mr["sort"], fmt.Sprintf("%s is not sortable", name))
+				continue
+			}
+
+			q.Sorts = append(q.Sorts, Sort{Column: field.Column, Desc: desc, Nullable: field.Nullable})
+		}
+	}
+
+	return q, mr
+}
+
+func parseValue(t Type, operator Operator, raw string) (any, error) {
+	switch operator {
+	case Null:
+		null, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("is not a boolean")
+		}
+		return null, nil
+
+	case In:
+		parts := strings.Split(raw, ",")
+		if len(parts) > MaxInValues {
+			return nil, fmt.Errorf("must have at most %d values", MaxInValues)
+		}
+
+		values := make([]any, 0, len(parts))
+		for _, part := range parts {
+			value, err := parseScalar(t, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+
+	case Like, Ilike:
+		if t != String {
+			return nil, errors.New("is not a string")
+		}
+		return "%" + escapeLike(raw) + "%", nil
+	}
+
+	return parseScalar(t, raw)
+}
+
+func parseScalar(t Type, raw string) (any, error) {
+	switch t {
+	case Number:
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, errors.New("is not a number")
+		}
+		return number, nil
+	case Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("is not a boolean")
+		}
+		return b, nil
+	case UUID:
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, errors.New("is not a valid uuid")
+		}
+		return id, nil
+	case Time:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, errors.New("is not a valid RFC3339 date")
+		}
+		return t, nil
+	default:
+		return raw, nil
+	}
+}
+
+// escapeLike makes the wildcards of the value match literally, the filter
+// is always a "contains".
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+var comparisons = map[Operator]string{
+	Eq:    "=",
+	Ne:    "<>",
+	Gt:    ">",
+	Gte:   ">=",
+	Lt:    "<",
+	Lte:   "<=",
+	Like:  "LIKE",
+	Ilike: "ILIKE",
+}
+
+// Where compiles the conditions, placeholders start at $argIndex. The
+// conditions are meant to be joined with AND.
+func (q Query) Where(argIndex int) ([]string, []any) {
+	var conditions []string
+	var args []any
+
+	placeholder := func(arg any) string {
+		args = append(args, arg)
+		return "$" + strconv.Itoa(argIndex+len(args)-1)
+	}
+
+	for _, condition := range q.Conditions {
+		switch condition.Operator {
+		case Null:
+			if condition.Value.(bool) {
+				conditions = append(conditions, condition.Column+" IS NULL")
+			} else {
+				conditions = append(conditions, condition.Column+" IS NOT NULL")
+			}
+		case In:
+			values := condition.Value.([]any)
+			placeholders := make([]string, 0, len(values))
+			for _, value := range values {
+				placeholders = append(placeholders, placeholder(value))
+			}
+			conditions = append(conditions, fmt.Sprintf("%s IN (%s)", condition.Column, strings.Join(placeholders, ", ")))
+		default:
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", condition.Column, comparisons[condition.Operator], placeholder(condition.Value)))
+		}
+	}
+
+	return conditions, args
+}
+
+// OrderBy compiles the sorts without the ORDER BY keyword, it is empty when
+// there is no sort so the caller can fall back to its default.
+func (q Query) OrderBy() string {
+	orders := make([]string, 0, len(q.Sorts))
+	for _, sort := range q.Sorts {
+		if sort.Desc {
+			orders = append(orders, sort.Column+" DESC")
+		} else {
+			orders = append(orders, sort.Column+" ASC")
+		}
+	}
+	return strings.Join(orders, ", ")
+}
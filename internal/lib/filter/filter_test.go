@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var testFields = Fields{
+	"email":      {Column: `"u"."email"`, Type: String, Operators: []Operator{Eq, Ilike}, Sortable: true},
+	"role_id":    {Column: `"u"."role_id"`, Type: UUID, Operators: []Operator{Eq, In}},
+	"created_at": {Column: `"u"."created_at"`, Type: Time, Operators: []Operator{Gte, Lt}, Sortable: true},
+	"blocked_at": {Column: `"u"."blocked_at"`, Type: Time, Operators: []Operator{Null}},
+}
+
+func TestParse(t *testing.T) {
+	roleA := uuid.MustParse("0194fdc2-fa2f-7000-8000-000000000001")
+	roleB := uuid.MustParse("0194fdc2-fa2f-7000-8000-000000000002")
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		conditions []Condition
+		sorts      []Sort
+		errors     []string
+	}{
+		{
+			name:  "operator defaults to eq",
+			query: "filter[email]=doe@example.com",
+			conditions: []Condition{
+				{Column: `"u"."email"`, Operator: Eq, Value: "doe@example.com"},
+			},
+		},
+		{
+			name:  "ilike escapes wildcards",
+			query: "filter[email][ilike]=50%25_off",
+			conditions: []Condition{
+				{Column: `"u"."email"`, Operator: Ilike, Value: `%50\%\_off%`},
+			},
+		},
+		{
+			name:  "in splits values",
+			query: "filter[role_id][in]=" + roleA.String() + "," + roleB.String(),
+			conditions: []Condition{
+				{Column: `"u"."role_id"`, Operator: In, Value: []any{roleA, roleB}},
+			},
+		},
+		{
+			name:  "typed values",
+			query: "filter[created_at][gte]=2025-01-01T00:00:00Z&filter[blocked_at][null]=true",
+			conditions: []Condition{
+				{Column: `"u"."blocked_at"`, Operator: Null, Value: true},
+				{Column: `"u"."created_at"`, Operator: Gte, Value: from},
+			},
+		},
+		{
+			name:  "sort",
+			query: "sort=-created_at,email",
+			sorts: []Sort{
+				{Column: `"u"."created_at"`, Desc: true},
+				{Column: `"u"."email"`},
+			},
+		},
+		{
+			name:  "other parameters are ignored",
+			query: "offset=0&limit=10",
+		},
+		{
+			name:   "unknown field",
+			query:  "filter[password]=secret",
+			errors: []string{"filter.password"},
+		},
+		{
+			name:   "operator not allowed",
+			query:  "filter[email][gte]=a",
+			errors: []string{"filter.email"},
+		},
+		{
+			name:   "invalid value",
+			query:  "filter[role_id]=admin",
+			errors: []string{"filter.role_id"},
+		},
+		{
+			name:   "malformed key",
+			query:  "filter[email][ilike][x]=a",
+			errors: []string{"filter"},
+		},
+		{
+			name:   "field not sortable",
+			query:  "sort=role_id",
+			errors: []string{"sort"},
+		},
+		{
+			name:   "column injection",
+			query:  "sort=created_at%3BDROP+TABLE+users",
+			errors: []string{"sort"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q, mr := Parse(values, testFields)
+
+			for _, key := range tt.errors {
+				if _, ok := mr[key]; !ok {
+					t.Errorf("expected error on %q, got %v", key, mr)
+				}
+			}
+			if len(tt.errors) == 0 && !mr.Empty() {
+				t.Fatalf("unexpected errors: %v", mr)
+			}
+			if len(tt.errors) > 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(q.Conditions, tt.conditions) {
+				t.Errorf("conditions = %#v, want %#v", q.Conditions, tt.conditions)
+			}
+			if !reflect.DeepEqual(q.Sorts, tt.sorts) {
+				t.Errorf("sorts = %#v, want %#v", q.Sorts, tt.sorts)
+			}
+		})
+	}
+}
+
+func TestQueryWhere(t *testing.T) {
+	q := Query{
+		Conditions: []Condition{
+			{Column: `"u"."email"`, Operator: Ilike, Value: "%doe%"},
+			{Column: `"u"."role_id"`, Operator: In, Value: []any{"a", "b"}},
+			{Column: `"u"."blocked_at"`, Operator: Null, Value: false},
+			{Column: `"u"."created_at"`, Operator: Lt, Value: "t"},
+		},
+	}
+
+	conditions, args := q.Where(3)
+
+	expected := []string{
+		`"u"."email" ILIKE $3`,
+		`"u"."role_id" IN ($4, $5)`,
+		`"u"."blocked_at" IS NOT NULL`,
+		`"u"."created_at" < $6`,
+	}
+	if !reflect.DeepEqual(conditions, expected) {
+		t.Errorf("conditions = %v, want %v", conditions, expected)
+	}
+
+	if !reflect.DeepEqual(args, []any{"%doe%", "a", "b", "t"}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestQueryOrderBy(t *testing.T) {
+	if got := (Query{}).OrderBy(); got != "" {
+		t.Errorf("empty OrderBy = %q", got)
+	}
+
+	q := Query{Sorts: []Sort{{Column: `"created_at"`, Desc: true}, {Column: `"name"`}}}
+	if got := q.OrderBy(); got != `"created_at" DESC, "name" ASC` {
+		t.Errorf("OrderBy = %q", got)
+	}
+}
@@ -0,0 +1,8 @@
+package imaging
+
+import "errors"
+
+var (
+	ErrUnsupportedFormat = errors.New("image format is not supported, use jpeg, png, gif or webp")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
@@ -0,0 +1,105 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the decoded dimensions, a small file can declare a huge
+// image.
+const MaxPixels = 4096 * 4096
+
+var supportedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// Sniff returns the content type of data from its bytes rather than from
+// what the client claims.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+
+	for _, supported := range supportedTypes {
+		if contentType == supported {
+			return contentType, nil
+		}
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// Decode decodes data and applies its EXIF orientation, the returned image
+// carries no metadata anymore.
+func Decode(data []byte) (image.Image, string, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	return img, contentType, nil
+}
+
+// Encode writes img as jpeg when contentType is jpeg and as png otherwise so
+// transparency survives, the written content type is returned.
+func Encode(w io.Writer, img image.Image, contentType string) (string, error) {
+	if contentType == "image/jpeg" {
+		return "image/jpeg", EncodeJPEG(w, img, 90)
+	}
+
+	return "image/png", png.Encode(w, img)
+}
+
+// EncodeJPEG writes img as jpeg, transparent pixels are flattened on white.
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	bounds := img.Bounds()
+
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, bounds.Min, draw.Over)
+
+	return jpeg.Encode(w, flat, &jpeg.Options{Quality: quality})
+}
+
+// Thumbnail crops the center square of img and scales it down to size, a
+// smaller image isn't scaled up.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Point{
+		X: bounds.Min.X + (bounds.Dx()-side)/2,
+		Y: bounds.Min.Y + (bounds.Dy()-side)/2,
+	})
+
+	size = min(size, side)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+
+	return dst
+}
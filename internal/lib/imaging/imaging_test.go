@@ -0,0 +1,135 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// exifJPEG returns the start of a jpeg holding an EXIF orientation tag.
+func exifJPEG(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(data[4:], uint16(len(segment)+2))
+	data = append(data, segment...)
+
+	return append(data, 0xFF, 0xDA, 0, 2)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"Little endian", exifJPEG(binary.LittleEndian, 6), 6},
+		{"Big endian", exifJPEG(binary.BigEndian, 8), 8},
+		{"Out of range", exifJPEG(binary.BigEndian, 9), 1},
+		{"No EXIF", []byte{0xFF, 0xD8, 0xFF, 0xDA, 0, 2}, 1},
+		{"Not a jpeg", []byte("hello world"), 1},
+		{"Truncated", exifJPEG(binary.LittleEndian, 6)[:12], 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1 image, red on the left and blue on the right
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	src.Set(1, 0, color.NRGBA{B: 255, A: 255})
+
+	tests := []struct {
+		name        string
+		orientation int
+		wantBounds  image.Rectangle
+		// position of the red pixel
+		wantRed image.Point
+	}{
+		{"As stored", 1, image.Rect(0, 0, 2, 1), image.Point{0, 0}},
+		{"Mirrored", 2, image.Rect(0, 0, 2, 1), image.Point{1, 0}},
+		{"Rotated 90 clockwise", 6, image.Rect(0, 0, 1, 2), image.Point{0, 0}},
+		{"Rotated 90 counterclockwise", 8, image.Rect(0, 0, 1, 2), image.Point{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := orient(src, tt.orientation)
+			if got.Bounds() != tt.wantBounds {
+				t.Fatalf("orient() bounds = %v, want %v", got.Bounds(), tt.wantBounds)
+			}
+
+			r, _, _, _ := got.At(tt.wantRed.X, tt.wantRed.Y).RGBA()
+			if r == 0 {
+				t.Errorf("orient() red pixel isn't at %v", tt.wantRed)
+			}
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+
+	tests := []struct {
+		name string
+		size int
+		want int
+	}{
+		{"Scaled down", 64, 64},
+		{"Not scaled up", 512, 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Thumbnail(src, tt.size).Bounds()
+			if got.Dx() != tt.want || got.Dy() != tt.want {
+				t.Errorf("Thumbnail() = %v, want %dx%d", got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+
+	img, contentType, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if contentType != "image/png" || img.Bounds().Dx() != 4 {
+		t.Errorf("Decode() = %v %v, want image/png 4x3", contentType, img.Bounds())
+	}
+
+	_, _, err = Decode([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	if err != ErrUnsupportedFormat {
+		t.Errorf("Decode() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
@@ -0,0 +1,26 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, so they are told apart from JWT and
+// easy to spot by secret scanners.
+const APIKeyPrefix = "gofi_pat_"
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IsAPIKey reports whether token has the format of an API key.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix) && len(token) > len(APIKeyPrefix)
+}
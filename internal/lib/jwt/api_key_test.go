@@ -0,0 +1,44 @@
+package jwt
+
+import "testing"
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+
+	if !IsAPIKey(key) {
+		t.Errorf("IsAPIKey(%q) = false, want true", key)
+	}
+
+	other, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+
+	if key == other {
+		t.Errorf("GenerateAPIKey() returned the same key twice")
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "API key", token: "gofi_pat_abc123", want: true},
+		{name: "Prefix only", token: "gofi_pat_", want: false},
+		{name: "JWT", token: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.sig", want: false},
+		{name: "Empty", token: "", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsAPIKey(tc.token); got != tc.want {
+				t.Errorf("IsAPIKey(%q) = %v, want %v", tc.token, got, tc.want)
+			}
+		})
+	}
+}
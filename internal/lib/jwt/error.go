@@ -6,4 +6,9 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrExpiredToken       = errors.New("token has expired")
+	ErrTokenNotFound      = errors.New("token not found")
+	ErrInvalidTokenFormat = errors.New("invalid token format")
+	ErrInvalidKey         = errors.New("the key is not a valid PEM encoded key")
+	ErrUnsupportedKey     = errors.New("the key type is not supported, use RSA, ECDSA or Ed25519")
+	ErrNoSigningKey       = errors.New("no private key to sign tokens")
 )
@@ -6,6 +6,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// ExtractToken returns the JWT or API key of the request, API keys can also
+// be sent with the X-API-Key header.
 func (j *JWT) ExtractToken(c *fiber.Ctx) (string, error) {
 	contextQuery := c.Query("token")
 	if contextQuery != "" {
@@ -17,20 +19,29 @@ func (j *JWT) ExtractToken(c *fiber.Ctx) (string, error) {
 		return contextCookie, nil
 	}
 
+	contextAPIKey := c.Get("X-API-Key")
+	if contextAPIKey != "" {
+		if !IsAPIKey(contextAPIKey) {
+			return "", ErrInvalidTokenFormat
+		}
+
+		return contextAPIKey, nil
+	}
+
 	contextHeader := c.Get("Authorization")
 	if contextHeader != "" {
 		// Bearer <token>
 		parts := strings.Split(contextHeader, " ")
 		if len(parts) != 2 {
-			return "", ErrInvalidToken
+			return "", ErrInvalidTokenFormat
 		}
 
 		if parts[0] != "Bearer" || parts[1] == "" {
-			return "", ErrInvalidToken
+			return "", ErrInvalidTokenFormat
 		}
 
 		return parts[1], nil
 	}
 
-	return "", ErrInvalidToken
+	return "", ErrTokenNotFound
 }
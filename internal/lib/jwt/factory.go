@@ -2,8 +2,11 @@ package jwt
 
 import "gofi/internal/config"
 
-func New(config *config.ConfigApp) *JWT {
+// New returns a JWT signing with the keyring, or with HS256 and
+// config.JWTSecret when keyring is nil.
+func New(config *config.ConfigApp, keyring *Keyring) *JWT {
 	return &JWT{
-		config: config,
+		config:  config,
+		keyring: keyring,
 	}
 }
@@ -7,23 +7,29 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// accessTokenType marks the tokens Verify accepts.
+const accessTokenType = "access"
+
 func (j *JWT) Generate(payload *JWTPayload) (string, int64, error) {
 	expiresIn, err := strconv.Atoi(payload.ExpiresAt)
 	if err != nil {
 		return "", 0, err
 	}
 
-	secretKey := []byte(j.config.JWTSecret)
 	ExpiresToken := time.Now().Add(time.Hour * 24 * time.Duration(expiresIn)).Unix()
 
 	claims := jwt.MapClaims{
 		"exp": ExpiresToken,
 		"iss": j.config.Name,
 		"uid": payload.UID,
+		"typ": accessTokenType,
+	}
+
+	if payload.OrganizationID != "" {
+		claims["oid"] = payload.OrganizationID
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString(secretKey)
+	t, err := j.Sign(claims)
 	if err != nil {
 		return "", 0, err
 	}
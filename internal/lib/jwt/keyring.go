@@ -0,0 +1,195 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a signing key of the keyring, identified by the kid header of the
+// tokens it signs. Keys loaded from a public key only verify tokens, they are
+// kept during a rotation until the tokens they signed have expired.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+type Keyring struct {
+	signingKey *Key
+	keys       map[string]*Key
+}
+
+// LoadKeyring loads every *.pem file of dir, the file name without extension
+// is the kid of the key. Tokens are signed with the private key activeKID, or
+// when empty with the last private key in lexical order, so naming the files
+// by date (e.g. 2025-01.pem) rotates to the newest key.
+func LoadKeyring(dir string, activeKID string) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	keyring := &Keyring{keys: map[string]*Key{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := ParseKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("error loading key %s: %w", path, err)
+		}
+
+		keyring.Add(key)
+	}
+
+	if activeKID != "" {
+		if err := keyring.SetSigningKey(activeKID); err != nil {
+			return nil, err
+		}
+	}
+
+	if keyring.signingKey == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	return keyring, nil
+}
+
+// ParseKey parses a PEM encoded private or public key. The signing method
+// follows the key type: RS256 for RSA, ES256/ES384/ES512 for ECDSA depending
+// on the curve and EdDSA for Ed25519.
+func ParseKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidKey
+	}
+
+	key := &Key{ID: kid}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		key.PrivateKey = signer
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey = privateKey
+	case "EC PRIVATE KEY":
+		privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey = privateKey
+	case "PUBLIC KEY":
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PublicKey = publicKey
+	default:
+		return nil, ErrInvalidKey
+	}
+
+	if key.PrivateKey != nil {
+		key.PublicKey = key.PrivateKey.Public()
+	}
+
+	method, err := signingMethod(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	key.Method = method
+
+	return key, nil
+}
+
+func signingMethod(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+// Add adds the key to the keyring, the last private key added becomes the
+// signing key.
+func (k *Keyring) Add(key *Key) {
+	k.keys[key.ID] = key
+
+	if key.PrivateKey != nil {
+		k.signingKey = key
+	}
+}
+
+func (k *Keyring) SetSigningKey(kid string) error {
+	key, ok := k.keys[kid]
+	if !ok || key.PrivateKey == nil {
+		return fmt.Errorf("%w: %s", ErrNoSigningKey, kid)
+	}
+
+	k.signingKey = key
+	return nil
+}
+
+func (k *Keyring) SigningKey() *Key {
+	return k.signingKey
+}
+
+func (k *Keyring) Get(kid string) (*Key, bool) {
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// Keys returns every key of the keyring sorted by kid.
+func (k *Keyring) Keys() []*Key {
+	keys := make([]*Key, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys
+}
@@ -0,0 +1,242 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gofi/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writePrivateKey(t *testing.T, dir string, kid string, key crypto.Signer) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func writePublicKey(t *testing.T, dir string, kid string, key crypto.PublicKey) {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey() error = %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestKeyringGenerateVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		wantAlg string
+	}{
+		{"RSA key signs RS256", rsaKey, "RS256"},
+		{"ECDSA P-256 key signs ES256", ecKey, "ES256"},
+		{"Ed25519 key signs EdDSA", edKey, "EdDSA"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writePrivateKey(t, dir, "key-1", tc.key)
+
+			keyring, err := LoadKeyring(dir, "")
+			if err != nil {
+				t.Fatalf("LoadKeyring() error = %v", err)
+			}
+
+			j := New(&config.ConfigApp{Name: "gofi"}, keyring)
+
+			uid := uuid.New().String()
+			token, _, err := j.Generate(&JWTPayload{UID: uid, ExpiresAt: "1"})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified() error = %v", err)
+			}
+
+			if parsed.Header["kid"] != "key-1" {
+				t.Errorf("Generate() kid = %v, want key-1", parsed.Header["kid"])
+			}
+
+			if parsed.Method.Alg() != tc.wantAlg {
+				t.Errorf("Generate() alg = %v, want %v", parsed.Method.Alg(), tc.wantAlg)
+			}
+
+			claims, err := j.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			if claims.UID != uid {
+				t.Errorf("Verify() UID = %v, want %v", claims.UID, uid)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cfg := &config.ConfigApp{Name: "gofi"}
+
+	oldDir := t.TempDir()
+	writePrivateKey(t, oldDir, "2024-01", oldKey)
+
+	oldKeyring, err := LoadKeyring(oldDir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+
+	oldToken, _, err := New(cfg, oldKeyring).Generate(&JWTPayload{UID: "uid", ExpiresAt: "1"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// the old private key is replaced by its public key during the rotation
+	dir := t.TempDir()
+	writePublicKey(t, dir, "2024-01", oldKey.Public())
+	writePrivateKey(t, dir, "2025-01", newKey)
+
+	keyring, err := LoadKeyring(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+
+	if keyring.SigningKey().ID != "2025-01" {
+		t.Errorf("SigningKey() = %v, want 2025-01", keyring.SigningKey().ID)
+	}
+
+	j := New(cfg, keyring)
+
+	if _, err := j.Verify(oldToken); err != nil {
+		t.Errorf("Verify() token of the rotated key error = %v", err)
+	}
+
+	if err := keyring.SetSigningKey("2024-01"); err == nil {
+		t.Error("SetSigningKey() with a public key should fail")
+	}
+
+	jwks := keyring.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() returned %d keys, want 2", len(jwks.Keys))
+	}
+
+	for _, key := range jwks.Keys {
+		if key.Kty != "EC" || key.Crv != "P-256" || key.Alg != "ES256" || key.X == "" || key.Y == "" {
+			t.Errorf("JWKS() key = %+v, want an ES256 key", key)
+		}
+	}
+}
+
+func TestKeyringVerifyRejects(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	cfg := &config.ConfigApp{Name: "gofi", JWTSecret: "test-secret-key-12345"}
+
+	dir := t.TempDir()
+	writePrivateKey(t, dir, "key-1", rsaKey)
+
+	keyring, err := LoadKeyring(dir, "key-1")
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+
+	claims := jwt.MapClaims{"exp": 9999999999, "iss": "gofi", "uid": "uid"}
+
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknownKid.Header["kid"] = "unknown"
+	unknownKidToken, _ := unknownKid.SignedString(rsaKey)
+
+	// HS256 signed with the public key, the classic algorithm confusion attack
+	confusion := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confusion.Header["kid"] = "key-1"
+	der, _ := x509.MarshalPKIXPublicKey(rsaKey.Public())
+	confusionToken, _ := confusion.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	legacyToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+
+	tests := []struct {
+		name        string
+		token       string
+		wantErr     bool
+		errContains string
+	}{
+		{"Unknown kid", unknownKidToken, true, "unknown key id"},
+		{"Algorithm not matching the key", confusionToken, true, "unexpected signing method"},
+		{"HS256 token without kid", legacyToken, true, "no key id"},
+	}
+
+	j := New(cfg, keyring)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := j.Verify(tc.token)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if tc.wantErr && !strings.Contains(err.Error(), tc.errContains) {
+				t.Errorf("Verify() error = %v, want containing %v", err, tc.errContains)
+			}
+		})
+	}
+}
+
+func TestLoadKeyringErrors(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	t.Run("Empty directory", func(t *testing.T) {
+		if _, err := LoadKeyring(t.TempDir(), ""); err == nil {
+			t.Error("LoadKeyring() should fail without a private key")
+		}
+	})
+
+	t.Run("Unknown active key", func(t *testing.T) {
+		dir := t.TempDir()
+		writePrivateKey(t, dir, "key-1", ecKey)
+
+		if _, err := LoadKeyring(dir, "key-2"); err == nil {
+			t.Error("LoadKeyring() should fail with an unknown active key")
+		}
+	})
+
+	t.Run("Invalid PEM", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "key-1.pem"), []byte("not a key"), 0o600)
+
+		if _, err := LoadKeyring(dir, ""); err == nil {
+			t.Error("LoadKeyring() should fail with an invalid key")
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package jwt
+
+import "github.com/golang-jwt/jwt/v5"
+
+// Sign signs the claims of the tokens we verify ourselves, with the signing
+// key of the keyring or HS256 when there is no keyring.
+func (j *JWT) Sign(claims map[string]any) (string, error) {
+	if j.keyring != nil {
+		return j.signWithKeyring(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims))
+	return token.SignedString([]byte(j.config.JWTSecret))
+}
+
+// SignIDToken signs tokens verified by third parties, e.g. an OpenID Connect
+// ID token. They are verified with the published JWKS, so they are never
+// signed with the JWT secret.
+func (j *JWT) SignIDToken(claims map[string]any) (string, error) {
+	if j.keyring == nil {
+		return "", ErrNoSigningKey
+	}
+
+	return j.signWithKeyring(claims)
+}
+
+func (j *JWT) signWithKeyring(claims map[string]any) (string, error) {
+	key := j.keyring.SigningKey()
+
+	token := jwt.NewWithClaims(key.Method, jwt.MapClaims(claims))
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
@@ -0,0 +1,54 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"gofi/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestSignIDToken(t *testing.T) {
+	claims := map[string]any{"sub": "user", "aud": "client"}
+
+	t.Run("Without keyring", func(t *testing.T) {
+		j := New(&config.ConfigApp{Name: "gofi", JWTSecret: "secret"}, nil)
+
+		_, err := j.SignIDToken(claims)
+		if !errors.Is(err, ErrNoSigningKey) {
+			t.Errorf("SignIDToken() error = %v, want %v", err, ErrNoSigningKey)
+		}
+	})
+
+	t.Run("With keyring", func(t *testing.T) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		dir := t.TempDir()
+		writePrivateKey(t, dir, "key-1", key)
+
+		keyring, err := LoadKeyring(dir, "")
+		if err != nil {
+			t.Fatalf("LoadKeyring() error = %v", err)
+		}
+
+		j := New(&config.ConfigApp{Name: "gofi", JWTSecret: "secret"}, keyring)
+
+		token, err := j.SignIDToken(claims)
+		if err != nil {
+			t.Fatalf("SignIDToken() error = %v", err)
+		}
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		if err != nil {
+			t.Fatalf("ParseUnverified() error = %v", err)
+		}
+
+		if parsed.Method.Alg() != "ES256" || parsed.Header["kid"] != "key-1" {
+			t.Errorf("SignIDToken() alg = %v, kid = %v, want ES256 and key-1", parsed.Method.Alg(), parsed.Header["kid"])
+		}
+	})
+}
@@ -3,17 +3,21 @@ package jwt
 import "gofi/internal/config"
 
 type JWT struct {
-	config *config.ConfigApp
+	config  *config.ConfigApp
+	keyring *Keyring
 }
 
 type JWTPayload struct {
 	UID       string
 	Secret    string
 	ExpiresAt string
+	// OrganizationID is the active organization, empty when none
+	OrganizationID string
 }
 
 type JWTClaims struct {
 	Exp int64
 	Iss string
 	UID string
+	OID string // active organization, empty when none
 }
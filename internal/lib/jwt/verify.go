@@ -8,12 +8,7 @@ import (
 )
 
 func (j *JWT) Verify(extractToken string) (*JWTClaims, error) {
-	token, err := jwt.Parse(extractToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Method.Alg())
-		}
-		return []byte(j.config.JWTSecret), nil
-	})
+	token, err := jwt.Parse(extractToken, j.keyFunc)
 
 	if err != nil {
 		message := fmt.Sprintf("Token verification failed: %v. Please ensure your token is valid and not expired.", err)
@@ -24,13 +19,72 @@ func (j *JWT) Verify(extractToken string) (*JWTClaims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return &JWTClaims{
-			Exp: int64(claims["exp"].(float64)),
-			Iss: claims["iss"].(string),
-			UID: claims["uid"].(string),
-		}, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	// ID tokens are signed with the same keys, they carry an audience and
+	// must never pass as access tokens. Tokens issued before typ existed
+	// have neither claim and are still accepted.
+	if typ, ok := claims["typ"]; ok && typ != accessTokenType {
+		return nil, ErrInvalidToken
+	}
+	if _, ok := claims["aud"]; ok {
+		return nil, ErrInvalidToken
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	uid, ok := claims["uid"].(string)
+	if !ok || uid == "" {
+		return nil, ErrInvalidToken
+	}
+
+	iss, _ := claims["iss"].(string)
+
+	// oid is only set once the user picked an organization
+	oid, _ := claims["oid"].(string)
+
+	return &JWTClaims{
+		Exp: int64(exp),
+		Iss: iss,
+		UID: uid,
+		OID: oid,
+	}, nil
+}
+
+// keyFunc picks the verification key by the kid header. Tokens without kid
+// are signed with HS256 and the JWT secret, they are only accepted while no
+// keyring is loaded, once it is the secret must not open a way around it.
+func (j *JWT) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, ok := t.Header["kid"].(string)
+	if !ok {
+		if j.keyring != nil {
+			return nil, errors.New("token has no key id")
+		}
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Method.Alg())
+		}
+		return []byte(j.config.JWTSecret), nil
+	}
+
+	if j.keyring == nil {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+
+	key, ok := j.keyring.Get(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+
+	// the algorithm is bound to the key, never to the token header
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Method.Alg())
 	}
 
-	return nil, ErrInvalidToken
+	return key.PublicKey, nil
 }
@@ -1,6 +1,7 @@
 package jwt
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -311,6 +312,106 @@ func TestVerifyIntegrationWithGenerate(t *testing.T) {
 	}
 }
 
+func TestVerifyOrganizationClaim(t *testing.T) {
+	j := &JWT{
+		config: &config.ConfigApp{
+			Name:      "gofi",
+			JWTSecret: "test-secret-key-12345",
+		},
+	}
+
+	oid := uuid.New().String()
+
+	token, _, err := j.Generate(&JWTPayload{
+		UID:            uuid.New().String(),
+		ExpiresAt:      "1",
+		OrganizationID: oid,
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	claims, err := j.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if claims.OID != oid {
+		t.Errorf("Verify() claims.OID = %v, want %v", claims.OID, oid)
+	}
+
+	// tokens without an active organization have no oid claim
+	token, _, err = j.Generate(&JWTPayload{
+		UID:       uuid.New().String(),
+		ExpiresAt: "1",
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	claims, err = j.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if claims.OID != "" {
+		t.Errorf("Verify() claims.OID = %v, want empty", claims.OID)
+	}
+}
+
+func TestVerifyRejectsNonAccessTokens(t *testing.T) {
+	secret := "test-secret-key-12345"
+
+	j := &JWT{
+		config: &config.ConfigApp{
+			Name:      "gofi",
+			JWTSecret: secret,
+		},
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	uid := uuid.New().String()
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{
+			name:   "Missing uid",
+			claims: jwt.MapClaims{"exp": exp, "iss": "gofi"},
+		},
+		{
+			name:   "Missing exp",
+			claims: jwt.MapClaims{"iss": "gofi", "uid": uid},
+		},
+		{
+			name:   "uid is not a string",
+			claims: jwt.MapClaims{"exp": exp, "iss": "gofi", "uid": 42},
+		},
+		{
+			name:   "ID token",
+			claims: jwt.MapClaims{"exp": exp, "iss": "gofi", "sub": uid, "aud": "client"},
+		},
+		{
+			name:   "Wrong typ",
+			claims: jwt.MapClaims{"exp": exp, "iss": "gofi", "uid": uid, "typ": "id"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tc.claims).SignedString([]byte(secret))
+			if err != nil {
+				t.Fatalf("Failed to create test token: %v", err)
+			}
+
+			if _, err := j.Verify(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
 func TestVerifyErrorMessages(t *testing.T) {
 	j := &JWT{
 		config: &config.ConfigApp{
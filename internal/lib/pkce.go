@@ -0,0 +1,22 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// VerifyCodeChallenge checks a PKCE code verifier against the S256 code
+// challenge sent with the authorization request (RFC 7636). The plain method
+// is not supported.
+func VerifyCodeChallenge(verifier string, challenge string) bool {
+	// RFC 7636 4.1, the verifier has between 43 and 128 characters
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,30 @@
+package lib
+
+import "testing"
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// example from RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"Matching verifier", verifier, challenge, true},
+		{"Wrong verifier", "aBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", challenge, false},
+		{"Plain challenge is rejected", verifier, verifier, false},
+		{"Verifier too short", "short", "short", false},
+		{"Empty challenge", verifier, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyCodeChallenge(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("VerifyCodeChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
@@ -19,7 +20,11 @@ func NewRefreshToken(config *config.ConfigApp) *RefreshToken {
 }
 
 func (h RefreshToken) Generate(data string, expires int64) string {
-	message := fmt.Sprintf("%s.%d", data, expires)
+	// random nonce so two tokens issued in the same second never collide
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+
+	message := fmt.Sprintf("%s.%d.%s", data, expires, base64.RawURLEncoding.EncodeToString(nonce))
 
 	hash := hmac.New(sha256.New, []byte(h.config.Secret))
 	hash.Write([]byte(message))
@@ -3,7 +3,9 @@ package lib
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 
+	"gofi/internal/lib/filter"
 	"gofi/internal/lib/validator"
 
 	"github.com/gofiber/fiber/v2"
@@ -54,6 +56,22 @@ func ValidateRequestQuery(c *fiber.Ctx, obj Validatable) error {
 	return ValidateStruct(obj)
 }
 
+// ValidateRequestFilter parses the `filter[...]` and `sort` parameters of the
+// query string against the whitelist of the resource.
+func ValidateRequestFilter(c *fiber.Ctx, fields filter.Fields) (filter.Query, error) {
+	values := make(url.Values)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		values.Add(string(key), string(value))
+	})
+
+	q, mr := filter.Parse(values, fields)
+	if !mr.Empty() {
+		return filter.Query{}, &ErrValidationFailed{MessageRecord: mr}
+	}
+
+	return q, nil
+}
+
 func ValidateRequestBody(c *fiber.Ctx, obj Validatable) error {
 	err := c.BodyParser(obj)
 	if err != nil {
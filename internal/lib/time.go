@@ -1,7 +1,35 @@
 package lib
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 func TimePtr(t time.Time) *time.Time {
 	return &t
 }
+
+// HumanizeDuration writes d in the largest unit it is a whole number of,
+// e.g. "15 minutes" or "1 hour", for messages shown to users.
+func HumanizeDuration(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+		{time.Second, "second"},
+	}
+
+	for _, unit := range units {
+		if d >= unit.size && d%unit.size == 0 {
+			n := int64(d / unit.size)
+			if n == 1 {
+				return fmt.Sprintf("1 %s", unit.name)
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+
+	return d.String()
+}
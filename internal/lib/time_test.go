@@ -0,0 +1,28 @@
+package lib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanizeDuration(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{input: 15 * time.Minute, expected: "15 minutes"},
+		{input: time.Hour, expected: "1 hour"},
+		{input: 2 * time.Hour, expected: "2 hours"},
+		{input: 90 * time.Minute, expected: "90 minutes"},
+		{input: 30 * time.Second, expected: "30 seconds"},
+		{input: 1500 * time.Millisecond, expected: "1.5s"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expected, func(t *testing.T) {
+			if got := HumanizeDuration(tc.input); got != tc.expected {
+				t.Errorf("HumanizeDuration(%v) = %v, want %v", tc.input, got, tc.expected)
+			}
+		})
+	}
+}
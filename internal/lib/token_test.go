@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestGenerateRandomToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{"Generate 16 bytes", 16},
+		{"Generate 32 bytes", 32},
+		{"Generate 64 bytes", 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := GenerateRandomToken(tt.length)
+			if err != nil {
+				t.Fatalf("GenerateRandomToken() error = %v", err)
+			}
+
+			decoded, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				t.Fatalf("GenerateRandomToken() returned invalid encoding: %v", err)
+			}
+
+			if len(decoded) != tt.length {
+				t.Errorf("GenerateRandomToken() decoded length = %d, want %d", len(decoded), tt.length)
+			}
+		})
+	}
+
+	first, _ := GenerateRandomToken(32)
+	second, _ := GenerateRandomToken(32)
+	if first == second {
+		t.Error("GenerateRandomToken() returned the same token twice")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "empty string",
+			input:    "",
+			expected: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:     "simple token",
+			input:    "token",
+			expected: "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HashToken(tt.input)
+			if got != tt.expected {
+				t.Errorf("HashToken() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Generate returns the code of secret at the given time.
+func (t *TOTP) Generate(secret string, at time.Time) (string, error) {
+	key, err := t.decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return t.generateCode(key, t.step(at)), nil
+}
+
+// Validate checks code against secret at the given time, tolerating a clock
+// drift of Skew periods. The matched time step is returned so callers can
+// reject a code that was already used.
+func (t *TOTP) Validate(secret string, code string, at time.Time) (step int64, valid bool, err error) {
+	key, err := t.decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(code) != t.Digits {
+		return 0, false, nil
+	}
+
+	current := t.step(at)
+	for i := -t.Skew; i <= t.Skew; i++ {
+		expected := t.generateCode(key, current+i)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + i, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func (t *TOTP) step(at time.Time) int64 {
+	return at.Unix() / t.Period
+}
+
+// generateCode implements the HOTP algorithm from RFC 4226.
+func (t *TOTP) generateCode(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(math.Pow10(t.Digits))
+
+	return fmt.Sprintf("%0*d", t.Digits, value%mod)
+}
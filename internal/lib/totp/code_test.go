@@ -0,0 +1,149 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors for SHA1, seed "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerate(t *testing.T) {
+	totp := &TOTP{Period: 30, Digits: 8, Skew: 1}
+
+	tests := []struct {
+		name     string
+		unix     int64
+		expected string
+	}{
+		{"Time 59", 59, "94287082"},
+		{"Time 1111111109", 1111111109, "07081804"},
+		{"Time 1111111111", 1111111111, "14050471"},
+		{"Time 1234567890", 1234567890, "89005924"},
+		{"Time 2000000000", 2000000000, "69279037"},
+		{"Time 20000000000", 20000000000, "65353130"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := totp.Generate(rfcSecret, time.Unix(tc.unix, 0))
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("Generate() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateInvalidSecret(t *testing.T) {
+	totp := New()
+
+	_, err := totp.Generate("not-base32!", time.Now())
+	if err != ErrInvalidSecret {
+		t.Errorf("Generate() error = %v, want %v", err, ErrInvalidSecret)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	totp := New()
+	now := time.Unix(1700000000, 0)
+
+	current, _ := totp.Generate(rfcSecret, now)
+	previous, _ := totp.Generate(rfcSecret, now.Add(-30*time.Second))
+	next, _ := totp.Generate(rfcSecret, now.Add(30*time.Second))
+	tooOld, _ := totp.Generate(rfcSecret, now.Add(-90*time.Second))
+
+	tests := []struct {
+		name      string
+		code      string
+		wantValid bool
+		wantStep  int64
+	}{
+		{"Current code", current, true, now.Unix() / 30},
+		{"Previous code within skew", previous, true, now.Unix()/30 - 1},
+		{"Next code within skew", next, true, now.Unix()/30 + 1},
+		{"Code outside of skew", tooOld, false, 0},
+		{"Wrong length", "12345", false, 0},
+		{"Empty code", "", false, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			step, valid, err := totp.Validate(rfcSecret, tc.code, now)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			if valid != tc.wantValid {
+				t.Errorf("Validate() valid = %v, want %v", valid, tc.wantValid)
+			}
+
+			if step != tc.wantStep {
+				t.Errorf("Validate() step = %v, want %v", step, tc.wantStep)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	totp := New()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+
+	key, err := totp.decodeSecret(secret)
+	if err != nil {
+		t.Fatalf("GenerateSecret() produced undecodable secret: %v", err)
+	}
+
+	if len(key) != 20 {
+		t.Errorf("GenerateSecret() key length = %d, want 20", len(key))
+	}
+}
+
+func TestURI(t *testing.T) {
+	totp := New()
+
+	uri := totp.URI(rfcSecret, "gofi", "user@localhost.test")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/gofi:user@localhost.test?") {
+		t.Errorf("URI() = %v, has unexpected prefix", uri)
+	}
+
+	for _, param := range []string{"secret=" + rfcSecret, "issuer=gofi", "digits=6", "period=30", "algorithm=SHA1"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("URI() = %v, should contain %v", uri, param)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	totp := New()
+
+	codes, err := totp.GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	if len(codes) != 10 {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want 10", len(codes))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("GenerateRecoveryCodes() code %v has incorrect format", code)
+		}
+
+		if seen[code] {
+			t.Errorf("GenerateRecoveryCodes() returned duplicate code %v", code)
+		}
+		seen[code] = true
+	}
+}
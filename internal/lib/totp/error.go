@@ -0,0 +1,7 @@
+package totp
+
+import "errors"
+
+var (
+	ErrInvalidSecret = errors.New("the totp secret is not valid base32")
+)
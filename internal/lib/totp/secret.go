@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random 160-bit secret encoded as base32, the size
+// recommended by RFC 4226 for HMAC-SHA1.
+func (t *TOTP) GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth URI understood by authenticator apps, usually
+// rendered as a QR code by the client.
+func (t *TOTP) URI(secret string, issuer string, account string) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, account))
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", t.Digits))
+	params.Set("period", fmt.Sprintf("%d", t.Period))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// GenerateRecoveryCodes returns n one-time codes formatted as xxxxx-xxxxx.
+func (t *TOTP) GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+
+	for range n {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes = append(codes, fmt.Sprintf("%s-%s", code[:5], code[5:]))
+	}
+
+	return codes, nil
+}
+
+func (t *TOTP) decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, ErrInvalidSecret
+	}
+
+	return key, nil
+}
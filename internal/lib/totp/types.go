@@ -0,0 +1,15 @@
+package totp
+
+type TOTP struct {
+	Period int64 // seconds
+	Digits int
+	Skew   int64 // number of periods accepted before and after the current one
+}
+
+func New() *TOTP {
+	return &TOTP{
+		Period: 30,
+		Digits: 6,
+		Skew:   1,
+	}
+}
@@ -0,0 +1,60 @@
+package lib
+
+import "strings"
+
+var userAgentBrowsers = []struct {
+	token string
+	name  string
+}{
+	// order matters, e.g. Edge and Opera user agents also contain Chrome
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+}
+
+var userAgentPlatforms = []struct {
+	token string
+	name  string
+}{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceFromUserAgent returns a short description of the device of a user
+// agent, e.g. "Chrome on macOS", to help users recognize their sessions.
+func DeviceFromUserAgent(userAgent string) string {
+	var browser, platform string
+
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, p := range userAgentPlatforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
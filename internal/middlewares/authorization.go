@@ -1,29 +1,61 @@
 package middlewares
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"gofi/internal/lib"
 	"gofi/internal/lib/jwt"
+	"gofi/internal/models"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 func (m Middlewares) Authorization() fiber.Handler {
+	return m.authorization(false, false)
+}
+
+// OptionalAuthorization lets anonymous requests through, a request with a
+// token is still rejected when the token is invalid.
+func (m Middlewares) OptionalAuthorization() fiber.Handler {
+	return m.authorization(true, false)
+}
+
+// SessionAuthorization guards the routes that manage credentials, they need
+// a signed in user so a leaked api key can't mint new keys or factors.
+func (m Middlewares) SessionAuthorization() fiber.Handler {
+	return m.authorization(false, true)
+}
+
+func (m Middlewares) authorization(optional, sessionOnly bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		jwt := jwt.New(&m.app.Config.App)
+		jwt := jwt.New(&m.app.Config.App, m.app.Keyring)
 
 		extractToken, err := jwt.ExtractToken(c)
 		if err != nil {
+			if optional && isTokenNotFound(err) {
+				return c.Next()
+			}
+
 			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 				"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
 			})
 		}
 
-		session, err := m.app.Repositories.Session.GetByToken(extractToken)
+		if isAPIKey(extractToken) {
+			if sessionOnly {
+				return c.Status(http.StatusForbidden).JSON(fiber.Map{
+					"message": "Forbidden, api keys can't manage credentials",
+				})
+			}
+
+			return m.authorizeAPIKey(c, extractToken)
+		}
+
+		session, err := m.app.Repositories.Session.GetByToken(c.UserContext(), extractToken)
 		if err != nil {
 			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 				"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
@@ -51,8 +83,99 @@ func (m Middlewares) Authorization() fiber.Handler {
 			}
 
 			lib.ContextSetUID(c, uuid.MustParse(claims.UID))
+			lib.ContextSetSessionID(c, session.ID)
+
+			// the membership is checked on every request so removed members
+			// lose access before their token expires
+			if claims.OID != "" {
+				organizationID, err := uuid.Parse(claims.OID)
+				if err != nil {
+					return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+						"message": "Unauthorized, invalid organization",
+					})
+				}
+
+				_, err = m.app.Repositories.OrganizationMember.Get(c.UserContext(), organizationID, session.UserID)
+				if err != nil {
+					return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+						"message": "Unauthorized, not a member of the organization",
+					})
+				}
+
+				lib.ContextSetOrganizationID(c, organizationID)
+			}
+
+			err = m.app.Repositories.Session.UpdateLastActivity(c.UserContext(), session.ID)
+			if err != nil {
+				m.app.Logger.Error("failed to update session last activity", "error", err.Error())
+			}
 		}
 
 		return c.Next()
 	}
 }
+
+// authorizeAPIKey authenticates a request made with a personal access token,
+// a key without the write scope can only read.
+func (m Middlewares) authorizeAPIKey(c *fiber.Ctx, key string) error {
+	apiKey, err := m.app.Repositories.APIKey.GetByKey(c.UserContext(), lib.HashToken(key))
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized, invalid api key",
+		})
+	}
+
+	if apiKey.IsExpired() {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized, api key has expired",
+		})
+	}
+
+	// the user must still be active and not blocked
+	user, err := m.app.Repositories.User.GetByID(c.UserContext(), apiKey.UserID)
+	if err != nil {
+		return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"message": fmt.Sprintf("Unauthorized, %s", err.Error()),
+		})
+	}
+
+	if !isReadOnlyMethod(c.Method()) && !apiKey.HasScope(models.APIKeyScopeWrite) {
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{
+			"message": "Forbidden, api key is read only",
+		})
+	}
+
+	// like sessions, the membership is checked on every request
+	if apiKey.OrganizationID != nil {
+		_, err = m.app.Repositories.OrganizationMember.Get(c.UserContext(), *apiKey.OrganizationID, user.ID)
+		if err != nil {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Unauthorized, not a member of the organization",
+			})
+		}
+
+		lib.ContextSetOrganizationID(c, *apiKey.OrganizationID)
+	}
+
+	err = m.app.Repositories.APIKey.UpdateLastUsed(c.UserContext(), apiKey.ID, c.IP())
+	if err != nil {
+		m.app.Logger.Error("failed to update api key last used", "error", err.Error())
+	}
+
+	lib.ContextSetUID(c, user.ID)
+
+	return c.Next()
+}
+
+// isAPIKey is used where the jwt package is shadowed by the JWT instance.
+func isAPIKey(token string) bool {
+	return jwt.IsAPIKey(token)
+}
+
+func isTokenNotFound(err error) bool {
+	return errors.Is(err, jwt.ErrTokenNotFound)
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions
+}
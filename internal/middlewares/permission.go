@@ -1,10 +1,15 @@
 package middlewares
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"gofi/internal/lib"
+	"gofi/internal/lib/constant"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -19,7 +24,7 @@ func (m Middlewares) PermissionAccess(roles []string) fiber.Handler {
 			})
 		}
 
-		user, err := m.app.Repositories.User.GetByID(uid)
+		user, err := m.app.Repositories.User.GetByID(c.UserContext(), uid)
 		if err != nil {
 			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
 				"message": fmt.Sprintf("Unauthorized, permission access failed: %s", err.Error()),
@@ -35,3 +40,60 @@ func (m Middlewares) PermissionAccess(roles []string) fiber.Handler {
 		return c.Next()
 	}
 }
+
+// organizationRoleIDs are the roles holding the permissions of each role
+// within an organization, an unknown role grants none.
+var organizationRoleIDs = map[string]uuid.UUID{
+	models.OrganizationRoleOwner:  uuid.MustParse(constant.RoleOrganizationOwner),
+	models.OrganizationRoleAdmin:  uuid.MustParse(constant.RoleOrganizationAdmin),
+	models.OrganizationRoleMember: uuid.MustParse(constant.RoleOrganizationMember),
+}
+
+// RequirePermission only lets the request through when the role of the user
+// is granted every one of the permissions. Inside an organization the role
+// is the one of the membership, the global role of the user doesn't count.
+func (m Middlewares) RequirePermission(permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, err := lib.ContextGetUID(c)
+		if err != nil {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		user, err := m.app.Repositories.User.GetByID(c.UserContext(), uid)
+		if err != nil {
+			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"message": fmt.Sprintf("Unauthorized, permission access failed: %s", err.Error()),
+			})
+		}
+
+		roleID := user.RoleID
+		if organizationID := lib.ContextGetOrganizationID(c); organizationID != uuid.Nil {
+			member, err := m.app.Repositories.OrganizationMember.Get(c.UserContext(), organizationID, uid)
+			if err != nil {
+				if errors.Is(err, repositories.ErrRecordNotFound) {
+					return c.Status(http.StatusForbidden).JSON(fiber.Map{
+						"message": "Forbidden, not a member of the organization",
+					})
+				}
+				return err
+			}
+
+			roleID = organizationRoleIDs[member.Role]
+		}
+
+		allowed, err := m.app.Repositories.Permission.RoleHasAll(c.UserContext(), roleID, permissions...)
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			return c.Status(http.StatusForbidden).JSON(fiber.Map{
+				"message": fmt.Sprintf("Forbidden, missing permission: %s", strings.Join(permissions, ", ")),
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	APIKeyScopeRead  = "read"
+	APIKeyScopeWrite = "write"
+)
+
+// APIKey is a personal access token used by scripts and CI jobs to call the
+// API on behalf of a user.
+type APIKey struct {
+	ID             uuid.UUID      `db:"id" json:"id"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+	UserID         uuid.UUID      `db:"user_id" json:"user_id"`
+	OrganizationID *uuid.UUID     `db:"organization_id" json:"organization_id,omitempty"` // requests are scoped to it
+	Name           string         `db:"name" json:"name"`
+	Prefix         string         `db:"prefix" json:"prefix"` // shown to tell keys apart
+	Key            string         `db:"key" json:"-"`         // sha256 hash
+	Scopes         pq.StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt      *time.Time     `db:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time     `db:"last_used_at" json:"last_used_at,omitempty"`
+	LastUsedIP     *string        `db:"last_used_ip" json:"last_used_ip,omitempty"`
+}
+
+func (entity *APIKey) IsExpired() bool {
+	return entity.ExpiresAt != nil && entity.ExpiresAt.Before(time.Now())
+}
+
+func (entity *APIKey) HasScope(scope string) bool {
+	return slices.Contains(entity.Scopes, scope)
+}
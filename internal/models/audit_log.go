@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditActionUserCreate     = "user.create"
+	AuditActionUserUpdate     = "user.update"
+	AuditActionUserDelete     = "user.delete"
+	AuditActionUserSoftDelete = "user.soft-delete"
+	AuditActionUserRestore    = "user.restore"
+	AuditActionUserUnlock     = "user.unlock"
+	AuditActionUserLock       = "user.lock"
+	AuditActionUserRemove     = "user.remove" // from the organization
+
+	AuditActionRoleCreate           = "role.create"
+	AuditActionRoleUpdate           = "role.update"
+	AuditActionRoleDelete           = "role.delete"
+	AuditActionRoleSoftDelete       = "role.soft-delete"
+	AuditActionRoleRestore          = "role.restore"
+	AuditActionRolePermissionAttach = "role.permission-attach"
+	AuditActionRolePermissionDetach = "role.permission-detach"
+)
+
+const (
+	AuditTargetUser = "user"
+	AuditTargetRole = "role"
+)
+
+// AuditLog records who did what to which row, rows are never updated nor
+// deleted. Before and After are JSON snapshots of the target, nil when it
+// didn't exist on that side of the action.
+type AuditLog struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	ActorID        *uuid.UUID      `db:"actor_id" json:"actor_id"`
+	OrganizationID *uuid.UUID      `db:"organization_id" json:"organization_id,omitempty"`
+	Action         string          `db:"action" json:"action"`
+	TargetType     string          `db:"target_type" json:"target_type"`
+	TargetID       *uuid.UUID      `db:"target_id" json:"target_id"`
+	Before         json.RawMessage `db:"before" json:"before,omitempty"`
+	After          json.RawMessage `db:"after" json:"after,omitempty"`
+	IPAddress      string          `db:"ip_address" json:"ip_address"`
+	UserAgent      string          `db:"user_agent" json:"user_agent"`
+	RequestID      *string         `db:"request_id" json:"request_id,omitempty"`
+}
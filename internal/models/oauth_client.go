@@ -0,0 +1,64 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// OAuthClient is an application allowed to sign users in through gofi as
+// its OpenID Connect provider.
+type OAuthClient struct {
+	Base
+	Name         string         `db:"name" json:"name"`
+	ClientID     string         `db:"client_id" json:"client_id"`
+	ClientSecret *string        `db:"client_secret" json:"-"` // argon2 hash
+	RedirectURIs pq.StringArray `db:"redirect_uris" json:"redirect_uris"`
+	Scopes       pq.StringArray `db:"scopes" json:"scopes"`
+}
+
+// IsPublic reports whether the client can't keep a secret (SPA, mobile app),
+// such clients are authenticated with PKCE only.
+func (entity *OAuthClient) IsPublic() bool {
+	return entity.ClientSecret == nil
+}
+
+// AllowsRedirectURI compares uri with the registered redirect URIs, the
+// match must be exact.
+func (entity *OAuthClient) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(entity.RedirectURIs, uri)
+}
+
+func (entity *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(entity.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// OAuthConsent records the scopes a user granted to a client.
+type OAuthConsent struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+	UserID    uuid.UUID      `db:"user_id" json:"user_id"`
+	ClientID  uuid.UUID      `db:"client_id" json:"client_id"`
+	Scopes    pq.StringArray `db:"scopes" json:"scopes"`
+	// Relation
+	Client *OAuthClient `json:"client,omitempty"`
+}
+
+func (entity *OAuthConsent) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(entity.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
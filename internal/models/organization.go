@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// Organization is a customer company, its data is only visible to its
+// members.
+type Organization struct {
+	Base
+	Name    string    `db:"name" json:"name"`
+	Slug    string    `db:"slug" json:"slug"`
+	OwnerID uuid.UUID `db:"owner_id" json:"owner_id"`
+}
+
+// OrganizationMember is the membership of a user in an organization, Role is
+// the role within that organization only.
+type OrganizationMember struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	UserID         uuid.UUID `db:"user_id" json:"user_id"`
+	Role           string    `db:"role" json:"role"`
+	// Relation
+	Organization *Organization `json:"organization,omitempty"`
+	User         *User         `json:"user,omitempty"`
+}
+
+// CanManage reports whether the member can invite and manage other members.
+func (entity *OrganizationMember) CanManage() bool {
+	return entity.Role == OrganizationRoleOwner || entity.Role == OrganizationRoleAdmin
+}
+
+type OrganizationInvitation struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	OrganizationID uuid.UUID  `db:"organization_id" json:"organization_id"`
+	InvitedBy      uuid.UUID  `db:"invited_by" json:"invited_by"`
+	Email          string     `db:"email" json:"email"`
+	Role           string     `db:"role" json:"role"`
+	Token          string     `db:"token" json:"-"` // hashed, see lib.HashToken
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	AcceptedAt     *time.Time `db:"accepted_at" json:"accepted_at,omitempty"`
+}
+
+func (entity *OrganizationInvitation) IsExpired() bool {
+	return entity.ExpiresAt.Before(time.Now())
+}
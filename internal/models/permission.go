@@ -0,0 +1,9 @@
+package models
+
+// Permission is a named action, e.g. "users:write", granted to roles
+// through role_permissions.
+type Permission struct {
+	Base
+	Name        string  `db:"name" json:"name"`
+	Description *string `db:"description" json:"description"`
+}
@@ -9,7 +9,9 @@ import (
 type RefreshToken struct {
 	ID        uuid.UUID  `db:"id" json:"id"`
 	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
-	Token     string     `db:"token" json:"token"`
+	FamilyID  uuid.UUID  `db:"family_id" json:"family_id"` // shared by every token rotated from the same sign in
+	SessionID *uuid.UUID `db:"session_id" json:"session_id,omitempty"`
+	Token     string     `db:"token" json:"-"` // hashed, see lib.HashToken
 	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
 	CreatedAt time.Time  `db:"created_at" json:"created_at"`
 	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at"`
@@ -13,4 +13,10 @@ type Session struct {
 	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
 	IPAddress string    `db:"ip_address" json:"ip_address"`
 	UserAgent string    `db:"user_agent" json:"user_agent"`
+	// OrganizationID is the active organization, carried in the access token
+	OrganizationID *uuid.UUID `db:"organization_id" json:"organization_id,omitempty"`
+	// LastActivityAt is refreshed by the Authorization middleware
+	LastActivityAt *time.Time `db:"last_activity_at" json:"last_activity_at,omitempty"`
+	Device         string     `json:"device,omitempty"`
+	Current        bool       `json:"current"`
 }
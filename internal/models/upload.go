@@ -1,13 +1,47 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// UploadStatusPending is an upload presigned for the client, the object
+	// may not exist yet.
+	UploadStatusPending = "pending"
+	UploadStatusReady   = "ready"
+)
 
 type Upload struct {
 	Base
-	KeyFile   string    `db:"key_file" json:"key_file"`
-	FileName  string    `db:"file_name" json:"file_name"`
-	MimeType  string    `db:"mimetype" json:"mimetype"`
-	Size      int64     `db:"size" json:"size"`
-	SignedURL string    `db:"signed_url" json:"signed_url"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	UserID    *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+	KeyFile   string     `db:"key_file" json:"key_file"`
+	FileName  string     `db:"file_name" json:"file_name"`
+	MimeType  string     `db:"mimetype" json:"mimetype"`
+	Size      int64      `db:"size" json:"size"`
+	SignedURL string     `db:"signed_url" json:"signed_url"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	Status    string     `db:"status" json:"status"`
+	// MultipartUploadID is the S3 multipart upload of a pending upload sent
+	// in parts.
+	MultipartUploadID *string `db:"multipart_upload_id" json:"-"`
+	// Variants are the signed urls of the resized copies by size, only
+	// avatars have some.
+	Variants map[string]string `json:"variants,omitempty"`
+}
+
+// IsOwnedBy reports whether the upload has been uploaded by the user.
+func (entity *Upload) IsOwnedBy(userID uuid.UUID) bool {
+	return entity.UserID != nil && *entity.UserID == userID
+}
+
+func (entity *Upload) IsReady() bool {
+	return entity.Status == UploadStatusReady
+}
+
+// SignedURLExpired reports whether the signed url lapses within margin, it
+// should be signed again before being handed out.
+func (entity *Upload) SignedURLExpired(margin time.Duration) bool {
+	return time.Now().Add(margin).After(entity.ExpiresAt)
 }
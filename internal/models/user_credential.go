@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// UserCredential is a WebAuthn credential (passkey) registered by a user.
+type UserCredential struct {
+	ID              uuid.UUID      `db:"id" json:"id"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time      `db:"updated_at" json:"updated_at"`
+	UserID          uuid.UUID      `db:"user_id" json:"user_id"`
+	Name            string         `db:"name" json:"name"`
+	CredentialID    []byte         `db:"credential_id" json:"-"`
+	PublicKey       []byte         `db:"public_key" json:"-"`
+	AttestationType string         `db:"attestation_type" json:"-"`
+	Transports      pq.StringArray `db:"transports" json:"transports"`
+	AAGUID          []byte         `db:"aaguid" json:"-"`
+	SignCount       int64          `db:"sign_count" json:"-"`
+	UserPresent     bool           `db:"user_present" json:"-"`
+	UserVerified    bool           `db:"user_verified" json:"-"`
+	BackupEligible  bool           `db:"backup_eligible" json:"backup_eligible"`
+	BackupState     bool           `db:"backup_state" json:"backup_state"`
+	LastUsedAt      *time.Time     `db:"last_used_at" json:"last_used_at,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserMFA struct {
+	Base
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	Secret       string     `db:"secret" json:"-"` // encrypted, see lib.Encryptor
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	ConfirmedAt  *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
+}
+
+func (entity *UserMFA) Enabled() bool {
+	return entity.ConfirmedAt != nil
+}
+
+type UserRecoveryCode struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	Code      string     `db:"code" json:"-"` // argon2 hash
+	UsedAt    *time.Time `db:"used_at" json:"used_at,omitempty"`
+}
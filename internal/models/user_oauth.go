@@ -11,7 +11,7 @@ type UserOAuth struct {
 	UserID       uuid.UUID `db:"user_id" json:"user_id"`
 	IdentityID   string    `db:"identity_id" json:"identity_id"`
 	Provider     string    `db:"provider" json:"provider"`
-	AccessToken  string    `db:"access_token" json:"access_token"`
-	RefreshToken *string   `db:"refresh_token" json:"refresh_token,omitempty"`
+	AccessToken  string    `db:"access_token" json:"-"`
+	RefreshToken *string   `db:"refresh_token" json:"-"`
 	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
 }
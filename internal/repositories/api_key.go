@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type APIKeyRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+const apiKeyColumns = `"id", "created_at", "updated_at", "user_id", "organization_id", "name", "prefix", "key", "scopes", "expires_at", "last_used_at", "last_used_ip"`
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }, apiKey *models.APIKey) error {
+	return row.Scan(
+		&apiKey.ID,
+		&apiKey.CreatedAt,
+		&apiKey.UpdatedAt,
+		&apiKey.UserID,
+		&apiKey.OrganizationID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		&apiKey.Key,
+		&apiKey.Scopes,
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+		&apiKey.LastUsedIP,
+	)
+}
+
+func (r APIKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	return r.listByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r APIKeyRepository) listByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.APIKey, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM "api_keys"
+		WHERE "user_id" = $1
+		ORDER BY "created_at" DESC;
+	`, apiKeyColumns)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	apiKeys := []*models.APIKey{}
+	for rows.Next() {
+		apiKey := &models.APIKey{}
+		if err := scanAPIKey(rows, apiKey); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, nil
+}
+
+// GetByKey looks an API key up by the hash of the key.
+func (r APIKeyRepository) GetByKey(ctx context.Context, key string) (*models.APIKey, error) {
+	return r.getByKeyExec(ctx, r.DB, key)
+}
+
+func (r APIKeyRepository) getByKeyExec(ctx context.Context, exc Executor, key string) (*models.APIKey, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM "api_keys"
+		WHERE "key" = $1;
+	`, apiKeyColumns)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	apiKey := &models.APIKey{}
+	err := scanAPIKey(exc.QueryRowContext(ctx, query, key), apiKey)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return apiKey, nil
+}
+
+func (r APIKeyRepository) Insert(ctx context.Context, apiKeys ...*models.APIKey) error {
+	return r.insertExec(ctx, r.DB, apiKeys...)
+}
+
+func (r APIKeyRepository) insertExec(ctx context.Context, exc Executor, apiKeys ...*models.APIKey) error {
+	if len(apiKeys) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "user_id", "organization_id", "name", "prefix", "key", "scopes", "expires_at"}
+
+	valueStrings := make([]string, 0, len(apiKeys))
+	valueArgs := make([]any, 0, len(apiKeys)*len(columns))
+
+	for i, apiKey := range apiKeys {
+		values := []any{apiKey.ID, apiKey.UserID, apiKey.OrganizationID, apiKey.Name, apiKey.Prefix, apiKey.Key, apiKey.Scopes, apiKey.ExpiresAt}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "api_keys" (%s)
+		VALUES %s
+		RETURNING "id", "created_at", "updated_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, apiKey := range apiKeys {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&apiKey.ID, &apiKey.CreatedAt, &apiKey.UpdatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateLastUsed records when and from where the API key was last used.
+func (r APIKeyRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID, ip string) error {
+	return r.updateLastUsedExec(ctx, r.DB, id, ip)
+}
+
+func (r APIKeyRepository) updateLastUsedExec(ctx context.Context, exc Executor, id uuid.UUID, ip string) error {
+	query := `
+		UPDATE "api_keys"
+		SET "last_used_at" = now(), "last_used_ip" = $1
+		WHERE "id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, ip, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r APIKeyRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return r.deleteExec(ctx, r.DB, userID, id)
+}
+
+func (r APIKeyRepository) deleteExec(ctx context.Context, exc Executor, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+		DELETE FROM "api_keys"
+		WHERE "user_id" = $1 AND "id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
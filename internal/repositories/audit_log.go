@@ -0,0 +1,266 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type AuditLogRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+// AuditLogFilter narrows the listed logs, zero values are ignored.
+type AuditLogFilter struct {
+	ActorID    uuid.UUID
+	TargetType string
+	TargetID   uuid.UUID
+	Action     string
+	From       *time.Time
+	To         *time.Time
+}
+
+// where builds the WHERE clause of the filter, placeholders start at $1.
+func (f AuditLogFilter) where() (string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.ActorID != uuid.Nil {
+		add(`"actor_id" = $%d`, f.ActorID)
+	}
+
+	if f.TargetType != "" {
+		add(`"target_type" = $%d`, f.TargetType)
+	}
+
+	if f.TargetID != uuid.Nil {
+		add(`"target_id" = $%d`, f.TargetID)
+	}
+
+	if f.Action != "" {
+		add(`"action" = $%d`, f.Action)
+	}
+
+	if f.From != nil {
+		add(`"created_at" >= $%d`, *f.From)
+	}
+
+	if f.To != nil {
+		add(`"created_at" < $%d`, *f.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r AuditLogRepository) List(ctx context.Context, filter AuditLogFilter, opts *QueryOptions) ([]*models.AuditLog, PaginationMetadata, error) {
+	return r.listExec(ctx, r.DB, filter, opts)
+}
+
+func (r AuditLogRepository) listExec(ctx context.Context, exc Executor, filter AuditLogFilter, opts *QueryOptions) ([]*models.AuditLog, PaginationMetadata, error) {
+	if opts == nil {
+		opts = &QueryOptions{}
+	}
+
+	selectFields := `"id", "created_at", "actor_id", "organization_id", "action", "target_type", "target_id", "before", "after", "ip_address", "user_agent", "request_id"`
+	baseQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM "audit_logs"
+	`, selectFields)
+
+	where, args := filter.where()
+	argIndex := len(args) + 1
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(baseQuery)
+	queryBuilder.WriteString(where)
+
+	// ids are uuidv7, ordering by them keeps logs of the same instant stable
+	queryBuilder.WriteString(` ORDER BY "id" DESC`)
+
+	if opts.Limit > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
+		args = append(args, opts.Limit)
+		argIndex++
+	}
+
+	if opts.Offset > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" OFFSET $%d", argIndex))
+		args = append(args, opts.Offset)
+		argIndex++
+	}
+
+	query := queryBuilder.String()
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*models.AuditLog
+	for rows.Next() {
+		log := &models.AuditLog{}
+		if err := rows.Scan(
+			&log.ID,
+			&log.CreatedAt,
+			&log.ActorID,
+			&log.OrganizationID,
+			&log.Action,
+			&log.TargetType,
+			&log.TargetID,
+			&log.Before,
+			&log.After,
+			&log.IPAddress,
+			&log.UserAgent,
+			&log.RequestID,
+		); err != nil {
+			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
+		}
+		logs = append(logs, log)
+	}
+
+	count, err := r.countExec(ctx, exc, filter)
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Errorf("error counting rows: %w", err)
+	}
+
+	return logs, PaginationMetadata{
+		Total: count,
+	}, nil
+}
+
+func (r AuditLogRepository) countExec(ctx context.Context, exc Executor, filter AuditLogFilter) (int64, error) {
+	where, args := filter.where()
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "audit_logs"%s;
+	`, where)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	var count int64
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r AuditLogRepository) Insert(ctx context.Context, logs ...*models.AuditLog) error {
+	return r.InsertExec(ctx, r.DB, logs...)
+}
+
+// InsertExec is meant to run in the transaction of the audited change, so
+// the log is only written when the change is.
+func (r AuditLogRepository) InsertExec(ctx context.Context, exc Executor, logs ...*models.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "actor_id", "organization_id", "action", "target_type", "target_id", "before", "after", "ip_address", "user_agent", "request_id"}
+
+	valueStrings := make([]string, 0, len(logs))
+	valueArgs := make([]any, 0, len(logs)*len(columns))
+
+	for i, log := range logs {
+		values := []any{
+			log.ID,
+			log.ActorID,
+			log.OrganizationID,
+			log.Action,
+			log.TargetType,
+			log.TargetID,
+			nullJSON(log.Before),
+			nullJSON(log.After),
+			log.IPAddress,
+			log.UserAgent,
+			log.RequestID,
+		}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "audit_logs" (%s)
+		VALUES %s
+		RETURNING "created_at";
+	`, strings.Join(columns, ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, log := range logs {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&log.CreatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// nullJSON binds a JSON document as text, pq would send []byte as bytea.
+func nullJSON(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+
+	return string(raw)
+}
@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"fmt"
 	"gofi/internal/config"
-	"time"
 
 	"braces.dev/errtrace"
 	"github.com/google/uuid"
@@ -15,21 +14,21 @@ import (
 type BaseRepository struct {
 	DB        *sql.DB
 	TableName string
-	Config    *config.ConfigApp
+	Config    *config.Config
 }
 
-func (r BaseRepository) countExec(exc Executor) (int64, error) {
+func (r BaseRepository) countExec(ctx context.Context, exc Executor) (int64, error) {
 	query := fmt.Sprintf(`
 		SELECT COUNT(*) 
 		FROM "%s";
 	`, r.TableName)
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	row := exc.QueryRowContext(ctx, query)
@@ -45,7 +44,7 @@ func (r BaseRepository) countExec(exc Executor) (int64, error) {
 	return count, nil
 }
 
-func (r BaseRepository) deleteExec(exc Executor, id uuid.UUID) error {
+func (r BaseRepository) deleteExec(ctx context.Context, exc Executor, id uuid.UUID) error {
 	query := fmt.Sprintf(`
 		DELETE FROM "%s" 
 		WHERE "id" = $1;
@@ -53,12 +52,12 @@ func (r BaseRepository) deleteExec(exc Executor, id uuid.UUID) error {
 
 	args := []any{id}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	result, err := exc.ExecContext(ctx, query, args...)
 	if err != nil {
-		return errtrace.Wrap(err)
+		return errtrace.Wrap(translateDeleteError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -73,7 +72,7 @@ func (r BaseRepository) deleteExec(exc Executor, id uuid.UUID) error {
 	return nil
 }
 
-func (r BaseRepository) softDeleteExec(exc Executor, id uuid.UUID) error {
+func (r BaseRepository) softDeleteExec(ctx context.Context, exc Executor, id uuid.UUID) error {
 	query := fmt.Sprintf(`
 		UPDATE "%s" 
 		SET "deleted_at" = now() 
@@ -82,7 +81,7 @@ func (r BaseRepository) softDeleteExec(exc Executor, id uuid.UUID) error {
 
 	args := []any{id}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	result, err := exc.ExecContext(ctx, query, args...)
@@ -102,7 +101,7 @@ func (r BaseRepository) softDeleteExec(exc Executor, id uuid.UUID) error {
 	return nil
 }
 
-func (r BaseRepository) restoreExec(exc Executor, id uuid.UUID) error {
+func (r BaseRepository) restoreExec(ctx context.Context, exc Executor, id uuid.UUID) error {
 	query := fmt.Sprintf(`
 		UPDATE "%s" 
 		SET "deleted_at" = NULL 
@@ -111,7 +110,7 @@ func (r BaseRepository) restoreExec(exc Executor, id uuid.UUID) error {
 
 	args := []any{id}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	result, err := exc.ExecContext(ctx, query, args...)
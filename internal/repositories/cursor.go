@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"gofi/internal/lib/filter"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is the position of a row in a keyset paginated list, the value of
+// the sort column and the id breaking its ties. It is handed to clients
+// base64 encoded and is opaque to them.
+type Cursor struct {
+	Column string    `json:"c"`
+	Value  string    `json:"v"`
+	ID     uuid.UUID `json:"i"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// keyset pages through a list sorted by a single column and the id, rows
+// are found with a row comparison against the cursor instead of an OFFSET.
+type keyset struct {
+	sort     filter.Sort
+	idColumn string
+	// cursor is nil on the first page.
+	cursor *Cursor
+	// backward is set for `before` cursors, the rows are read in the reverse
+	// order and flipped back.
+	backward bool
+}
+
+// newKeyset returns nil when the list can't be cursor paginated, it is only
+// an error when opts asks for a cursor. fallback is the default sort of the
+// list.
+func newKeyset(opts *QueryOptions, idColumn string, fallback filter.Sort) (*keyset, error) {
+	paginated := opts.After != "" || opts.Before != ""
+
+	k := &keyset{sort: fallback, idColumn: idColumn}
+
+	switch len(opts.Filter.Sorts) {
+	case 0:
+	case 1:
+		k.sort = opts.Filter.Sorts[0]
+	default:
+		if !paginated {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: only one sort field is supported", ErrInvalidCursor)
+	}
+
+	if k.sort.Nullable {
+		if !paginated {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: the sort field can be null", ErrInvalidCursor)
+	}
+
+	if !paginated {
+		return k, nil
+	}
+
+	if opts.After != "" && opts.Before != "" {
+		return nil, fmt.Errorf("%w: after and before can't be used together", ErrInvalidCursor)
+	}
+
+	raw := opts.After
+	if opts.Before != "" {
+		raw = opts.Before
+		k.backward = true
+	}
+
+	cursor, err := DecodeCursor(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor.Column != k.sort.Column {
+		return nil, fmt.Errorf("%w: the cursor is of another sort", ErrInvalidCursor)
+	}
+
+	k.cursor = cursor
+
+	return k, nil
+}
+
+func (k keyset) desc() bool {
+	return k.sort.Desc != k.backward
+}
+
+// where compiles the row comparison against the cursor, placeholders start
+// at $argIndex.
+func (k keyset) where(argIndex int) (string, []any) {
+	operator := ">"
+	if k.desc() {
+		operator = "<"
+	}
+
+	condition := fmt.Sprintf("(%s, %s) %s ($%d, $%d)", k.sort.Column, k.idColumn, operator, argIndex, argIndex+1)
+
+	return condition, []any{k.cursor.Value, k.cursor.ID}
+}
+
+func (k keyset) orderBy() string {
+	order := "ASC"
+	if k.desc() {
+		order = "DESC"
+	}
+
+	return fmt.Sprintf("%s %s, %s %s", k.sort.Column, order, k.idColumn, order)
+}
+
+// selectKey is the sort column as text, selected with the rows to encode
+// their cursors.
+func (k keyset) selectKey() string {
+	return k.sort.Column + "::text"
+}
+
+// page trims the extra row read to know whether there is a next page, puts
+// backward pages back in order and returns the cursors around the rows.
+// ids and keys are the id and selectKey of each row.
+func page[T any](k keyset, opts *QueryOptions, rows []T, ids []uuid.UUID, keys []string) ([]T, PaginationMetadata) {
+	more := opts.Limit > 0 && int64(len(rows)) > opts.Limit
+	if more {
+		rows, ids, keys = rows[:opts.Limit], ids[:opts.Limit], keys[:opts.Limit]
+	}
+
+	if k.backward {
+		slices.Reverse(rows)
+		slices.Reverse(ids)
+		slices.Reverse(keys)
+	}
+
+	var meta PaginationMetadata
+	if len(rows) == 0 {
+		return rows, meta
+	}
+
+	first := Cursor{Column: k.sort.Column, Value: keys[0], ID: ids[0]}.Encode()
+	last := Cursor{Column: k.sort.Column, Value: keys[len(keys)-1], ID: ids[len(ids)-1]}.Encode()
+
+	// Coming from a cursor or an offset means there are rows before this
+	// page, or after it when going backward.
+	skipped := k.cursor != nil || opts.Offset > 0
+
+	if k.backward {
+		meta.NextCursor = last
+		if more {
+			meta.PrevCursor = first
+		}
+	} else {
+		if skipped {
+			meta.PrevCursor = first
+		}
+		if more {
+			meta.NextCursor = last
+		}
+	}
+
+	return rows, meta
+}
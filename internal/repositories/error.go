@@ -1,9 +1,104 @@
 package repositories
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/lib/pq"
+)
 
 var (
 	ErrInsertDuplicate = errors.New("insert duplicate")
 	ErrEditConflict    = errors.New("edit conflict")
 	ErrRecordNotFound  = errors.New("record not found")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrNotNullViolation    = errors.New("not null violation")
+	ErrCheckViolation      = errors.New("check violation")
 )
+
+// constraintErrors are the integrity constraint violations of PostgreSQL, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+var constraintErrors = map[pq.ErrorCode]error{
+	"23502": ErrNotNullViolation,
+	"23503": ErrForeignKeyViolation,
+	"23505": ErrInsertDuplicate,
+	"23514": ErrCheckViolation,
+}
+
+// ConstraintError is a violated constraint of the database, errors.Is matches
+// it against its Kind.
+type ConstraintError struct {
+	// Kind is one of ErrInsertDuplicate, ErrForeignKeyViolation,
+	// ErrNotNullViolation and ErrCheckViolation.
+	Kind       error
+	Table      string
+	Constraint string
+	// Column is empty when the database doesn't tell, e.g. for check
+	// constraints. Multi-column keys are comma separated.
+	Column string
+	// Referenced is set when a deleted row is still referenced by other rows,
+	// a foreign key violation otherwise means the referenced row is missing.
+	Referenced bool
+	Err        *pq.Error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err.Message)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// keyDetail reads the columns of unique and foreign key violations, their
+// detail is e.g. `Key (email)=(doe@example.com) already exists.`.
+var keyDetail = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// translateError turns the constraint violations of PostgreSQL into a
+// *ConstraintError, other errors are returned as is.
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	kind, ok := constraintErrors[pqErr.Code]
+	if !ok {
+		return err
+	}
+
+	column := pqErr.Column
+	if column == "" {
+		if matches := keyDetail.FindStringSubmatch(pqErr.Detail); matches != nil {
+			column = matches[1]
+		}
+	}
+
+	return &ConstraintError{
+		Kind:       kind,
+		Table:      pqErr.Table,
+		Constraint: pqErr.Constraint,
+		Column:     column,
+		Err:        pqErr,
+	}
+}
+
+// translateDeleteError is translateError for deletes, their foreign key
+// violations come from the rows still referencing the deleted one.
+func translateDeleteError(err error) error {
+	err = translateError(err)
+
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) && constraintErr.Kind == ErrForeignKeyViolation {
+		constraintErr.Referenced = true
+	}
+
+	return err
+}
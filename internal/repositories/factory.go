@@ -7,21 +7,49 @@ import (
 )
 
 type Repositories struct {
-	Role              RoleRepository
-	User              UserRepository
-	UserVerifyAccount UserVerifyAccountRepository
-	Session           SessionRepository
-	RefreshToken      RefreshTokenRepository
-	UserOAuth         UserOAuthRepository
+	Role                   RoleRepository
+	User                   UserRepository
+	UserVerifyAccount      UserVerifyAccountRepository
+	Session                SessionRepository
+	RefreshToken           RefreshTokenRepository
+	UserOAuth              UserOAuthRepository
+	PasswordReset          PasswordResetRepository
+	UserMFA                UserMFARepository
+	UserRecoveryCode       UserRecoveryCodeRepository
+	UserCredential         UserCredentialRepository
+	OAuthClient            OAuthClientRepository
+	OAuthConsent           OAuthConsentRepository
+	APIKey                 APIKeyRepository
+	Permission             PermissionRepository
+	RolePermission         RolePermissionRepository
+	Organization           OrganizationRepository
+	OrganizationMember     OrganizationMemberRepository
+	OrganizationInvitation OrganizationInvitationRepository
+	AuditLog               AuditLogRepository
+	Upload                 UploadRepository
 }
 
-func New(db *sql.DB, config *config.ConfigApp) Repositories {
+func New(db *sql.DB, config *config.Config) Repositories {
 	return Repositories{
-		Role:              RoleRepository{BaseRepository: BaseRepository{DB: db, TableName: "roles", Config: config}},
-		User:              UserRepository{BaseRepository: BaseRepository{DB: db, TableName: "users", Config: config}},
-		UserVerifyAccount: UserVerifyAccountRepository{DB: db, Config: config},
-		Session:           SessionRepository{DB: db, Config: config},
-		RefreshToken:      RefreshTokenRepository{DB: db, Config: config},
-		UserOAuth:         UserOAuthRepository{DB: db, Config: config},
+		Role:                   NewRoleRepository(db, config),
+		User:                   NewUserRepository(db, config),
+		UserVerifyAccount:      UserVerifyAccountRepository{DB: db, Config: config},
+		Session:                NewSessionRepository(db, config),
+		RefreshToken:           NewRefreshTokenRepository(db, config),
+		UserOAuth:              UserOAuthRepository{DB: db, Config: config},
+		PasswordReset:          PasswordResetRepository{DB: db, Config: config},
+		UserMFA:                UserMFARepository{DB: db, Config: config},
+		UserRecoveryCode:       UserRecoveryCodeRepository{DB: db, Config: config},
+		UserCredential:         UserCredentialRepository{DB: db, Config: config},
+		OAuthClient:            NewOAuthClientRepository(db, config),
+		OAuthConsent:           OAuthConsentRepository{DB: db, Config: config},
+		APIKey:                 APIKeyRepository{DB: db, Config: config},
+		Permission:             NewPermissionRepository(db, config),
+		RolePermission:         RolePermissionRepository{DB: db, Config: config},
+		Organization:           NewOrganizationRepository(db, config),
+		OrganizationMember:     OrganizationMemberRepository{DB: db, Config: config},
+		OrganizationInvitation: OrganizationInvitationRepository{DB: db, Config: config},
+		AuditLog:               AuditLogRepository{DB: db, Config: config},
+		Upload:                 NewUploadRepository(db, config),
 	}
 }
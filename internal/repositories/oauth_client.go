@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+var oauthClientSchema = Schema[models.OAuthClient]{
+	Table: "oauth_clients",
+	Columns: append(
+		BaseColumns(func(client *models.OAuthClient) *models.Base { return &client.Base }),
+		Column[models.OAuthClient]{Name: "name", Field: func(client *models.OAuthClient) any { return &client.Name }},
+		Column[models.OAuthClient]{Name: "client_id", Field: func(client *models.OAuthClient) any { return &client.ClientID }, InsertOnly: true},
+		Column[models.OAuthClient]{Name: "client_secret", Field: func(client *models.OAuthClient) any { return &client.ClientSecret }, InsertOnly: true},
+		Column[models.OAuthClient]{Name: "redirect_uris", Field: func(client *models.OAuthClient) any { return &client.RedirectURIs }},
+		Column[models.OAuthClient]{Name: "scopes", Field: func(client *models.OAuthClient) any { return &client.Scopes }},
+	),
+	SoftDelete: true,
+	OrderBy:    "created_at",
+	Order:      "DESC",
+}
+
+type OAuthClientRepository struct {
+	Repository[models.OAuthClient]
+}
+
+func NewOAuthClientRepository(db *sql.DB, config *config.Config) OAuthClientRepository {
+	return OAuthClientRepository{Repository: NewRepository(db, config, oauthClientSchema)}
+}
+
+func (r OAuthClientRepository) GetByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	return r.getByClientIDExec(ctx, r.DB, clientID)
+}
+
+func (r OAuthClientRepository) getByClientIDExec(ctx context.Context, exc Executor, clientID string) (*models.OAuthClient, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "name", "client_id", "client_secret", "redirect_uris", "scopes"
+		FROM "oauth_clients"
+		WHERE "client_id" = $1 AND "deleted_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	client := &models.OAuthClient{}
+	err := exc.QueryRowContext(ctx, query, clientID).Scan(
+		&client.ID,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+		&client.Name,
+		&client.ClientID,
+		&client.ClientSecret,
+		&client.RedirectURIs,
+		&client.Scopes,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type OAuthConsentRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+func (r OAuthConsentRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OAuthConsent, error) {
+	return r.listByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r OAuthConsentRepository) listByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.OAuthConsent, error) {
+	query := `
+		SELECT oc."id", oc."created_at", oc."updated_at", oc."user_id", oc."client_id", oc."scopes",
+			c."id", c."name", c."client_id"
+		FROM "oauth_consents" oc
+		INNER JOIN "oauth_clients" c ON c."id" = oc."client_id"
+		WHERE oc."user_id" = $1 AND c."deleted_at" IS NULL
+		ORDER BY oc."updated_at" DESC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	consents := []*models.OAuthConsent{}
+	for rows.Next() {
+		consent := &models.OAuthConsent{Client: &models.OAuthClient{}}
+		if err := rows.Scan(
+			&consent.ID,
+			&consent.CreatedAt,
+			&consent.UpdatedAt,
+			&consent.UserID,
+			&consent.ClientID,
+			&consent.Scopes,
+			&consent.Client.ID,
+			&consent.Client.Name,
+			&consent.Client.ClientID,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		consents = append(consents, consent)
+	}
+
+	return consents, nil
+}
+
+func (r OAuthConsentRepository) GetByUserClient(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) (*models.OAuthConsent, error) {
+	return r.getByUserClientExec(ctx, r.DB, userID, clientID)
+}
+
+func (r OAuthConsentRepository) getByUserClientExec(ctx context.Context, exc Executor, userID uuid.UUID, clientID uuid.UUID) (*models.OAuthConsent, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "user_id", "client_id", "scopes"
+		FROM "oauth_consents"
+		WHERE "user_id" = $1 AND "client_id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	consent := &models.OAuthConsent{}
+	err := exc.QueryRowContext(ctx, query, userID, clientID).Scan(
+		&consent.ID,
+		&consent.CreatedAt,
+		&consent.UpdatedAt,
+		&consent.UserID,
+		&consent.ClientID,
+		&consent.Scopes,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return consent, nil
+}
+
+func (r OAuthConsentRepository) Upsert(ctx context.Context, consent *models.OAuthConsent) error {
+	return r.upsertExec(ctx, r.DB, consent)
+}
+
+// upsertExec records the consent, granting a client again replaces the
+// scopes granted before.
+func (r OAuthConsentRepository) upsertExec(ctx context.Context, exc Executor, consent *models.OAuthConsent) error {
+	query := `
+		INSERT INTO "oauth_consents" ("id", "user_id", "client_id", "scopes")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("user_id", "client_id")
+		DO UPDATE SET "scopes" = EXCLUDED."scopes", "updated_at" = now()
+		RETURNING "id", "created_at", "updated_at";
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	args := []any{
+		consent.ID,
+		consent.UserID,
+		consent.ClientID,
+		consent.Scopes,
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&consent.ID, &consent.CreatedAt, &consent.UpdatedAt)
+	if err != nil {
+		return errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return nil
+}
+
+func (r OAuthConsentRepository) Delete(ctx context.Context, userID uuid.UUID, clientID uuid.UUID) error {
+	return r.deleteExec(ctx, r.DB, userID, clientID)
+}
+
+func (r OAuthConsentRepository) deleteExec(ctx context.Context, exc Executor, userID uuid.UUID, clientID uuid.UUID) error {
+	query := `
+		DELETE FROM "oauth_consents"
+		WHERE "user_id" = $1 AND "client_id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, clientID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
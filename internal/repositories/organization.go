@@ -0,0 +1,415 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+var organizationSchema = Schema[models.Organization]{
+	Table: "organizations",
+	Columns: append(
+		BaseColumns(func(organization *models.Organization) *models.Base { return &organization.Base }),
+		Column[models.Organization]{Name: "name", Field: func(organization *models.Organization) any { return &organization.Name }},
+		Column[models.Organization]{Name: "slug", Field: func(organization *models.Organization) any { return &organization.Slug }},
+		Column[models.Organization]{Name: "owner_id", Field: func(organization *models.Organization) any { return &organization.OwnerID }},
+	),
+	SoftDelete: true,
+	OrderBy:    "created_at",
+	Order:      "DESC",
+}
+
+type OrganizationRepository struct {
+	Repository[models.Organization]
+}
+
+func NewOrganizationRepository(db *sql.DB, config *config.Config) OrganizationRepository {
+	return OrganizationRepository{Repository: NewRepository(db, config, organizationSchema)}
+}
+
+type OrganizationMemberRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+// ListByOrganizationID returns the members of the organization with their
+// user, the owner first.
+func (r OrganizationMemberRepository) ListByOrganizationID(ctx context.Context, organizationID uuid.UUID) ([]*models.OrganizationMember, error) {
+	return r.listByOrganizationIDExec(ctx, r.DB, organizationID)
+}
+
+func (r OrganizationMemberRepository) listByOrganizationIDExec(ctx context.Context, exc Executor, organizationID uuid.UUID) ([]*models.OrganizationMember, error) {
+	query := `
+		SELECT "om"."id", "om"."created_at", "om"."updated_at", "om"."organization_id", "om"."user_id", "om"."role",
+			"u"."id", "u"."first_name", "u"."last_name", "u"."email"
+		FROM "organization_members" "om"
+		INNER JOIN "users" "u" ON "u"."id" = "om"."user_id"
+		WHERE "om"."organization_id" = $1 AND "u"."deleted_at" IS NULL
+		ORDER BY "om"."role" = 'owner' DESC, "om"."created_at" ASC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, organizationID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*models.OrganizationMember{}
+	for rows.Next() {
+		member := &models.OrganizationMember{User: &models.User{}}
+		if err := rows.Scan(
+			&member.ID,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Role,
+			&member.User.ID,
+			&member.User.FirstName,
+			&member.User.LastName,
+			&member.User.Email,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// ListByUserID returns the memberships of the user with their organization,
+// the oldest first.
+func (r OrganizationMemberRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.OrganizationMember, error) {
+	return r.listByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r OrganizationMemberRepository) listByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.OrganizationMember, error) {
+	query := `
+		SELECT "om"."id", "om"."created_at", "om"."updated_at", "om"."organization_id", "om"."user_id", "om"."role",
+			"o"."id", "o"."created_at", "o"."updated_at", "o"."name", "o"."slug", "o"."owner_id"
+		FROM "organization_members" "om"
+		INNER JOIN "organizations" "o" ON "o"."id" = "om"."organization_id"
+		WHERE "om"."user_id" = $1 AND "o"."deleted_at" IS NULL
+		ORDER BY "om"."created_at" ASC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	members := []*models.OrganizationMember{}
+	for rows.Next() {
+		member := &models.OrganizationMember{Organization: &models.Organization{}}
+		if err := rows.Scan(
+			&member.ID,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Role,
+			&member.Organization.ID,
+			&member.Organization.CreatedAt,
+			&member.Organization.UpdatedAt,
+			&member.Organization.Name,
+			&member.Organization.Slug,
+			&member.Organization.OwnerID,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+func (r OrganizationMemberRepository) Get(ctx context.Context, organizationID uuid.UUID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	return r.GetExec(ctx, r.DB, organizationID, userID)
+}
+
+func (r OrganizationMemberRepository) GetExec(ctx context.Context, exc Executor, organizationID uuid.UUID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	query := `
+		SELECT "om"."id", "om"."created_at", "om"."updated_at", "om"."organization_id", "om"."user_id", "om"."role"
+		FROM "organization_members" "om"
+		INNER JOIN "organizations" "o" ON "o"."id" = "om"."organization_id"
+		WHERE "om"."organization_id" = $1 AND "om"."user_id" = $2 AND "o"."deleted_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	member := &models.OrganizationMember{}
+	err := exc.QueryRowContext(ctx, query, organizationID, userID).Scan(
+		&member.ID,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return member, nil
+}
+
+func (r OrganizationMemberRepository) Insert(ctx context.Context, member *models.OrganizationMember) error {
+	return r.InsertExec(ctx, r.DB, member)
+}
+
+func (r OrganizationMemberRepository) InsertExec(ctx context.Context, exc Executor, member *models.OrganizationMember) error {
+	query := `
+		INSERT INTO "organization_members" ("id", "organization_id", "user_id", "role")
+		VALUES ($1, $2, $3, $4)
+		RETURNING "id", "created_at", "updated_at";
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	args := []any{
+		member.ID,
+		member.OrganizationID,
+		member.UserID,
+		member.Role,
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&member.ID, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+
+	return nil
+}
+
+func (r OrganizationMemberRepository) UpdateRole(ctx context.Context, organizationID uuid.UUID, userID uuid.UUID, role string) error {
+	return r.UpdateRoleExec(ctx, r.DB, organizationID, userID, role)
+}
+
+func (r OrganizationMemberRepository) UpdateRoleExec(ctx context.Context, exc Executor, organizationID uuid.UUID, userID uuid.UUID, role string) error {
+	query := `
+		UPDATE "organization_members"
+		SET "role" = $1, "updated_at" = now()
+		WHERE "organization_id" = $2 AND "user_id" = $3;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, role, organizationID, userID)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r OrganizationMemberRepository) Delete(ctx context.Context, organizationID uuid.UUID, userID uuid.UUID) error {
+	return r.DeleteExec(ctx, r.DB, organizationID, userID)
+}
+
+func (r OrganizationMemberRepository) DeleteExec(ctx context.Context, exc Executor, organizationID uuid.UUID, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "organization_members"
+		WHERE "organization_id" = $1 AND "user_id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, organizationID, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+type OrganizationInvitationRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+func (r OrganizationInvitationRepository) GetByToken(ctx context.Context, token string) (*models.OrganizationInvitation, error) {
+	return r.GetByTokenExec(ctx, r.DB, token)
+}
+
+// GetByTokenExec finds a pending invitation by its hashed token.
+func (r OrganizationInvitationRepository) GetByTokenExec(ctx context.Context, exc Executor, token string) (*models.OrganizationInvitation, error) {
+	query := `
+		SELECT "id", "created_at", "organization_id", "invited_by", "email", "role", "token", "expires_at", "accepted_at"
+		FROM "organization_invitations"
+		WHERE "token" = $1 AND "accepted_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	invitation := &models.OrganizationInvitation{}
+	err := exc.QueryRowContext(ctx, query, token).Scan(
+		&invitation.ID,
+		&invitation.CreatedAt,
+		&invitation.OrganizationID,
+		&invitation.InvitedBy,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.Token,
+		&invitation.ExpiresAt,
+		&invitation.AcceptedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return invitation, nil
+}
+
+func (r OrganizationInvitationRepository) Insert(ctx context.Context, invitation *models.OrganizationInvitation) error {
+	return r.insertExec(ctx, r.DB, invitation)
+}
+
+func (r OrganizationInvitationRepository) insertExec(ctx context.Context, exc Executor, invitation *models.OrganizationInvitation) error {
+	query := `
+		INSERT INTO "organization_invitations" ("id", "organization_id", "invited_by", "email", "role", "token", "expires_at")
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING "id", "created_at";
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	args := []any{
+		invitation.ID,
+		invitation.OrganizationID,
+		invitation.InvitedBy,
+		invitation.Email,
+		invitation.Role,
+		invitation.Token,
+		invitation.ExpiresAt,
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+
+	return nil
+}
+
+// AcceptExec marks the invitation as used, it fails with ErrEditConflict when
+// it was accepted concurrently.
+func (r OrganizationInvitationRepository) AcceptExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "organization_invitations"
+		SET "accepted_at" = now()
+		WHERE "id" = $1 AND "accepted_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
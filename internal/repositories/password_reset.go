@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type PasswordResetRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+func (r PasswordResetRepository) GetByToken(ctx context.Context, token string) (*models.PasswordReset, error) {
+	return r.getByTokenExec(ctx, r.DB, token)
+}
+
+func (r PasswordResetRepository) getByTokenExec(ctx context.Context, exc Executor, token string) (*models.PasswordReset, error) {
+	query := `
+		SELECT "id", "user_id", "token", "expires_at", "created_at", "used_at"
+		FROM "password_resets"
+		WHERE "token" = $1 AND "expires_at" > now() AND "used_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	pr := &models.PasswordReset{}
+	err := exc.QueryRowContext(ctx, query, token).Scan(
+		&pr.ID,
+		&pr.UserID,
+		&pr.Token,
+		&pr.ExpiresAt,
+		&pr.CreatedAt,
+		&pr.UsedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return pr, nil
+}
+
+func (r PasswordResetRepository) Insert(ctx context.Context, passwordResets ...*models.PasswordReset) error {
+	return r.InsertExec(ctx, r.DB, passwordResets...)
+}
+
+func (r PasswordResetRepository) InsertExec(ctx context.Context, exc Executor, passwordResets ...*models.PasswordReset) error {
+	if len(passwordResets) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "user_id", "token", "expires_at"}
+
+	valueStrings := make([]string, 0, len(passwordResets))
+	valueArgs := make([]any, 0, len(passwordResets)*len(columns))
+
+	for i, pr := range passwordResets {
+		values := []any{pr.ID, pr.UserID, pr.Token, pr.ExpiresAt}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "password_resets" (%s)
+		VALUES %s
+		RETURNING "id", "created_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, pr := range passwordResets {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&pr.ID, &pr.CreatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ConsumeExec marks the reset token as used. It fails with ErrEditConflict when
+// the token was already consumed, so a token can only ever be redeemed once.
+func (r PasswordResetRepository) ConsumeExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "password_resets"
+		SET "used_at" = now()
+		WHERE "id" = $1 AND "used_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// DeleteUnusedByUserIDExec removes every pending reset token of the user, so
+// only the most recently requested link stays valid.
+func (r PasswordResetRepository) DeleteUnusedByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "password_resets"
+		WHERE "user_id" = $1 AND "used_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+var permissionSchema = Schema[models.Permission]{
+	Table: "permissions",
+	Columns: append(
+		BaseColumns(func(permission *models.Permission) *models.Base { return &permission.Base }),
+		Column[models.Permission]{Name: "name", Field: func(permission *models.Permission) any { return &permission.Name }},
+		Column[models.Permission]{Name: "description", Field: func(permission *models.Permission) any { return &permission.Description }},
+	),
+	SoftDelete: true,
+	OrderBy:    "name",
+	Order:      "ASC",
+}
+
+type PermissionRepository struct {
+	Repository[models.Permission]
+}
+
+func NewPermissionRepository(db *sql.DB, config *config.Config) PermissionRepository {
+	return PermissionRepository{Repository: NewRepository(db, config, permissionSchema)}
+}
+
+// ListByRoleID returns the permissions granted to the role.
+func (r PermissionRepository) ListByRoleID(ctx context.Context, roleID uuid.UUID) ([]*models.Permission, error) {
+	return r.listByRoleIDExec(ctx, r.DB, roleID)
+}
+
+func (r PermissionRepository) listByRoleIDExec(ctx context.Context, exc Executor, roleID uuid.UUID) ([]*models.Permission, error) {
+	query := `
+		SELECT p."id", p."created_at", p."updated_at", p."name", p."description"
+		FROM "permissions" p
+		INNER JOIN "role_permissions" rp ON rp."permission_id" = p."id"
+		WHERE rp."role_id" = $1 AND p."deleted_at" IS NULL
+		ORDER BY p."name" ASC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, roleID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []*models.Permission{}
+	for rows.Next() {
+		permission := &models.Permission{}
+		if err := rows.Scan(
+			&permission.ID,
+			&permission.CreatedAt,
+			&permission.UpdatedAt,
+			&permission.Name,
+			&permission.Description,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, nil
+}
+
+// RoleHasAll reports whether the role is granted every one of the named
+// permissions.
+func (r PermissionRepository) RoleHasAll(ctx context.Context, roleID uuid.UUID, names ...string) (bool, error) {
+	return r.roleHasAllExec(ctx, r.DB, roleID, names...)
+}
+
+func (r PermissionRepository) roleHasAllExec(ctx context.Context, exc Executor, roleID uuid.UUID, names ...string) (bool, error) {
+	if len(names) == 0 {
+		return true, nil
+	}
+
+	query := `
+		SELECT COUNT(DISTINCT p."name")
+		FROM "permissions" p
+		INNER JOIN "role_permissions" rp ON rp."permission_id" = p."id"
+		WHERE rp."role_id" = $1 AND p."name" = ANY($2) AND p."deleted_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	var count int
+	err := exc.QueryRowContext(ctx, query, roleID, pq.StringArray(names)).Scan(&count)
+	if err != nil {
+		return false, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return count == len(names), nil
+}
+
+type RolePermissionRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+// Attach grants the permissions to the role, permissions the role already
+// has are left as they are.
+func (r RolePermissionRepository) Attach(ctx context.Context, roleID uuid.UUID, permissionIDs ...uuid.UUID) error {
+	return r.AttachExec(ctx, r.DB, roleID, permissionIDs...)
+}
+
+func (r RolePermissionRepository) AttachExec(ctx context.Context, exc Executor, roleID uuid.UUID, permissionIDs ...uuid.UUID) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+
+	columns := []string{"role_id", "permission_id"}
+
+	valueStrings := make([]string, 0, len(permissionIDs))
+	valueArgs := make([]any, 0, len(permissionIDs)*len(columns))
+
+	for i, permissionID := range permissionIDs {
+		values := []any{roleID, permissionID}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "role_permissions" (%s)
+		VALUES %s
+		ON CONFLICT ("role_id", "permission_id") DO NOTHING;
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, valueArgs...)
+	if err != nil {
+		err = translateError(err)
+		// the role or one of the permissions doesn't exist
+		if errors.Is(err, ErrForeignKeyViolation) {
+			return errtrace.Wrap(ErrRecordNotFound)
+		}
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+// Detach revokes the permission from the role.
+func (r RolePermissionRepository) Detach(ctx context.Context, roleID uuid.UUID, permissionID uuid.UUID) error {
+	return r.DetachExec(ctx, r.DB, roleID, permissionID)
+}
+
+func (r RolePermissionRepository) DetachExec(ctx context.Context, exc Executor, roleID uuid.UUID, permissionID uuid.UUID) error {
+	query := `
+		DELETE FROM "role_permissions"
+		WHERE "role_id" = $1 AND "permission_id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, roleID, permissionID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
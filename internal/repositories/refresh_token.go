@@ -5,47 +5,66 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
-	"time"
 
 	"gofi/internal/config"
 	"gofi/internal/models"
 
 	"braces.dev/errtrace"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
 )
 
+var refreshTokenSchema = Schema[models.RefreshToken]{
+	Table: "refresh_tokens",
+	Columns: []Column[models.RefreshToken]{
+		{Name: "id", Field: func(rt *models.RefreshToken) any { return &rt.ID }},
+		{Name: "user_id", Field: func(rt *models.RefreshToken) any { return &rt.UserID }, InsertOnly: true},
+		{Name: "family_id", Field: func(rt *models.RefreshToken) any { return &rt.FamilyID }, InsertOnly: true},
+		// set to NULL when the session is deleted, it must not be written back
+		{Name: "session_id", Field: func(rt *models.RefreshToken) any { return &rt.SessionID }, InsertOnly: true},
+		{Name: "token", Field: func(rt *models.RefreshToken) any { return &rt.Token }},
+		{Name: "expires_at", Field: func(rt *models.RefreshToken) any { return &rt.ExpiresAt }},
+		{Name: "created_at", Field: func(rt *models.RefreshToken) any { return &rt.CreatedAt }, ReadOnly: true},
+		{Name: "revoked_at", Field: func(rt *models.RefreshToken) any { return &rt.RevokedAt }},
+	},
+	OrderBy: "created_at",
+	Order:   "DESC",
+}
+
 type RefreshTokenRepository struct {
-	DB     *sql.DB
-	Config *config.ConfigApp
+	Repository[models.RefreshToken]
 }
 
-func (r RefreshTokenRepository) Get(userID uuid.UUID, token string) (*models.RefreshToken, error) {
-	return r.getExec(r.DB, userID, token)
+func NewRefreshTokenRepository(db *sql.DB, config *config.Config) RefreshTokenRepository {
+	return RefreshTokenRepository{Repository: NewRepository(db, config, refreshTokenSchema)}
 }
 
-func (r RefreshTokenRepository) getExec(exc Executor, userID uuid.UUID, token string) (*models.RefreshToken, error) {
+// GetByUserToken only returns a token of the user that can still be used.
+func (r RefreshTokenRepository) GetByUserToken(ctx context.Context, userID uuid.UUID, token string) (*models.RefreshToken, error) {
+	return r.getExec(ctx, r.DB, userID, token)
+}
+
+func (r RefreshTokenRepository) getExec(ctx context.Context, exc Executor, userID uuid.UUID, token string) (*models.RefreshToken, error) {
 	query := `
-		SELECT "id", "user_id", "token", "expires_at", "created_at", "revoked_at"
+		SELECT "id", "user_id", "family_id", "session_id", "token", "expires_at", "created_at", "revoked_at"
 		FROM "refresh_tokens"
 		WHERE "user_id" = $1 AND "token" = $2 AND "expires_at" > now() AND "revoked_at" IS NULL;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	rt := &models.RefreshToken{}
 	err := exc.QueryRowContext(ctx, query, userID, token).Scan(
 		&rt.ID,
 		&rt.UserID,
+		&rt.FamilyID,
+		&rt.SessionID,
 		&rt.Token,
 		&rt.ExpiresAt,
 		&rt.CreatedAt,
@@ -63,108 +82,166 @@ func (r RefreshTokenRepository) getExec(exc Executor, userID uuid.UUID, token st
 	return rt, nil
 }
 
-func (r RefreshTokenRepository) Insert(refreshTokens ...*models.RefreshToken) error {
-	return r.InsertExec(r.DB, refreshTokens...)
+func (r RefreshTokenRepository) GetByToken(ctx context.Context, token string) (*models.RefreshToken, error) {
+	return r.GetByTokenExec(ctx, r.DB, token)
 }
 
-func (r RefreshTokenRepository) InsertExec(exc Executor, refreshTokens ...*models.RefreshToken) error {
-	if len(refreshTokens) == 0 {
-		return nil
-	}
-
-	columns := []string{"id", "user_id", "token", "expires_at", "created_at", "revoked_at"}
+// GetByTokenExec returns the refresh token even when it is already revoked, so
+// callers can detect reuse. The row is locked when running inside a transaction.
+func (r RefreshTokenRepository) GetByTokenExec(ctx context.Context, exc Executor, token string) (*models.RefreshToken, error) {
+	query := `
+		SELECT "id", "user_id", "family_id", "session_id", "token", "expires_at", "created_at", "revoked_at"
+		FROM "refresh_tokens"
+		WHERE "token" = $1
+		FOR UPDATE;
+	`
 
-	valueStrings := make([]string, 0, len(refreshTokens))
-	valueArgs := make([]any, 0, len(refreshTokens)*len(columns))
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
 
-	for i, refreshToken := range refreshTokens {
-		values := []any{refreshToken.ID, refreshToken.UserID, refreshToken.Token, refreshToken.ExpiresAt, refreshToken.CreatedAt, refreshToken.RevokedAt}
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
 
-		placeholders := make([]string, 0, len(values))
-		for j := range columns {
-			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+	rt := &models.RefreshToken{}
+	err := exc.QueryRowContext(ctx, query, token).Scan(
+		&rt.ID,
+		&rt.UserID,
+		&rt.FamilyID,
+		&rt.SessionID,
+		&rt.Token,
+		&rt.ExpiresAt,
+		&rt.CreatedAt,
+		&rt.RevokedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
 		}
-
-		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
-		valueArgs = append(valueArgs, values...)
 	}
 
-	query := fmt.Sprintf(`
-		INSERT INTO "refresh_tokens" (%s)
-		VALUES %s
-		RETURNING "id", "created_at";
-	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+	return rt, nil
+}
 
-	if r.Config != nil && r.Config.Debug {
+func (r RefreshTokenRepository) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.RevokeByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r RefreshTokenRepository) RevokeByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) error {
+	query := `
+		UPDATE "refresh_tokens"
+		SET "revoked_at" = now()
+		WHERE "user_id" = $1 AND "revoked_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
-	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	_, err := exc.ExecContext(ctx, query, userID)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23505" {
-				return errtrace.Wrap(ErrInsertDuplicate)
-			}
-		}
 		return errtrace.Wrap(err)
 	}
-	defer rows.Close()
 
-	for _, refreshToken := range refreshTokens {
-		if !rows.Next() {
-			return errtrace.New("error scanning row: no next row")
-		}
+	return nil
+}
 
-		if err := rows.Scan(&refreshToken.ID, &refreshToken.CreatedAt); err != nil {
-			return errtrace.Errorf("error scanning row: %w", err)
-		}
+func (r RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.RevokeFamilyExec(ctx, r.DB, familyID)
+}
+
+func (r RefreshTokenRepository) RevokeFamilyExec(ctx context.Context, exc Executor, familyID uuid.UUID) error {
+	query := `
+		UPDATE "refresh_tokens"
+		SET "revoked_at" = now()
+		WHERE "family_id" = $1 AND "revoked_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return errtrace.Wrap(err)
 	}
 
 	return nil
 }
 
-func (r RefreshTokenRepository) Update(refreshToken *models.RefreshToken) error {
-	return r.updateExec(r.DB, refreshToken)
+func (r RefreshTokenRepository) RevokeBySessionID(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	return r.RevokeBySessionIDExec(ctx, r.DB, userID, sessionID)
 }
 
-func (r RefreshTokenRepository) updateExec(exc Executor, refreshToken *models.RefreshToken) error {
+// RevokeBySessionIDExec revokes the token families of a session, the tokens
+// lose their session once it is deleted so this must run first.
+func (r RefreshTokenRepository) RevokeBySessionIDExec(ctx context.Context, exc Executor, userID uuid.UUID, sessionID uuid.UUID) error {
 	query := `
 		UPDATE "refresh_tokens"
-		SET "token" = $1, "expires_at" = $2, "revoked_at" = $3
-		WHERE "id" = $4;
+		SET "revoked_at" = now()
+		WHERE "user_id" = $1 AND "revoked_at" IS NULL AND "family_id" IN (
+			SELECT "family_id"
+			FROM "refresh_tokens"
+			WHERE "session_id" = $2
+		);
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	args := []any{
-		refreshToken.Token,
-		refreshToken.ExpiresAt,
-		refreshToken.RevokedAt,
-		refreshToken.ID,
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
-	result, err := exc.ExecContext(ctx, query, args...)
+	_, err := exc.ExecContext(ctx, query, userID, sessionID)
 	if err != nil {
-		return err
+		return errtrace.Wrap(err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	return nil
+}
+
+func (r RefreshTokenRepository) RevokeOthers(ctx context.Context, userID uuid.UUID, keepSessionID uuid.UUID) error {
+	return r.RevokeOthersExec(ctx, r.DB, userID, keepSessionID)
+}
+
+// RevokeOthersExec revokes every token family of the user except the ones of
+// keepSessionID.
+func (r RefreshTokenRepository) RevokeOthersExec(ctx context.Context, exc Executor, userID uuid.UUID, keepSessionID uuid.UUID) error {
+	query := `
+		UPDATE "refresh_tokens"
+		SET "revoked_at" = now()
+		WHERE "user_id" = $1 AND "revoked_at" IS NULL AND "family_id" NOT IN (
+			SELECT "family_id"
+			FROM "refresh_tokens"
+			WHERE "session_id" = $2
+		);
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
 	}
 
-	if rowsAffected == 0 {
-		return ErrEditConflict
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID, keepSessionID)
+	if err != nil {
+		return errtrace.Wrap(err)
 	}
 
 	return nil
@@ -0,0 +1,482 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+// Column maps a column of the table to a field of T.
+type Column[T any] struct {
+	Name string
+	// Field returns a pointer to the field of the entity, it is scanned into
+	// on reads and bound as the value on writes.
+	Field func(entity *T) any
+	// ReadOnly columns are filled by the database, e.g. "created_at", they
+	// are never written and are read back after an insert.
+	ReadOnly bool
+	// InsertOnly columns are left out of Update, e.g. an owner that never
+	// changes or a password that has its own query.
+	InsertOnly bool
+}
+
+// Schema declares the table of T once, the queries of Repository are built
+// from it.
+type Schema[T any] struct {
+	Table   string
+	Columns []Column[T]
+	// SoftDelete hides the rows with a "deleted_at" from List and Get.
+	SoftDelete bool
+	// OrderBy and Order are the default ordering of List, when the request
+	// has no sort. Order is "ASC" or "DESC".
+	OrderBy string
+	Order   string
+}
+
+// Repository implements List/Get/Insert/Update/Delete/SoftDelete/Restore for
+// a table declared by its Schema. Repositories embed it and only write the
+// queries specific to their table.
+type Repository[T any] struct {
+	BaseRepository
+	Schema Schema[T]
+}
+
+// BaseColumns maps the columns of models.Base, base returns the Base
+// embedded in T.
+func BaseColumns[T any](base func(entity *T) *models.Base) []Column[T] {
+	return []Column[T]{
+		{Name: "id", Field: func(entity *T) any { return &base(entity).ID }},
+		{Name: "created_at", Field: func(entity *T) any { return &base(entity).CreatedAt }, ReadOnly: true},
+		{Name: "updated_at", Field: func(entity *T) any { return &base(entity).UpdatedAt }, ReadOnly: true},
+	}
+}
+
+func NewRepository[T any](db *sql.DB, config *config.Config, schema Schema[T]) Repository[T] {
+	return Repository[T]{
+		BaseRepository: BaseRepository{DB: db, TableName: schema.Table, Config: config},
+		Schema:         schema,
+	}
+}
+
+func (r Repository[T]) columnNames(match func(Column[T]) bool) []string {
+	names := make([]string, 0, len(r.Schema.Columns))
+	for _, column := range r.Schema.Columns {
+		if match(column) {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+func (r Repository[T]) fields(entity *T, match func(Column[T]) bool) []any {
+	fields := make([]any, 0, len(r.Schema.Columns))
+	for _, column := range r.Schema.Columns {
+		if match(column) {
+			fields = append(fields, column.Field(entity))
+		}
+	}
+	return fields
+}
+
+func (r Repository[T]) hasColumn(name string) bool {
+	return slices.ContainsFunc(r.Schema.Columns, func(column Column[T]) bool {
+		return column.Name == name
+	})
+}
+
+func allColumns[T any](Column[T]) bool { return true }
+
+func writableColumns[T any](column Column[T]) bool { return !column.ReadOnly }
+
+func returnedColumns[T any](column Column[T]) bool { return column.ReadOnly || column.Name == "id" }
+
+func updatableColumns[T any](column Column[T]) bool {
+	return !column.ReadOnly && !column.InsertOnly && column.Name != "id" && column.Name != "updated_at"
+}
+
+func quoteColumns(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, pq.QuoteIdentifier(name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (r Repository[T]) Count(ctx context.Context) (int64, error) {
+	return r.CountExec(ctx, r.DB)
+}
+
+// CountExec counts the rows List can return, soft deleted rows excluded.
+func (r Repository[T]) CountExec(ctx context.Context, exc Executor) (int64, error) {
+	return r.countWhereExec(ctx, exc, &QueryOptions{})
+}
+
+// where builds the WHERE clause of List, placeholders start at $1.
+func (r Repository[T]) where(opts *QueryOptions) (string, []any) {
+	var conditions []string
+	if r.Schema.SoftDelete {
+		conditions = append(conditions, `"deleted_at" IS NULL`)
+	}
+
+	filters, args := opts.Filter.Where(1)
+	conditions = append(conditions, filters...)
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r Repository[T]) countWhereExec(ctx context.Context, exc Executor, opts *QueryOptions) (int64, error) {
+	where, args := r.where(opts)
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "%s"%s;
+	`, r.Schema.Table, where)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	var count int64
+	if err := exc.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, errtrace.Errorf("error scanning row: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r Repository[T]) List(ctx context.Context, opts *QueryOptions) ([]*T, PaginationMetadata, error) {
+	return r.ListExec(ctx, r.DB, opts)
+}
+
+// ListExec lists the rows matching opts.Filter, sorted by it or by the
+// default order of the schema.
+func (r Repository[T]) ListExec(ctx context.Context, exc Executor, opts *QueryOptions) ([]*T, PaginationMetadata, error) {
+	if opts == nil {
+		opts = &QueryOptions{}
+	}
+
+	where, args := r.where(opts)
+	argIndex := len(args) + 1
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString(fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"%s
+	`, quoteColumns(r.columnNames(allColumns[T])), r.Schema.Table, where))
+
+	orderBy := opts.Filter.OrderBy()
+	if orderBy == "" && r.Schema.OrderBy != "" {
+		orderBy = pq.QuoteIdentifier(r.Schema.OrderBy) + " " + r.Schema.Order
+	}
+
+	if orderBy != "" {
+		queryBuilder.WriteString(" ORDER BY " + orderBy)
+	}
+
+	if opts.Limit > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
+		args = append(args, opts.Limit)
+		argIndex++
+	}
+
+	if opts.Offset > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" OFFSET $%d", argIndex))
+		args = append(args, opts.Offset)
+		argIndex++
+	}
+
+	query := queryBuilder.String()
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	entities := []*T{}
+	for rows.Next() {
+		entity := new(T)
+		if err := rows.Scan(r.fields(entity, allColumns[T])...); err != nil {
+			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
+		}
+		entities = append(entities, entity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, PaginationMetadata{}, errtrace.Errorf("error querying rows: %w", err)
+	}
+
+	count, err := r.countWhereExec(ctx, exc, opts)
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+	}
+
+	return entities, PaginationMetadata{Total: count}, nil
+}
+
+func (r Repository[T]) Get(ctx context.Context, id uuid.UUID) (*T, error) {
+	return r.GetExec(ctx, r.DB, id)
+}
+
+func (r Repository[T]) GetExec(ctx context.Context, exc Executor, id uuid.UUID) (*T, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM "%s"
+		WHERE "id" = $1
+	`, quoteColumns(r.columnNames(allColumns[T])), r.Schema.Table)
+
+	if r.Schema.SoftDelete {
+		query += ` AND "deleted_at" IS NULL`
+	}
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	entity := new(T)
+	err := exc.QueryRowContext(ctx, query, id).Scan(r.fields(entity, allColumns[T])...)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return entity, nil
+}
+
+func (r Repository[T]) Insert(ctx context.Context, entities ...*T) error {
+	return r.InsertExec(ctx, r.DB, entities...)
+}
+
+// InsertExec inserts the writable columns of the entities and scans the id
+// and read-only columns back into them.
+func (r Repository[T]) InsertExec(ctx context.Context, exc Executor, entities ...*T) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	columns := r.columnNames(writableColumns[T])
+
+	valueStrings := make([]string, 0, len(entities))
+	valueArgs := make([]any, 0, len(entities)*len(columns))
+
+	for i, entity := range entities {
+		values := r.fields(entity, writableColumns[T])
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "%s" (%s)
+		VALUES %s
+		RETURNING %s;
+	`, r.Schema.Table, quoteColumns(columns), strings.Join(valueStrings, ", "), quoteColumns(r.columnNames(returnedColumns[T])))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, entity := range entities {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(r.fields(entity, returnedColumns[T])...); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+
+	return nil
+}
+
+func (r Repository[T]) Update(ctx context.Context, id uuid.UUID, entity *T) error {
+	return r.UpdateExec(ctx, r.DB, id, entity)
+}
+
+// UpdateExec writes every writable column but "id", soft deleted rows are
+// never written and return ErrEditConflict. Tables with an
+// "updated_at" use it as the version of the row: the update only applies when
+// it is still the one of entity, ErrEditConflict is returned otherwise, and
+// the new one is set on entity.
+func (r Repository[T]) UpdateExec(ctx context.Context, exc Executor, id uuid.UUID, entity *T) error {
+	columns := r.columnNames(updatableColumns[T])
+	args := r.fields(entity, updatableColumns[T])
+
+	sets := make([]string, 0, len(columns)+1)
+	for i, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s = $%d", pq.QuoteIdentifier(column), i+1))
+	}
+
+	args = append(args, id)
+	where := fmt.Sprintf(`"id" = $%d`, len(args))
+	returning := ""
+
+	if r.Schema.SoftDelete {
+		where += ` AND "deleted_at" IS NULL`
+	}
+
+	var version []any
+	if r.hasColumn("updated_at") {
+		version = r.fields(entity, func(column Column[T]) bool { return column.Name == "updated_at" })
+
+		sets = append(sets, `"updated_at" = now()`)
+		args = append(args, version[0])
+		where += fmt.Sprintf(` AND "updated_at" = $%d`, len(args))
+		returning = ` RETURNING "updated_at"`
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE "%s"
+		SET %s
+		WHERE %s%s;
+	`, r.Schema.Table, strings.Join(sets, ", "), where, returning)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	if version != nil {
+		err := exc.QueryRowContext(ctx, query, args...).Scan(version...)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrEditConflict
+			}
+			return errtrace.Wrap(translateError(err))
+		}
+
+		return nil
+	}
+
+	result, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r Repository[T]) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.DeleteExec(ctx, r.DB, id)
+}
+
+func (r Repository[T]) DeleteExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	return r.BaseRepository.deleteExec(ctx, exc, id)
+}
+
+// DeleteVersionExec deletes the row only while its "updated_at" is still
+// version, ErrEditConflict is returned otherwise.
+func (r Repository[T]) DeleteVersionExec(ctx context.Context, exc Executor, id uuid.UUID, version time.Time) error {
+	query := fmt.Sprintf(`
+		DELETE FROM "%s"
+		WHERE "id" = $1 AND "updated_at" = $2;
+	`, r.Schema.Table)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id, version)
+	if err != nil {
+		return errtrace.Wrap(translateDeleteError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r Repository[T]) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	return r.SoftDeleteExec(ctx, r.DB, id)
+}
+
+func (r Repository[T]) SoftDeleteExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	return r.BaseRepository.softDeleteExec(ctx, exc, id)
+}
+
+func (r Repository[T]) Restore(ctx context.Context, id uuid.UUID) error {
+	return r.RestoreExec(ctx, r.DB, id)
+}
+
+func (r Repository[T]) RestoreExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	return r.BaseRepository.restoreExec(ctx, exc, id)
+}
@@ -5,44 +5,92 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
 	"gofi/internal/config"
+	"gofi/internal/lib/filter"
 	"gofi/internal/models"
 
 	"braces.dev/errtrace"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
 )
 
+var sessionSchema = Schema[models.Session]{
+	Table: "sessions",
+	Columns: append(
+		BaseColumns(func(session *models.Session) *models.Base { return &session.Base }),
+		Column[models.Session]{Name: "user_id", Field: func(session *models.Session) any { return &session.UserID }, InsertOnly: true},
+		Column[models.Session]{Name: "token", Field: func(session *models.Session) any { return &session.Token }},
+		Column[models.Session]{Name: "expires_at", Field: func(session *models.Session) any { return &session.ExpiresAt }},
+		Column[models.Session]{Name: "ip_address", Field: func(session *models.Session) any { return &session.IPAddress }},
+		Column[models.Session]{Name: "user_agent", Field: func(session *models.Session) any { return &session.UserAgent }},
+		Column[models.Session]{Name: "organization_id", Field: func(session *models.Session) any { return &session.OrganizationID }},
+		Column[models.Session]{Name: "last_activity_at", Field: func(session *models.Session) any { return &session.LastActivityAt }, ReadOnly: true},
+	),
+	OrderBy: "created_at",
+	Order:   "DESC",
+}
+
+// SessionRepository gets its inserts from Repository, sessions are otherwise
+// looked up and deleted by user and token.
 type SessionRepository struct {
-	DB     *sql.DB
-	Config *config.ConfigApp
+	Repository[models.Session]
 }
 
-func (r SessionRepository) Count() (int64, error) {
-	return r.countExec(r.DB)
+func NewSessionRepository(db *sql.DB, config *config.Config) SessionRepository {
+	return SessionRepository{Repository: NewRepository(db, config, sessionSchema)}
 }
 
-func (r SessionRepository) countExec(exc Executor) (int64, error) {
-	query := `
+// SessionFilterFields is the whitelist of the filters and sorts of List.
+var SessionFilterFields = filter.Fields{
+	"user_id":          {Column: `"s"."user_id"`, Type: filter.UUID, Operators: []filter.Operator{filter.Eq, filter.In}},
+	"ip_address":       {Column: `"s"."ip_address"`, Type: filter.String, Operators: []filter.Operator{filter.Eq, filter.Like}},
+	"user_agent":       {Column: `"s"."user_agent"`, Type: filter.String, Operators: []filter.Operator{filter.Ilike}},
+	"created_at":       {Column: `"s"."created_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte}, Sortable: true},
+	"expires_at":       {Column: `"s"."expires_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte}, Sortable: true},
+	"last_activity_at": {Column: `"s"."last_activity_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte, filter.Null}, Sortable: true, Nullable: true},
+}
+
+// listWhere builds the WHERE clause of List, placeholders start at $1.
+func (r SessionRepository) listWhere(opts *QueryOptions) (string, []any) {
+	var conditions []string
+	var args []any
+
+	if condition, tenantArgs := opts.Tenant.where(`"s"."user_id"`, len(args)+1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, tenantArgs...)
+	}
+
+	filters, filterArgs := opts.Filter.Where(len(args) + 1)
+	conditions = append(conditions, filters...)
+	args = append(args, filterArgs...)
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r SessionRepository) countExec(ctx context.Context, exc Executor, opts *QueryOptions) (int64, error) {
+	where, args := r.listWhere(opts)
+
+	query := fmt.Sprintf(`
 		SELECT COUNT(*)
-		FROM "sessions";
-	`
+		FROM "sessions" "s"%s;
+	`, where)
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	var count int64
-	err := exc.QueryRowContext(ctx, query).Scan(&count)
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return 0, errtrace.Errorf("error scanning row: %w", err)
 	}
@@ -50,51 +98,69 @@ func (r SessionRepository) countExec(exc Executor) (int64, error) {
 	return count, nil
 }
 
-func (r SessionRepository) List(opts *QueryOptions) ([]*models.Session, PaginationMetadata, error) {
-	return r.listExec(r.DB, opts)
+func (r SessionRepository) List(ctx context.Context, opts *QueryOptions) ([]*models.Session, PaginationMetadata, error) {
+	return r.listExec(ctx, r.DB, opts)
 }
 
-func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Session, PaginationMetadata, error) {
+func (r SessionRepository) listExec(ctx context.Context, exc Executor, opts *QueryOptions) ([]*models.Session, PaginationMetadata, error) {
 	if opts == nil {
 		opts = &QueryOptions{}
 	}
 
-	selectFields := `"s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."expires_at", "s"."ip_address", "s"."user_agent"`
+	keyset, err := newKeyset(opts, `"s"."id"`, filter.Sort{Column: `"s"."created_at"`, Desc: true})
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+	}
+
+	where, args := r.listWhere(opts)
+	argIndex := len(args) + 1
+
+	if keyset != nil && keyset.cursor != nil {
+		condition, keysetArgs := keyset.where(argIndex)
+		if where == "" {
+			where = " WHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+		args = append(args, keysetArgs...)
+		argIndex += len(keysetArgs)
+	}
+
+	selectFields := `"s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."expires_at", "s"."ip_address", "s"."user_agent", "s"."last_activity_at"`
+	if keyset != nil {
+		selectFields += ", " + keyset.selectKey()
+	}
+
 	baseQuery := fmt.Sprintf(`
 		SELECT %s
-		FROM "sessions" "s"
-	`, selectFields)
-
-	var args []any
-	argIndex := 1
+		FROM "sessions" "s"%s
+	`, selectFields, where)
 
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(baseQuery)
 
-	orderBy := `"s"."created_at"`
-	order := "DESC"
-
-	if opts.OrderBy != "" {
-		orderBy = opts.OrderBy
+	orderBy := opts.Filter.OrderBy()
+	if keyset != nil {
+		orderBy = keyset.orderBy()
+	} else if orderBy == "" {
+		orderBy = `"s"."created_at" DESC`
 	}
 
-	if opts.Order != "" {
-		upperOrder := strings.ToUpper(opts.Order)
-		if upperOrder != "ASC" && upperOrder != "DESC" {
-			return nil, PaginationMetadata{}, errtrace.New("invalid order")
-		}
-		order = upperOrder
-	}
-
-	queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s %s", orderBy, order))
+	queryBuilder.WriteString(" ORDER BY " + orderBy)
 
 	if opts.Limit > 0 {
+		limit := opts.Limit
+		// one more row tells whether there is a next page
+		if keyset != nil {
+			limit++
+		}
+
 		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
-		args = append(args, opts.Limit)
+		args = append(args, limit)
 		argIndex++
 	}
 
-	if opts.Offset > 0 {
+	if opts.Offset > 0 && (keyset == nil || keyset.cursor == nil) {
 		queryBuilder.WriteString(fmt.Sprintf(" OFFSET $%d", argIndex))
 		args = append(args, opts.Offset)
 		argIndex++
@@ -102,12 +168,12 @@ func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models
 
 	query := queryBuilder.String()
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	rows, err := exc.QueryContext(ctx, query, args...)
@@ -117,9 +183,12 @@ func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models
 	defer rows.Close()
 
 	var sessions []*models.Session
+	var ids []uuid.UUID
+	var keys []string
 	for rows.Next() {
 		session := &models.Session{}
-		if err := rows.Scan(
+
+		dest := []any{
 			&session.ID,
 			&session.CreatedAt,
 			&session.UpdatedAt,
@@ -127,39 +196,104 @@ func (r SessionRepository) listExec(exc Executor, opts *QueryOptions) ([]*models
 			&session.ExpiresAt,
 			&session.IPAddress,
 			&session.UserAgent,
-		); err != nil {
+			&session.LastActivityAt,
+		}
+
+		var key string
+		if keyset != nil {
+			dest = append(dest, &key)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
 			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
 		}
+
 		sessions = append(sessions, session)
+		ids = append(ids, session.ID)
+		keys = append(keys, key)
+	}
+
+	var meta PaginationMetadata
+	if keyset != nil {
+		sessions, meta = page(*keyset, opts, sessions, ids, keys)
+	}
+
+	if !opts.SkipTotal {
+		meta.Total, err = r.countExec(ctx, exc, opts)
+		if err != nil {
+			return nil, PaginationMetadata{}, errtrace.Errorf("error counting rows: %w", err)
+		}
 	}
 
-	count, err := r.countExec(exc)
+	return sessions, meta, nil
+}
+
+// ListByUserID returns the active sessions of a user, the most recently used
+// first.
+func (r SessionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	return r.listByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r SessionRepository) listByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.Session, error) {
+	query := `
+		SELECT "s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."expires_at", "s"."ip_address", "s"."user_agent", "s"."last_activity_at"
+		FROM "sessions" "s"
+		WHERE "s"."user_id" = $1 AND "s"."expires_at" > now()
+		ORDER BY "s"."last_activity_at" DESC NULLS LAST, "s"."created_at" DESC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
 	if err != nil {
-		return nil, PaginationMetadata{}, errtrace.Errorf("error counting rows: %w", err)
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.Session{}
+	for rows.Next() {
+		session := &models.Session{}
+		if err := rows.Scan(
+			&session.ID,
+			&session.CreatedAt,
+			&session.UpdatedAt,
+			&session.UserID,
+			&session.ExpiresAt,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.LastActivityAt,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		sessions = append(sessions, session)
 	}
 
-	return sessions, PaginationMetadata{
-		Total: count,
-	}, nil
+	return sessions, nil
 }
 
-func (r SessionRepository) GetByUserID(userID uuid.UUID) (*models.Session, error) {
-	return r.getByUserIDExec(r.DB, userID)
+func (r SessionRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Session, error) {
+	return r.getByUserIDExec(ctx, r.DB, userID)
 }
 
-func (r SessionRepository) getByUserIDExec(exc Executor, userID uuid.UUID) (*models.Session, error) {
+func (r SessionRepository) getByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) (*models.Session, error) {
 	query := `
 		SELECT "id", "user_id", "token", "expires_at"
 		FROM "sessions"
 		WHERE "user_id" = $1;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	session := &models.Session{}
@@ -181,23 +315,23 @@ func (r SessionRepository) getByUserIDExec(exc Executor, userID uuid.UUID) (*mod
 	return session, nil
 }
 
-func (r SessionRepository) GetByUserToken(userID uuid.UUID, token string) (*models.Session, error) {
-	return r.getByUserTokenExec(r.DB, userID, token)
+func (r SessionRepository) GetByUserToken(ctx context.Context, userID uuid.UUID, token string) (*models.Session, error) {
+	return r.getByUserTokenExec(ctx, r.DB, userID, token)
 }
 
-func (r SessionRepository) getByUserTokenExec(exc Executor, userID uuid.UUID, token string) (*models.Session, error) {
+func (r SessionRepository) getByUserTokenExec(ctx context.Context, exc Executor, userID uuid.UUID, token string) (*models.Session, error) {
 	query := `
-		SELECT "id", "user_id", "token", "expires_at"
+		SELECT "id", "user_id", "token", "expires_at", "organization_id"
 		FROM "sessions"
 		WHERE "user_id" = $1 AND "token" = $2;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	session := &models.Session{}
@@ -206,6 +340,7 @@ func (r SessionRepository) getByUserTokenExec(exc Executor, userID uuid.UUID, to
 		&session.UserID,
 		&session.Token,
 		&session.ExpiresAt,
+		&session.OrganizationID,
 	)
 	if err != nil {
 		switch {
@@ -219,23 +354,23 @@ func (r SessionRepository) getByUserTokenExec(exc Executor, userID uuid.UUID, to
 	return session, nil
 }
 
-func (r SessionRepository) GetByToken(token string) (*models.Session, error) {
-	return r.getByTokenExec(r.DB, token)
+func (r SessionRepository) GetByToken(ctx context.Context, token string) (*models.Session, error) {
+	return r.getByTokenExec(ctx, r.DB, token)
 }
 
-func (r SessionRepository) getByTokenExec(exc Executor, token string) (*models.Session, error) {
+func (r SessionRepository) getByTokenExec(ctx context.Context, exc Executor, token string) (*models.Session, error) {
 	query := `
 		SELECT "s"."id", "s"."created_at", "s"."updated_at", "s"."user_id", "s"."token", "s"."expires_at", "s"."ip_address", "s"."user_agent"
 		FROM "sessions" "s"
 		WHERE "s"."token" = $1 AND "s"."expires_at" > now();
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	session := &models.Session{}
@@ -261,100 +396,189 @@ func (r SessionRepository) getByTokenExec(exc Executor, token string) (*models.S
 	return session, nil
 }
 
-func (r SessionRepository) Insert(session ...*models.Session) error {
-	return r.InsertExec(r.DB, session...)
+func (r SessionRepository) Update(ctx context.Context, id uuid.UUID, session *models.Session) error {
+	return r.UpdateExec(ctx, r.DB, id, session)
 }
 
-func (r SessionRepository) InsertExec(exc Executor, session ...*models.Session) error {
-	if len(session) == 0 {
-		return nil
+// UpdateExec rotates the token of the session, it isn't versioned: a refresh
+// and an organization switch may race and the last one wins.
+func (r SessionRepository) UpdateExec(ctx context.Context, exc Executor, id uuid.UUID, session *models.Session) error {
+	query := `
+		UPDATE "sessions"
+		SET "token" = $1, "expires_at" = $2, "ip_address" = $3, "user_agent" = $4, "organization_id" = $5, "updated_at" = now()
+		WHERE "id" = $6;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
 	}
 
-	columns := []string{"id", "user_id", "token", "expires_at", "ip_address", "user_agent"}
+	args := []any{
+		session.Token,
+		session.ExpiresAt,
+		session.IPAddress,
+		session.UserAgent,
+		session.OrganizationID,
+		id,
+	}
 
-	valueStrings := make([]string, 0, len(session))
-	valueArgs := make([]any, 0, len(session)*len(columns))
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
 
-	for i, s := range session {
-		values := []any{s.ID, s.UserID, s.Token, s.ExpiresAt, s.IPAddress, s.UserAgent}
+	result, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
 
-		placeholders := make([]string, 0, len(values))
-		for j := range columns {
-			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
-		}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
-		valueArgs = append(valueArgs, values...)
+	if rowsAffected == 0 {
+		return ErrEditConflict
 	}
 
-	query := fmt.Sprintf(`
-		INSERT INTO "sessions" (%s)
-		VALUES %s
-		RETURNING "id", "created_at", "updated_at";
-	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+	return nil
+}
 
-	if r.Config != nil && r.Config.Debug {
+// UpdateLastActivity records the session has been used, at most once a
+// minute to spare a write on every request.
+func (r SessionRepository) UpdateLastActivity(ctx context.Context, id uuid.UUID) error {
+	return r.updateLastActivityExec(ctx, r.DB, id)
+}
+
+func (r SessionRepository) updateLastActivityExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "sessions"
+		SET "last_activity_at" = now()
+		WHERE "id" = $1 AND ("last_activity_at" IS NULL OR "last_activity_at" < now() - INTERVAL '1 minute');
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
-	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	_, err := exc.ExecContext(ctx, query, id)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23505" {
-				return errtrace.Wrap(ErrInsertDuplicate)
-			}
-		}
 		return errtrace.Wrap(err)
 	}
-	defer rows.Close()
 
-	for _, s := range session {
-		if !rows.Next() {
-			return errtrace.New("error scanning row: no next row")
-		}
+	return nil
+}
 
-		if err := rows.Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt); err != nil {
-			return errtrace.Errorf("error scanning row: %w", err)
-		}
+func (r SessionRepository) Delete(ctx context.Context, userID uuid.UUID, token string) error {
+	return r.deleteExec(ctx, r.DB, userID, token)
+}
+
+func (r SessionRepository) deleteExec(ctx context.Context, exc Executor, userID uuid.UUID, token string) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "user_id" = $1 AND "token" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	args := []any{userID, token}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(err)
 	}
 
 	return nil
 }
 
-func (r SessionRepository) Update(id uuid.UUID, session *models.Session) error {
-	return r.updateExec(r.DB, id, session)
+func (r SessionRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.DeleteByUserIDExec(ctx, r.DB, userID)
 }
 
-func (r SessionRepository) updateExec(exc Executor, id uuid.UUID, session *models.Session) error {
+func (r SessionRepository) DeleteByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) error {
 	query := `
-		UPDATE "sessions"
-		SET "token" = $1, "expires_at" = $2, "ip_address" = $3, "user_agent" = $4, "updated_at" = now()
-		WHERE "id" = $5;
+		DELETE FROM "sessions"
+		WHERE "user_id" = $1;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	args := []any{
-		session.Token,
-		session.ExpiresAt,
-		session.IPAddress,
-		session.UserAgent,
-		id,
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r SessionRepository) DeleteByRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.DeleteByRefreshTokenFamilyExec(ctx, r.DB, familyID)
+}
+
+func (r SessionRepository) DeleteByRefreshTokenFamilyExec(ctx context.Context, exc Executor, familyID uuid.UUID) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "id" IN (
+			SELECT "session_id"
+			FROM "refresh_tokens"
+			WHERE "family_id" = $1 AND "session_id" IS NOT NULL
+		);
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
-	result, err := exc.ExecContext(ctx, query, args...)
+	_, err := exc.ExecContext(ctx, query, familyID)
 	if err != nil {
-		return err
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
+
+func (r SessionRepository) DeleteByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return r.DeleteByIDExec(ctx, r.DB, userID, id)
+}
+
+// DeleteByIDExec revokes a session, scoped to its user so one can't revoke the
+// sessions of someone else.
+func (r SessionRepository) DeleteByIDExec(ctx context.Context, exc Executor, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+		DELETE FROM "sessions"
+		WHERE "user_id" = $1 AND "id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, id)
+	if err != nil {
+		return errtrace.Wrap(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -363,33 +587,32 @@ func (r SessionRepository) updateExec(exc Executor, id uuid.UUID, session *model
 	}
 
 	if rowsAffected == 0 {
-		return ErrEditConflict
+		return ErrRecordNotFound
 	}
 
 	return nil
 }
 
-func (r SessionRepository) Delete(userID uuid.UUID, token string) error {
-	return r.deleteExec(r.DB, userID, token)
+func (r SessionRepository) DeleteOthers(ctx context.Context, userID uuid.UUID, keepID uuid.UUID) error {
+	return r.DeleteOthersExec(ctx, r.DB, userID, keepID)
 }
 
-func (r SessionRepository) deleteExec(exc Executor, userID uuid.UUID, token string) error {
+// DeleteOthersExec revokes every session of the user except keepID.
+func (r SessionRepository) DeleteOthersExec(ctx context.Context, exc Executor, userID uuid.UUID, keepID uuid.UUID) error {
 	query := `
 		DELETE FROM "sessions"
-		WHERE "user_id" = $1 AND "token" = $2;
+		WHERE "user_id" = $1 AND "id" <> $2;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	args := []any{userID, token}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
-	_, err := exc.ExecContext(ctx, query, args...)
+	_, err := exc.ExecContext(ctx, query, userID, keepID)
 	if err != nil {
 		return errtrace.Wrap(err)
 	}
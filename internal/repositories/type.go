@@ -3,6 +3,14 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
+
+	"gofi/internal/config"
+
+	"gofi/internal/lib/filter"
+
+	"github.com/google/uuid"
 )
 
 type Executor interface {
@@ -18,10 +26,66 @@ type QueryOptions struct {
 	Offset int64
 	Limit  int64
 
-	OrderBy string
-	Order   string // asc | desc
+	// After and Before are cursors of a keyset paginated list, Offset is
+	// ignored when one is set.
+	After  string
+	Before string
+
+	// SkipTotal doesn't count the rows, PaginationMetadata.Total stays 0.
+	SkipTotal bool
+
+	// Filter narrows and sorts the rows, it is parsed against the whitelist
+	// of the resource so its columns are safe to put in the query.
+	Filter filter.Query
+
+	// Tenant scopes the rows to the members of an organization.
+	Tenant Tenant
+}
+
+// Tenant scopes queries to the members of an organization. The zero value
+// matches no rows, only Global lifts the scope.
+type Tenant struct {
+	OrganizationID uuid.UUID
+	// Global is set for the callers granted constant.PermissionGlobalAccess
+	// without an active organization.
+	Global bool
+}
+
+// where is the condition keeping the rows whose userColumn is a member of the
+// organization, it is empty for global tenants. The organization is bound to
+// $argIndex.
+func (t Tenant) where(userColumn string, argIndex int) (string, []any) {
+	if t.Global {
+		return "", nil
+	}
+
+	condition := fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM "organization_members" "om"
+		WHERE "om"."user_id" = %s AND "om"."organization_id" = $%d
+	)`, userColumn, argIndex)
+
+	return condition, []any{t.OrganizationID}
 }
 
 type PaginationMetadata struct {
 	Total int64 `json:"total"`
+	// NextCursor and PrevCursor are empty when there is no page on that
+	// side, or when the list can't be cursor paginated.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// defaultQueryTimeout bounds the queries of repositories built without a
+// config, e.g. by the seeders.
+const defaultQueryTimeout = 3 * time.Second
+
+// withTimeout bounds a query by config.ConfigDB.QueryTimeout, the deadline
+// and cancellation of ctx still apply.
+func withTimeout(ctx context.Context, cfg *config.Config) (context.Context, context.CancelFunc) {
+	timeout := defaultQueryTimeout
+	if cfg != nil && cfg.DB.QueryTimeout > 0 {
+		timeout = cfg.DB.QueryTimeout
+	}
+
+	return context.WithTimeout(ctx, timeout)
 }
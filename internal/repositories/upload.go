@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+var uploadSchema = Schema[models.Upload]{
+	Table: "uploads",
+	Columns: append(
+		BaseColumns(func(upload *models.Upload) *models.Base { return &upload.Base }),
+		Column[models.Upload]{Name: "user_id", Field: func(upload *models.Upload) any { return &upload.UserID }, InsertOnly: true},
+		Column[models.Upload]{Name: "key_file", Field: func(upload *models.Upload) any { return &upload.KeyFile }},
+		Column[models.Upload]{Name: "file_name", Field: func(upload *models.Upload) any { return &upload.FileName }},
+		Column[models.Upload]{Name: "mimetype", Field: func(upload *models.Upload) any { return &upload.MimeType }},
+		Column[models.Upload]{Name: "size", Field: func(upload *models.Upload) any { return &upload.Size }},
+		Column[models.Upload]{Name: "signed_url", Field: func(upload *models.Upload) any { return &upload.SignedURL }},
+		Column[models.Upload]{Name: "expires_at", Field: func(upload *models.Upload) any { return &upload.ExpiresAt }},
+		Column[models.Upload]{Name: "status", Field: func(upload *models.Upload) any { return &upload.Status }},
+		Column[models.Upload]{Name: "multipart_upload_id", Field: func(upload *models.Upload) any { return &upload.MultipartUploadID }},
+	),
+	SoftDelete: true,
+	OrderBy:    "created_at",
+	Order:      "DESC",
+}
+
+type UploadRepository struct {
+	Repository[models.Upload]
+}
+
+func NewUploadRepository(db *sql.DB, config *config.Config) UploadRepository {
+	return UploadRepository{Repository: NewRepository(db, config, uploadSchema)}
+}
+
+// nullUpload scans the columns of an upload that may be missing from a LEFT
+// JOIN.
+type nullUpload struct {
+	ID        uuid.NullUUID
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+	UserID    uuid.NullUUID
+	KeyFile   sql.NullString
+	FileName  sql.NullString
+	MimeType  sql.NullString
+	Size      sql.NullInt64
+	SignedURL sql.NullString
+	ExpiresAt sql.NullTime
+	Status    sql.NullString
+}
+
+// Upload returns nil when the join found no upload.
+func (n nullUpload) Upload() *models.Upload {
+	if !n.ID.Valid {
+		return nil
+	}
+
+	upload := &models.Upload{
+		Base: models.Base{
+			ID:        n.ID.UUID,
+			CreatedAt: n.CreatedAt.Time,
+			UpdatedAt: n.UpdatedAt.Time,
+		},
+		KeyFile:   n.KeyFile.String,
+		FileName:  n.FileName.String,
+		MimeType:  n.MimeType.String,
+		Size:      n.Size.Int64,
+		SignedURL: n.SignedURL.String,
+		ExpiresAt: n.ExpiresAt.Time,
+		Status:    n.Status.String,
+	}
+
+	if n.UserID.Valid {
+		upload.UserID = &n.UserID.UUID
+	}
+
+	return upload
+}
+
+func (r UploadRepository) UpdateSignedURL(ctx context.Context, id uuid.UUID, signedURL string, expiresAt time.Time) error {
+	return r.updateSignedURLExec(ctx, r.DB, id, signedURL, expiresAt)
+}
+
+func (r UploadRepository) updateSignedURLExec(ctx context.Context, exc Executor, id uuid.UUID, signedURL string, expiresAt time.Time) error {
+	query := `
+		UPDATE "uploads"
+		SET "signed_url" = $1, "expires_at" = $2, "updated_at" = now()
+		WHERE "id" = $3;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, signedURL, expiresAt, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+// MarkReady stores the signed url of a completed upload, ErrEditConflict
+// is returned when the upload isn't pending anymore.
+func (r UploadRepository) MarkReady(ctx context.Context, id uuid.UUID, signedURL string, expiresAt time.Time) error {
+	return r.markReadyExec(ctx, r.DB, id, signedURL, expiresAt)
+}
+
+func (r UploadRepository) markReadyExec(ctx context.Context, exc Executor, id uuid.UUID, signedURL string, expiresAt time.Time) error {
+	query := `
+		UPDATE "uploads"
+		SET "status" = $1, "signed_url" = $2, "expires_at" = $3, "multipart_upload_id" = NULL, "updated_at" = now()
+		WHERE "id" = $4 AND "status" = $5;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, models.UploadStatusReady, signedURL, expiresAt, id, models.UploadStatusPending)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
@@ -5,70 +5,135 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
+	"gofi/internal/config"
+	"gofi/internal/lib/filter"
 	"gofi/internal/models"
 
 	"braces.dev/errtrace"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
 )
 
+var userSchema = Schema[models.User]{
+	Table: "users",
+	Columns: append(
+		BaseColumns(func(user *models.User) *models.Base { return &user.Base }),
+		Column[models.User]{Name: "first_name", Field: func(user *models.User) any { return &user.FirstName }},
+		Column[models.User]{Name: "last_name", Field: func(user *models.User) any { return &user.LastName }},
+		Column[models.User]{Name: "email", Field: func(user *models.User) any { return &user.Email }},
+		Column[models.User]{Name: "phone", Field: func(user *models.User) any { return &user.Phone }},
+		Column[models.User]{Name: "password", Field: func(user *models.User) any { return &user.Password }, InsertOnly: true},
+		Column[models.User]{Name: "active_at", Field: func(user *models.User) any { return &user.ActiveAt }},
+		Column[models.User]{Name: "blocked_at", Field: func(user *models.User) any { return &user.BlockedAt }},
+		Column[models.User]{Name: "role_id", Field: func(user *models.User) any { return &user.RoleID }},
+		Column[models.User]{Name: "upload_id", Field: func(user *models.User) any { return &user.UploadID }},
+	),
+	SoftDelete: true,
+	OrderBy:    "created_at",
+	Order:      "DESC",
+}
+
+// UserRepository gets its writes from Repository, its reads join the role
+// and the avatar.
 type UserRepository struct {
-	BaseRepository
+	Repository[models.User]
+}
+
+func NewUserRepository(db *sql.DB, config *config.Config) UserRepository {
+	return UserRepository{Repository: NewRepository(db, config, userSchema)}
 }
 
-func (r UserRepository) Count() (int64, error) {
-	return r.BaseRepository.countExec(r.DB)
+func (r UserRepository) List(ctx context.Context, opts *QueryOptions) ([]*models.User, PaginationMetadata, error) {
+	return r.listExec(ctx, r.DB, opts)
 }
 
-func (r UserRepository) List(opts *QueryOptions) ([]*models.User, PaginationMetadata, error) {
-	return r.listExec(r.DB, opts)
+// UserFilterFields is the whitelist of the filters and sorts of List.
+var UserFilterFields = filter.Fields{
+	"first_name": {Column: `"u"."first_name"`, Type: filter.String, Operators: []filter.Operator{filter.Eq, filter.Like, filter.Ilike}, Sortable: true},
+	"last_name":  {Column: `"u"."last_name"`, Type: filter.String, Operators: []filter.Operator{filter.Eq, filter.Like, filter.Ilike, filter.Null}, Sortable: true, Nullable: true},
+	"email":      {Column: `"u"."email"`, Type: filter.String, Operators: []filter.Operator{filter.Eq, filter.Ne, filter.Like, filter.Ilike, filter.In}, Sortable: true},
+	"phone":      {Column: `"u"."phone"`, Type: filter.String, Operators: []filter.Operator{filter.Eq, filter.Like, filter.Null}},
+	"role_id":    {Column: `"u"."role_id"`, Type: filter.UUID, Operators: []filter.Operator{filter.Eq, filter.Ne, filter.In}},
+	"created_at": {Column: `"u"."created_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte}, Sortable: true},
+	"updated_at": {Column: `"u"."updated_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte}, Sortable: true},
+	"active_at":  {Column: `"u"."active_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte, filter.Null}, Sortable: true, Nullable: true},
+	"blocked_at": {Column: `"u"."blocked_at"`, Type: filter.Time, Operators: []filter.Operator{filter.Gt, filter.Gte, filter.Lt, filter.Lte, filter.Null}},
 }
 
-func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.User, PaginationMetadata, error) {
+// listWhere builds the WHERE clause of List, placeholders start at $1.
+func (r UserRepository) listWhere(opts *QueryOptions) (string, []any) {
+	conditions := []string{`"u"."deleted_at" IS NULL`}
+	var args []any
+
+	if condition, tenantArgs := opts.Tenant.where(`"u"."id"`, len(args)+1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, tenantArgs...)
+	}
+
+	filters, filterArgs := opts.Filter.Where(len(args) + 1)
+	conditions = append(conditions, filters...)
+	args = append(args, filterArgs...)
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r UserRepository) listExec(ctx context.Context, exc Executor, opts *QueryOptions) ([]*models.User, PaginationMetadata, error) {
+	keyset, err := newKeyset(opts, `"u"."id"`, filter.Sort{Column: `"u"."created_at"`, Desc: true})
+	if err != nil {
+		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+	}
+
+	where, args := r.listWhere(opts)
+	argIndex := len(args) + 1
+
 	selectFields := `"u"."id", "u"."created_at", "u"."updated_at", "u"."deleted_at", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."active_at", "u"."blocked_at", "u"."role_id", "u"."upload_id"`
 	selectRoleFields := `"r"."id", "r"."name", "r"."created_at", "r"."updated_at"`
+
+	selectKey := ""
+	if keyset != nil {
+		selectKey = ", " + keyset.selectKey()
+	}
+
 	baseQuery := fmt.Sprintf(`
-		SELECT %s, %s
+		SELECT %s, %s%s
 		FROM "users" "u"
-		LEFT JOIN "roles" "r" ON "u"."role_id" = "r"."id"
-		WHERE "u"."deleted_at" IS NULL
-	`, selectFields, selectRoleFields)
-
-	var args []any
-	argIndex := 1
+		LEFT JOIN "roles" "r" ON "u"."role_id" = "r"."id"%s
+	`, selectFields, selectRoleFields, selectKey, where)
 
 	var queryBuilder strings.Builder
 	queryBuilder.WriteString(baseQuery)
 
-	orderBy := `"u"."created_at"`
-	order := "DESC"
-
-	if opts.OrderBy != "" {
-		orderBy = opts.OrderBy
+	if keyset != nil && keyset.cursor != nil {
+		condition, keysetArgs := keyset.where(argIndex)
+		queryBuilder.WriteString(" AND " + condition)
+		args = append(args, keysetArgs...)
+		argIndex += len(keysetArgs)
 	}
 
-	if opts.Order != "" {
-		upperOrder := strings.ToUpper(opts.Order)
-		if upperOrder != "ASC" && upperOrder != "DESC" {
-			return nil, PaginationMetadata{}, errtrace.New("invalid order")
-		}
-		order = upperOrder
+	orderBy := opts.Filter.OrderBy()
+	if keyset != nil {
+		orderBy = keyset.orderBy()
+	} else if orderBy == "" {
+		orderBy = `"u"."created_at" DESC`
 	}
 
-	queryBuilder.WriteString(fmt.Sprintf(" ORDER BY %s %s", orderBy, order))
+	queryBuilder.WriteString(" ORDER BY " + orderBy)
 
 	if opts.Limit > 0 {
+		limit := opts.Limit
+		// one more row tells whether there is a next page
+		if keyset != nil {
+			limit++
+		}
+
 		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
-		args = append(args, opts.Limit)
+		args = append(args, limit)
 		argIndex++
 	}
 
-	if opts.Offset > 0 {
+	if opts.Offset > 0 && (keyset == nil || keyset.cursor == nil) {
 		queryBuilder.WriteString(fmt.Sprintf(" OFFSET $%d", argIndex))
 		args = append(args, opts.Offset)
 		argIndex++
@@ -76,12 +141,12 @@ func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Us
 
 	query := queryBuilder.String()
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	rows, err := exc.QueryContext(ctx, query, args...)
@@ -91,11 +156,13 @@ func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Us
 	defer rows.Close()
 
 	var users []*models.User
+	var ids []uuid.UUID
+	var keys []string
 	for rows.Next() {
 		user := &models.User{}
 		role := &models.Role{}
 
-		err = rows.Scan(
+		dest := []any{
 			&user.ID,
 			&user.CreatedAt,
 			&user.UpdatedAt,
@@ -112,48 +179,101 @@ func (r UserRepository) listExec(exc Executor, opts *QueryOptions) ([]*models.Us
 			&role.Name,
 			&role.CreatedAt,
 			&role.UpdatedAt,
-		)
-		if err != nil {
+		}
+
+		var key string
+		if keyset != nil {
+			dest = append(dest, &key)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
 			return nil, PaginationMetadata{}, errtrace.Errorf("error scanning row: %w", err)
 		}
 
 		user.Role = role
 		users = append(users, user)
+		ids = append(ids, user.ID)
+		keys = append(keys, key)
+	}
+
+	var meta PaginationMetadata
+	if keyset != nil {
+		users, meta = page(*keyset, opts, users, ids, keys)
+	}
+
+	if !opts.SkipTotal {
+		meta.Total, err = r.countListExec(ctx, exc, opts)
+		if err != nil {
+			return nil, PaginationMetadata{}, errtrace.Wrap(err)
+		}
 	}
 
-	count, err := r.Count()
+	return users, meta, nil
+}
+
+// countListExec counts the users List can return for opts, without the
+// limit and offset.
+func (r UserRepository) countListExec(ctx context.Context, exc Executor, opts *QueryOptions) (int64, error) {
+	where, args := r.listWhere(opts)
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM "users" "u"%s;
+	`, where)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	var count int64
+	err := exc.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
-		return nil, PaginationMetadata{}, errtrace.Wrap(err)
+		return 0, errtrace.Errorf("error scanning row: %w", err)
 	}
 
-	return users, PaginationMetadata{Total: count}, nil
+	return count, nil
 }
 
-func (r UserRepository) Get(id uuid.UUID) (*models.User, error) {
-	return r.getExec(r.DB, id)
+func (r UserRepository) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.getExec(ctx, r.DB, id, Tenant{Global: true})
 }
 
-func (r UserRepository) getExec(exc Executor, id uuid.UUID) (*models.User, error) {
+// GetInTenant only finds the user when they are a member of the tenant.
+func (r UserRepository) GetInTenant(ctx context.Context, id uuid.UUID, tenant Tenant) (*models.User, error) {
+	return r.getExec(ctx, r.DB, id, tenant)
+}
+
+func (r UserRepository) getExec(ctx context.Context, exc Executor, id uuid.UUID, tenant Tenant) (*models.User, error) {
 	selectFields := `"u"."id", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."active_at", "u"."blocked_at", "u"."role_id", "u"."upload_id", "u"."created_at", "u"."updated_at"`
 	selectRoleFields := `"r"."id", "r"."name", "r"."created_at", "r"."updated_at"`
+	selectUploadFields := `"up"."id", "up"."created_at", "up"."updated_at", "up"."user_id", "up"."key_file", "up"."file_name", "up"."mimetype", "up"."size", "up"."signed_url", "up"."expires_at", "up"."status"`
 	query := fmt.Sprintf(`
-		SELECT %s, %s
+		SELECT %s, %s, %s
 		FROM "users" "u"
 		LEFT JOIN "roles" "r" ON "u"."role_id" = "r"."id"
-		WHERE "u"."id" = $1 AND "u"."deleted_at" IS NULL;
-	`, selectFields, selectRoleFields)
-
-	if r.Config != nil && r.Config.Debug {
+		LEFT JOIN "uploads" "up" ON "u"."upload_id" = "up"."id" AND "up"."deleted_at" IS NULL
+		WHERE "u"."id" = $1 AND "u"."deleted_at" IS NULL AND ($2 OR EXISTS (
+			SELECT 1 FROM "organization_members" "om"
+			WHERE "om"."user_id" = "u"."id" AND "om"."organization_id" = $3
+		));
+	`, selectFields, selectRoleFields, selectUploadFields)
+
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	user := &models.User{}
 	role := &models.Role{}
-	err := exc.QueryRowContext(ctx, query, id).Scan(
+	upload := nullUpload{}
+	err := exc.QueryRowContext(ctx, query, id, tenant.Global, tenant.OrganizationID).Scan(
 		&user.ID,
 		&user.FirstName,
 		&user.LastName,
@@ -169,6 +289,17 @@ func (r UserRepository) getExec(exc Executor, id uuid.UUID) (*models.User, error
 		&role.Name,
 		&role.CreatedAt,
 		&role.UpdatedAt,
+		&upload.ID,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+		&upload.UserID,
+		&upload.KeyFile,
+		&upload.FileName,
+		&upload.MimeType,
+		&upload.Size,
+		&upload.SignedURL,
+		&upload.ExpiresAt,
+		&upload.Status,
 	)
 	if err != nil {
 		switch {
@@ -180,14 +311,15 @@ func (r UserRepository) getExec(exc Executor, id uuid.UUID) (*models.User, error
 	}
 
 	user.Role = role
+	user.Upload = upload.Upload()
 	return user, nil
 }
 
-func (r UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
-	return r.getByIDExec(r.DB, id)
+func (r UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.getByIDExec(ctx, r.DB, id)
 }
 
-func (r UserRepository) getByIDExec(exc Executor, id uuid.UUID) (*models.User, error) {
+func (r UserRepository) getByIDExec(ctx context.Context, exc Executor, id uuid.UUID) (*models.User, error) {
 	query := `
 		SELECT "u"."id", "u"."email", "u"."active_at", "u"."blocked_at", "u"."role_id"
 		FROM "users" AS "u"
@@ -197,12 +329,12 @@ func (r UserRepository) getByIDExec(exc Executor, id uuid.UUID) (*models.User, e
 					"u"."deleted_at" IS NULL;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	user := &models.User{}
@@ -225,11 +357,11 @@ func (r UserRepository) getByIDExec(exc Executor, id uuid.UUID) (*models.User, e
 	return user, nil
 }
 
-func (r UserRepository) GetByEmail(email string) (*models.User, error) {
-	return r.getByEmailExec(r.DB, email)
+func (r UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.getByEmailExec(ctx, r.DB, email)
 }
 
-func (r UserRepository) getByEmailExec(exc Executor, email string) (*models.User, error) {
+func (r UserRepository) getByEmailExec(ctx context.Context, exc Executor, email string) (*models.User, error) {
 	query := `
 		SELECT "u"."id", "u"."created_at", "u"."updated_at", "u"."deleted_at", "u"."first_name", "u"."last_name", "u"."email", "u"."phone", "u"."password", "u"."active_at", "u"."blocked_at", "u"."role_id", "u"."upload_id"
 		FROM "users" AS "u"
@@ -239,12 +371,12 @@ func (r UserRepository) getByEmailExec(exc Executor, email string) (*models.User
 				"u"."deleted_at" IS NULL;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	user := &models.User{}
@@ -275,136 +407,74 @@ func (r UserRepository) getByEmailExec(exc Executor, email string) (*models.User
 	return user, nil
 }
 
-func (r UserRepository) Insert(users ...*models.User) error {
-	for _, user := range users {
-		if user.Password != nil {
-			if err := user.BeforeCreate(); err != nil {
-				return errtrace.Wrap(err)
-			}
-		}
-	}
-	return r.InsertExec(r.DB, users...)
+// HasPassword reports whether the user can sign in with a password, users
+// created from an OAuth identity have none.
+func (r UserRepository) HasPassword(ctx context.Context, id uuid.UUID) (bool, error) {
+	return r.hasPasswordExec(ctx, r.DB, id)
 }
 
-func (r UserRepository) InsertExec(exc Executor, users ...*models.User) error {
-	if len(users) == 0 {
-		return nil
-	}
-
-	columns := []string{
-		"id",
-		"first_name",
-		"last_name",
-		"email",
-		"phone",
-		"password",
-		"active_at",
-		"blocked_at",
-		"role_id",
-		"upload_id",
-	}
-
-	valueStrings := make([]string, 0, len(users))
-	valueArgs := make([]any, 0, len(users)*len(columns))
-
-	for i, user := range users {
-		values := []any{
-			user.ID,
-			user.FirstName,
-			user.LastName,
-			user.Email,
-			user.Phone,
-			user.Password,
-			user.ActiveAt,
-			user.BlockedAt,
-			user.RoleID,
-			user.UploadID,
-		}
-
-		placeholders := make([]string, 0, len(values))
-		for j := range columns {
-			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
-		}
-
-		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
-		valueArgs = append(valueArgs, values...)
-	}
-
-	query := fmt.Sprintf(`
-		INSERT INTO "users" (%s)
-		VALUES %s
-		RETURNING "id", "created_at", "updated_at";
-	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+func (r UserRepository) hasPasswordExec(ctx context.Context, exc Executor, id uuid.UUID) (bool, error) {
+	query := `
+		SELECT "u"."password" IS NOT NULL
+		FROM "users" AS "u"
+		WHERE "u"."id" = $1 AND "u"."deleted_at" IS NULL;
+	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
-	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	var hasPassword bool
+	err := exc.QueryRowContext(ctx, query, id).Scan(&hasPassword)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23505" {
-				return errtrace.Wrap(ErrInsertDuplicate)
-			}
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, errtrace.Wrap(ErrRecordNotFound)
+		default:
+			return false, errtrace.Errorf("error scanning row: %w", err)
 		}
-		return errtrace.Wrap(err)
 	}
-	defer rows.Close()
 
-	for _, user := range users {
-		if !rows.Next() {
-			return errtrace.New("error scanning row: no next row")
-		}
+	return hasPassword, nil
+}
 
-		if err := rows.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt); err != nil {
-			return errtrace.Errorf("error scanning row: %w", err)
+func (r UserRepository) Insert(ctx context.Context, users ...*models.User) error {
+	for _, user := range users {
+		if user.Password != nil {
+			if err := user.BeforeCreate(); err != nil {
+				return errtrace.Wrap(err)
+			}
 		}
 	}
-
-	return nil
+	return r.InsertExec(ctx, r.DB, users...)
 }
 
-func (r UserRepository) Update(id uuid.UUID, user *models.User) error {
-	return r.UpdateExec(r.DB, id, user)
+func (r UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, password string) error {
+	return r.UpdatePasswordExec(ctx, r.DB, id, password)
 }
 
-func (r UserRepository) UpdateExec(exc Executor, id uuid.UUID, user *models.User) error {
+func (r UserRepository) UpdatePasswordExec(ctx context.Context, exc Executor, id uuid.UUID, password string) error {
 	query := `
 		UPDATE "users"
-		SET "first_name" = $1,
-				"last_name" = $2,
-				"email" = $3,
-				"phone" = $4,
-				"active_at" = $5,
-				"blocked_at" = $6,
-				"role_id" = $7,
-				"upload_id" = $8,
-				"updated_at" = now()
-		WHERE "id" = $9;
+		SET "password" = $1, "updated_at" = now()
+		WHERE "id" = $2 AND "deleted_at" IS NULL;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
 	args := []any{
-		user.FirstName,
-		user.LastName,
-		user.Email,
-		user.Phone,
-		user.ActiveAt,
-		user.BlockedAt,
-		user.RoleID,
-		user.UploadID,
+		password,
 		id,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	result, err := exc.ExecContext(ctx, query, args...)
@@ -423,15 +493,3 @@ func (r UserRepository) UpdateExec(exc Executor, id uuid.UUID, user *models.User
 
 	return nil
 }
-
-func (r UserRepository) Delete(id uuid.UUID) error {
-	return r.BaseRepository.deleteExec(r.DB, id)
-}
-
-func (r UserRepository) SoftDelete(id uuid.UUID) error {
-	return r.BaseRepository.softDeleteExec(r.DB, id)
-}
-
-func (r UserRepository) Restore(id uuid.UUID) error {
-	return r.BaseRepository.restoreExec(r.DB, id)
-}
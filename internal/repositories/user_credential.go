@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type UserCredentialRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+const userCredentialColumns = `"id", "created_at", "updated_at", "user_id", "name", "credential_id", "public_key", "attestation_type", "transports", "aaguid", "sign_count", "user_present", "user_verified", "backup_eligible", "backup_state", "last_used_at"`
+
+func scanUserCredential(row interface{ Scan(dest ...any) error }, credential *models.UserCredential) error {
+	return row.Scan(
+		&credential.ID,
+		&credential.CreatedAt,
+		&credential.UpdatedAt,
+		&credential.UserID,
+		&credential.Name,
+		&credential.CredentialID,
+		&credential.PublicKey,
+		&credential.AttestationType,
+		&credential.Transports,
+		&credential.AAGUID,
+		&credential.SignCount,
+		&credential.UserPresent,
+		&credential.UserVerified,
+		&credential.BackupEligible,
+		&credential.BackupState,
+		&credential.LastUsedAt,
+	)
+}
+
+func (r UserCredentialRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserCredential, error) {
+	return r.listByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r UserCredentialRepository) listByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.UserCredential, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM "user_credentials"
+		WHERE "user_id" = $1
+		ORDER BY "created_at" ASC;
+	`, userCredentialColumns)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []*models.UserCredential{}
+	for rows.Next() {
+		credential := &models.UserCredential{}
+		if err := scanUserCredential(rows, credential); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, nil
+}
+
+func (r UserCredentialRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.UserCredential, error) {
+	return r.getByCredentialIDExec(ctx, r.DB, credentialID)
+}
+
+func (r UserCredentialRepository) getByCredentialIDExec(ctx context.Context, exc Executor, credentialID []byte) (*models.UserCredential, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM "user_credentials"
+		WHERE "credential_id" = $1;
+	`, userCredentialColumns)
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	credential := &models.UserCredential{}
+	err := scanUserCredential(exc.QueryRowContext(ctx, query, credentialID), credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return credential, nil
+}
+
+func (r UserCredentialRepository) Insert(ctx context.Context, credentials ...*models.UserCredential) error {
+	return r.insertExec(ctx, r.DB, credentials...)
+}
+
+func (r UserCredentialRepository) insertExec(ctx context.Context, exc Executor, credentials ...*models.UserCredential) error {
+	if len(credentials) == 0 {
+		return nil
+	}
+
+	columns := []string{
+		"id", "user_id", "name", "credential_id", "public_key", "attestation_type", "transports",
+		"aaguid", "sign_count", "user_present", "user_verified", "backup_eligible", "backup_state",
+	}
+
+	valueStrings := make([]string, 0, len(credentials))
+	valueArgs := make([]any, 0, len(credentials)*len(columns))
+
+	for i, credential := range credentials {
+		values := []any{
+			credential.ID,
+			credential.UserID,
+			credential.Name,
+			credential.CredentialID,
+			credential.PublicKey,
+			credential.AttestationType,
+			credential.Transports,
+			credential.AAGUID,
+			credential.SignCount,
+			credential.UserPresent,
+			credential.UserVerified,
+			credential.BackupEligible,
+			credential.BackupState,
+		}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "user_credentials" (%s)
+		VALUES %s
+		RETURNING "id", "created_at", "updated_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, credential := range credentials {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&credential.ID, &credential.CreatedAt, &credential.UpdatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r UserCredentialRepository) UpdateSignCount(ctx context.Context, id uuid.UUID, credential *models.UserCredential) error {
+	return r.updateSignCountExec(ctx, r.DB, id, credential)
+}
+
+// updateSignCountExec stores the signature counter of a successful assertion.
+// The counter must grow, unless the authenticator doesn't implement it and
+// always reports 0, otherwise ErrEditConflict is returned since the same
+// assertion was accepted concurrently.
+func (r UserCredentialRepository) updateSignCountExec(ctx context.Context, exc Executor, id uuid.UUID, credential *models.UserCredential) error {
+	query := `
+		UPDATE "user_credentials"
+		SET "sign_count" = $1, "backup_state" = $2, "last_used_at" = now(), "updated_at" = now()
+		WHERE "id" = $3 AND ("sign_count" < $1 OR ("sign_count" = 0 AND $1 = 0));
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	args := []any{
+		credential.SignCount,
+		credential.BackupState,
+		id,
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r UserCredentialRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return r.deleteExec(ctx, r.DB, userID, id)
+}
+
+func (r UserCredentialRepository) deleteExec(ctx context.Context, exc Executor, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+		DELETE FROM "user_credentials"
+		WHERE "user_id" = $1 AND "id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, userID, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type UserMFARepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+func (r UserMFARepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error) {
+	return r.GetByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r UserMFARepository) GetByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) (*models.UserMFA, error) {
+	query := `
+		SELECT "id", "created_at", "updated_at", "user_id", "secret", "last_used_step", "confirmed_at"
+		FROM "user_mfas"
+		WHERE "user_id" = $1;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	mfa := &models.UserMFA{}
+	err := exc.QueryRowContext(ctx, query, userID).Scan(
+		&mfa.ID,
+		&mfa.CreatedAt,
+		&mfa.UpdatedAt,
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.LastUsedStep,
+		&mfa.ConfirmedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return mfa, nil
+}
+
+func (r UserMFARepository) Insert(ctx context.Context, mfas ...*models.UserMFA) error {
+	return r.InsertExec(ctx, r.DB, mfas...)
+}
+
+func (r UserMFARepository) InsertExec(ctx context.Context, exc Executor, mfas ...*models.UserMFA) error {
+	if len(mfas) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "user_id", "secret", "last_used_step", "confirmed_at"}
+
+	valueStrings := make([]string, 0, len(mfas))
+	valueArgs := make([]any, 0, len(mfas)*len(columns))
+
+	for i, mfa := range mfas {
+		values := []any{mfa.ID, mfa.UserID, mfa.Secret, mfa.LastUsedStep, mfa.ConfirmedAt}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "user_mfas" (%s)
+		VALUES %s
+		RETURNING "id", "created_at", "updated_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, mfa := range mfas {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&mfa.ID, &mfa.CreatedAt, &mfa.UpdatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r UserMFARepository) Update(ctx context.Context, id uuid.UUID, mfa *models.UserMFA) error {
+	return r.UpdateExec(ctx, r.DB, id, mfa)
+}
+
+func (r UserMFARepository) UpdateExec(ctx context.Context, exc Executor, id uuid.UUID, mfa *models.UserMFA) error {
+	query := `
+		UPDATE "user_mfas"
+		SET "secret" = $1, "last_used_step" = $2, "confirmed_at" = $3, "updated_at" = now()
+		WHERE "id" = $4;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	args := []any{
+		mfa.Secret,
+		mfa.LastUsedStep,
+		mfa.ConfirmedAt,
+		id,
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r UserMFARepository) UseStep(ctx context.Context, id uuid.UUID, step int64) error {
+	return r.UseStepExec(ctx, r.DB, id, step)
+}
+
+// UseStepExec records the TOTP step of an accepted code. The step only moves
+// forward, ErrEditConflict is returned when it or a later one was already
+// used so concurrent requests can't replay the same code.
+func (r UserMFARepository) UseStepExec(ctx context.Context, exc Executor, id uuid.UUID, step int64) error {
+	query := `
+		UPDATE "user_mfas"
+		SET "last_used_step" = $1, "updated_at" = now()
+		WHERE "id" = $2 AND "last_used_step" < $1;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, step, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r UserMFARepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.DeleteByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r UserMFARepository) DeleteByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "user_mfas"
+		WHERE "user_id" = $1;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
@@ -7,44 +7,56 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"gofi/internal/config"
 	"gofi/internal/models"
 
 	"braces.dev/errtrace"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
 )
 
 type UserOAuthRepository struct {
 	DB     *sql.DB
-	Config *config.ConfigApp
+	Config *config.Config
 }
 
-func (r UserOAuthRepository) GetByUserProvider(userID uuid.UUID, provider string) (*models.UserOAuth, error) {
-	return r.GetByUserProviderExec(r.DB, userID, provider)
+func (r UserOAuthRepository) GetByUserProvider(ctx context.Context, userID uuid.UUID, provider string) (*models.UserOAuth, error) {
+	return r.GetByUserProviderExec(ctx, r.DB, userID, provider)
 }
 
-func (r UserOAuthRepository) GetByUserProviderExec(exc Executor, userID uuid.UUID, provider string) (*models.UserOAuth, error) {
-	query := `
+func (r UserOAuthRepository) GetByUserProviderExec(ctx context.Context, exc Executor, userID uuid.UUID, provider string) (*models.UserOAuth, error) {
+	return r.getExec(ctx, exc, `"user_id" = $1 AND "provider" = $2`, userID, provider)
+}
+
+// GetByProviderIdentity returns the identity of a provider account, whichever
+// user it is linked to.
+func (r UserOAuthRepository) GetByProviderIdentity(ctx context.Context, provider string, identityID string) (*models.UserOAuth, error) {
+	return r.GetByProviderIdentityExec(ctx, r.DB, provider, identityID)
+}
+
+func (r UserOAuthRepository) GetByProviderIdentityExec(ctx context.Context, exc Executor, provider string, identityID string) (*models.UserOAuth, error) {
+	return r.getExec(ctx, exc, `"provider" = $1 AND "identity_id" = $2`, provider, identityID)
+}
+
+func (r UserOAuthRepository) getExec(ctx context.Context, exc Executor, condition string, args ...any) (*models.UserOAuth, error) {
+	query := fmt.Sprintf(`
 		SELECT "id", "user_id", "identity_id", "provider", "access_token", "refresh_token", "expires_at"
 		FROM "user_oauths"
-		WHERE "user_id" = $1 AND "provider" = $2
+		WHERE %s
 		LIMIT 1;
-	`
+	`, condition)
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	userOAuth := &models.UserOAuth{}
-	err := exc.QueryRowContext(ctx, query, userID, provider).Scan(
+	err := exc.QueryRowContext(ctx, query, args...).Scan(
 		&userOAuth.ID,
 		&userOAuth.UserID,
 		&userOAuth.IdentityID,
@@ -65,11 +77,59 @@ func (r UserOAuthRepository) GetByUserProviderExec(exc Executor, userID uuid.UUI
 	return userOAuth, nil
 }
 
-func (r UserOAuthRepository) Insert(usersOAuths ...*models.UserOAuth) error {
-	return r.InsertExec(r.DB, usersOAuths...)
+func (r UserOAuthRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserOAuth, error) {
+	return r.ListByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r UserOAuthRepository) ListByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.UserOAuth, error) {
+	query := `
+		SELECT "id", "user_id", "identity_id", "provider", "access_token", "refresh_token", "expires_at"
+		FROM "user_oauths"
+		WHERE "user_id" = $1
+		ORDER BY "provider" ASC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	userOAuths := []*models.UserOAuth{}
+	for rows.Next() {
+		userOAuth := &models.UserOAuth{}
+		err := rows.Scan(
+			&userOAuth.ID,
+			&userOAuth.UserID,
+			&userOAuth.IdentityID,
+			&userOAuth.Provider,
+			&userOAuth.AccessToken,
+			&userOAuth.RefreshToken,
+			&userOAuth.ExpiresAt,
+		)
+		if err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+
+		userOAuths = append(userOAuths, userOAuth)
+	}
+
+	return userOAuths, nil
 }
 
-func (r UserOAuthRepository) InsertExec(exc Executor, usersOAuths ...*models.UserOAuth) error {
+func (r UserOAuthRepository) Insert(ctx context.Context, usersOAuths ...*models.UserOAuth) error {
+	return r.InsertExec(ctx, r.DB, usersOAuths...)
+}
+
+func (r UserOAuthRepository) InsertExec(ctx context.Context, exc Executor, usersOAuths ...*models.UserOAuth) error {
 	if len(usersOAuths) == 0 {
 		return nil
 	}
@@ -97,22 +157,17 @@ func (r UserOAuthRepository) InsertExec(exc Executor, usersOAuths ...*models.Use
 		RETURNING "id";
 	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	rows, err := exc.QueryContext(ctx, query, valueArgs...)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23505" {
-				return errtrace.Wrap(ErrInsertDuplicate)
-			}
-		}
-		return errtrace.Wrap(err)
+		return errtrace.Wrap(translateError(err))
 	}
 	defer rows.Close()
 
@@ -129,18 +184,18 @@ func (r UserOAuthRepository) InsertExec(exc Executor, usersOAuths ...*models.Use
 	return nil
 }
 
-func (r UserOAuthRepository) Update(id uuid.UUID, userOAuth *models.UserOAuth) error {
-	return r.UpdateExec(r.DB, id, userOAuth)
+func (r UserOAuthRepository) Update(ctx context.Context, id uuid.UUID, userOAuth *models.UserOAuth) error {
+	return r.UpdateExec(ctx, r.DB, id, userOAuth)
 }
 
-func (r UserOAuthRepository) UpdateExec(exc Executor, id uuid.UUID, userOAuth *models.UserOAuth) error {
+func (r UserOAuthRepository) UpdateExec(ctx context.Context, exc Executor, id uuid.UUID, userOAuth *models.UserOAuth) error {
 	query := `
 		UPDATE "user_oauths"
 		SET "user_id" = $1, "identity_id" = $2, "provider" = $3, "access_token" = $4, "refresh_token" = $5, "expires_at" = $6
 		WHERE "id" = $7;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
@@ -155,12 +210,12 @@ func (r UserOAuthRepository) UpdateExec(exc Executor, id uuid.UUID, userOAuth *m
 		id,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	result, err := exc.ExecContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -174,3 +229,40 @@ func (r UserOAuthRepository) UpdateExec(exc Executor, id uuid.UUID, userOAuth *m
 
 	return nil
 }
+
+func (r UserOAuthRepository) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return r.DeleteExec(ctx, r.DB, userID, id)
+}
+
+// DeleteExec unlinks an identity, scoped to its user so one can't unlink the
+// identities of someone else.
+func (r UserOAuthRepository) DeleteExec(ctx context.Context, exc Executor, userID uuid.UUID, id uuid.UUID) error {
+	query := `
+		DELETE FROM "user_oauths"
+		WHERE "id" = $1 AND "user_id" = $2;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
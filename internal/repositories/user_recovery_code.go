@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gofi/internal/config"
+	"gofi/internal/models"
+
+	"braces.dev/errtrace"
+	"github.com/google/uuid"
+	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
+)
+
+type UserRecoveryCodeRepository struct {
+	DB     *sql.DB
+	Config *config.Config
+}
+
+func (r UserRecoveryCodeRepository) ListUnusedByUserID(ctx context.Context, userID uuid.UUID) ([]*models.UserRecoveryCode, error) {
+	return r.ListUnusedByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r UserRecoveryCodeRepository) ListUnusedByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) ([]*models.UserRecoveryCode, error) {
+	query := `
+		SELECT "id", "created_at", "user_id", "code", "used_at"
+		FROM "user_recovery_codes"
+		WHERE "user_id" = $1 AND "used_at" IS NULL
+		ORDER BY "created_at" ASC;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errtrace.Errorf("error querying rows: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []*models.UserRecoveryCode
+	for rows.Next() {
+		code := &models.UserRecoveryCode{}
+		if err := rows.Scan(
+			&code.ID,
+			&code.CreatedAt,
+			&code.UserID,
+			&code.Code,
+			&code.UsedAt,
+		); err != nil {
+			return nil, errtrace.Errorf("error scanning row: %w", err)
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+func (r UserRecoveryCodeRepository) Insert(ctx context.Context, codes ...*models.UserRecoveryCode) error {
+	return r.InsertExec(ctx, r.DB, codes...)
+}
+
+func (r UserRecoveryCodeRepository) InsertExec(ctx context.Context, exc Executor, codes ...*models.UserRecoveryCode) error {
+	if len(codes) == 0 {
+		return nil
+	}
+
+	columns := []string{"id", "user_id", "code"}
+
+	valueStrings := make([]string, 0, len(codes))
+	valueArgs := make([]any, 0, len(codes)*len(columns))
+
+	for i, code := range codes {
+		values := []any{code.ID, code.UserID, code.Code}
+
+		placeholders := make([]string, 0, len(values))
+		for j := range columns {
+			placeholders = append(placeholders, "$"+strconv.Itoa(i*len(columns)+j+1))
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+		valueArgs = append(valueArgs, values...)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO "user_recovery_codes" (%s)
+		VALUES %s
+		RETURNING "id", "created_at";
+	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	rows, err := exc.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return errtrace.Wrap(translateError(err))
+	}
+	defer rows.Close()
+
+	for _, code := range codes {
+		if !rows.Next() {
+			return errtrace.New("error scanning row: no next row")
+		}
+
+		if err := rows.Scan(&code.ID, &code.CreatedAt); err != nil {
+			return errtrace.Errorf("error scanning row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ConsumeExec marks the recovery code as used, failing with ErrEditConflict
+// when it was already used by a concurrent request.
+func (r UserRecoveryCodeRepository) ConsumeExec(ctx context.Context, exc Executor, id uuid.UUID) error {
+	query := `
+		UPDATE "user_recovery_codes"
+		SET "used_at" = now()
+		WHERE "id" = $1 AND "used_at" IS NULL;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	result, err := exc.ExecContext(ctx, query, id)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrEditConflict
+	}
+
+	return nil
+}
+
+func (r UserRecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	return r.DeleteByUserIDExec(ctx, r.DB, userID)
+}
+
+func (r UserRecoveryCodeRepository) DeleteByUserIDExec(ctx context.Context, exc Executor, userID uuid.UUID) error {
+	query := `
+		DELETE FROM "user_recovery_codes"
+		WHERE "user_id" = $1;
+	`
+
+	if r.Config != nil && r.Config.App.Debug {
+		fmt.Println()
+		sqlfmt.PrettyPrint(query)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.Config)
+	defer cancel()
+
+	_, err := exc.ExecContext(ctx, query, userID)
+	if err != nil {
+		return errtrace.Wrap(err)
+	}
+
+	return nil
+}
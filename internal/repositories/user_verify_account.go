@@ -7,39 +7,37 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"gofi/internal/config"
 	"gofi/internal/models"
 
 	"braces.dev/errtrace"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 	"github.com/maxrichie5/go-sqlfmt/sqlfmt"
 )
 
 type UserVerifyAccountRepository struct {
 	DB     *sql.DB
-	Config *config.ConfigApp
+	Config *config.Config
 }
 
-func (r UserVerifyAccountRepository) Get(id uuid.UUID, token string) (*models.UserVerifyAccount, error) {
-	return r.getExec(r.DB, id, token)
+func (r UserVerifyAccountRepository) Get(ctx context.Context, id uuid.UUID, token string) (*models.UserVerifyAccount, error) {
+	return r.getExec(ctx, r.DB, id, token)
 }
 
-func (r UserVerifyAccountRepository) getExec(exc Executor, id uuid.UUID, token string) (*models.UserVerifyAccount, error) {
+func (r UserVerifyAccountRepository) getExec(ctx context.Context, exc Executor, id uuid.UUID, token string) (*models.UserVerifyAccount, error) {
 	query := `
 		SELECT "id", "token", "expires_at"
 		FROM "user_verify_accounts"
 		WHERE "id" = $1 AND "token" = $2;
 	`
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	user := &models.UserVerifyAccount{}
@@ -56,11 +54,11 @@ func (r UserVerifyAccountRepository) getExec(exc Executor, id uuid.UUID, token s
 	return user, nil
 }
 
-func (r UserVerifyAccountRepository) Insert(users ...*models.UserVerifyAccount) error {
-	return r.InsertExec(r.DB, users...)
+func (r UserVerifyAccountRepository) Insert(ctx context.Context, users ...*models.UserVerifyAccount) error {
+	return r.InsertExec(ctx, r.DB, users...)
 }
 
-func (r UserVerifyAccountRepository) InsertExec(exc Executor, users ...*models.UserVerifyAccount) error {
+func (r UserVerifyAccountRepository) InsertExec(ctx context.Context, exc Executor, users ...*models.UserVerifyAccount) error {
 	if len(users) == 0 {
 		return nil
 	}
@@ -88,22 +86,17 @@ func (r UserVerifyAccountRepository) InsertExec(exc Executor, users ...*models.U
 		RETURNING "id";
 	`, strings.Join(columns[:], ", "), strings.Join(valueStrings, ", "))
 
-	if r.Config != nil && r.Config.Debug {
+	if r.Config != nil && r.Config.App.Debug {
 		fmt.Println()
 		sqlfmt.PrettyPrint(query)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := withTimeout(ctx, r.Config)
 	defer cancel()
 
 	rows, err := exc.QueryContext(ctx, query, valueArgs...)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23505" {
-				return errtrace.Wrap(ErrInsertDuplicate)
-			}
-		}
-		return errtrace.Wrap(err)
+		return errtrace.Wrap(translateError(err))
 	}
 	defer rows.Close()
 
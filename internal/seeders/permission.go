@@ -0,0 +1,97 @@
+package seeders
+
+import (
+	"context"
+	"database/sql"
+
+	"gofi/internal/lib/constant"
+	"gofi/internal/models"
+	"gofi/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+type PermissionSeeder struct {
+	DB *sql.DB
+}
+
+func (s PermissionSeeder) Name() string {
+	return "permission"
+}
+
+// defaultRolePermissions are the permission sets granted to the seeded roles.
+var defaultRolePermissions = map[string][]string{
+	constant.RoleAdmin: {
+		constant.PermissionRolesRead,
+		constant.PermissionRolesWrite,
+		constant.PermissionPermissionsRead,
+		constant.PermissionPermissionsWrite,
+		constant.PermissionUsersRead,
+		constant.PermissionUsersWrite,
+		constant.PermissionSessionsRead,
+		constant.PermissionOAuthClientsRead,
+		constant.PermissionOAuthClientsWrite,
+		constant.PermissionOrganizationsRead,
+		constant.PermissionOrganizationsWrite,
+		constant.PermissionAuditLogsRead,
+		constant.PermissionGlobalAccess,
+	},
+	constant.RoleUser: {
+		constant.PermissionRolesRead,
+		constant.PermissionUsersRead,
+	},
+	// only the permissions of the routes scoped to the active organization
+	constant.RoleOrganizationOwner: {
+		constant.PermissionRolesRead,
+		constant.PermissionUsersRead,
+		constant.PermissionUsersWrite,
+		constant.PermissionSessionsRead,
+	},
+	constant.RoleOrganizationAdmin: {
+		constant.PermissionRolesRead,
+		constant.PermissionUsersRead,
+		constant.PermissionUsersWrite,
+		constant.PermissionSessionsRead,
+	},
+	constant.RoleOrganizationMember: {
+		constant.PermissionRolesRead,
+		constant.PermissionUsersRead,
+	},
+}
+
+func (s PermissionSeeder) Seed() {
+	permissions := []*models.Permission{}
+	permissionIDs := map[string]uuid.UUID{}
+
+	// every permission is granted to the admin role
+	for _, name := range defaultRolePermissions[constant.RoleAdmin] {
+		permission := &models.Permission{
+			Base: models.Base{
+				ID: uuid.Must(uuid.NewV7()),
+			},
+			Name: name,
+		}
+
+		permissions = append(permissions, permission)
+		permissionIDs[name] = permission.ID
+	}
+
+	permissionRepo := repositories.NewPermissionRepository(s.DB, nil)
+	err := permissionRepo.Insert(context.Background(), permissions...)
+	if err != nil {
+		panic(NewErrSeedingFailed(err))
+	}
+
+	rolePermissionRepo := repositories.RolePermissionRepository{DB: s.DB}
+	for roleID, names := range defaultRolePermissions {
+		ids := make([]uuid.UUID, 0, len(names))
+		for _, name := range names {
+			ids = append(ids, permissionIDs[name])
+		}
+
+		err := rolePermissionRepo.Attach(context.Background(), uuid.MustParse(roleID), ids...)
+		if err != nil {
+			panic(NewErrSeedingFailed(err))
+		}
+	}
+}
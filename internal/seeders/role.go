@@ -1,6 +1,7 @@
 package seeders
 
 import (
+	"context"
 	"database/sql"
 
 	"gofi/internal/lib/constant"
@@ -32,15 +33,28 @@ func (s RoleSeeder) Seed() {
 			},
 			Name: "User",
 		},
-	}
-
-	roleRepo := repositories.RoleRepository{
-		BaseRepository: repositories.BaseRepository{
-			DB:        s.DB,
-			TableName: "roles",
+		{
+			Base: models.Base{
+				ID: uuid.MustParse(constant.RoleOrganizationOwner),
+			},
+			Name: "Organization Owner",
+		},
+		{
+			Base: models.Base{
+				ID: uuid.MustParse(constant.RoleOrganizationAdmin),
+			},
+			Name: "Organization Admin",
+		},
+		{
+			Base: models.Base{
+				ID: uuid.MustParse(constant.RoleOrganizationMember),
+			},
+			Name: "Organization Member",
 		},
 	}
-	err := roleRepo.Insert(roles...)
+
+	roleRepo := repositories.NewRoleRepository(s.DB, nil)
+	err := roleRepo.Insert(context.Background(), roles...)
 	if err != nil {
 		panic(NewErrSeedingFailed(err))
 	}
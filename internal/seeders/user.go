@@ -1,6 +1,7 @@
 package seeders
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -53,13 +54,8 @@ func (s UserSeeder) Seed() {
 		},
 	}
 
-	userRepo := repositories.UserRepository{
-		BaseRepository: repositories.BaseRepository{
-			DB:        s.DB,
-			TableName: "users",
-		},
-	}
-	err := userRepo.Insert(users...)
+	userRepo := repositories.NewUserRepository(s.DB, nil)
+	err := userRepo.Insert(context.Background(), users...)
 	if err != nil {
 		panic(NewErrSeedingFailed(err))
 	}
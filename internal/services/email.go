@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
@@ -22,7 +23,7 @@ type SendEmailParams struct {
 	HtmlTemplate string
 }
 
-func (s EmailService) SendEmail(value SendEmailParams) (string, error) {
+func (s EmailService) SendEmail(ctx context.Context, value SendEmailParams) (string, error) {
 	client := resend.NewClient(s.Config.ApiKey)
 
 	// Load the HTML template
@@ -38,7 +39,7 @@ func (s EmailService) SendEmail(value SendEmailParams) (string, error) {
 		Subject: value.Subject,
 	}
 
-	sent, err := client.Emails.Send(params)
+	sent, err := client.Emails.SendWithContext(ctx, params)
 	if err != nil {
 		fmt.Printf("Error sending email: %s\n", err.Error())
 		return "", err
@@ -1,7 +1,12 @@
 package services
 
 type Services struct {
-	Email  EmailService
-	Google GoogleService
-	S3     S3Service
+	Email     EmailService
+	OAuth     OAuthService
+	Storage   Storage
+	MFA       MFAService
+	WebAuthn  WebAuthnService
+	MagicLink MagicLinkService
+	OIDC      OIDCService
+	Lockout   LockoutService
 }
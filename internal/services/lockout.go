@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gofi/internal/lib"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type LockoutService struct {
+	RedisClient *redis.Client
+}
+
+const (
+	SignInLockDuration = 15 * time.Minute
+
+	signInMaxFailures   = 5
+	signInFailureWindow = 15 * time.Minute
+	signInMaxBackoff    = 30 * time.Second
+
+	signInMaxIPFailures   = 20
+	signInIPFailureWindow = 15 * time.Minute
+)
+
+func signInKey(kind string, email string) string {
+	return fmt.Sprintf("sign-in:%s:%s", kind, lib.HashToken(strings.ToLower(strings.TrimSpace(email))))
+}
+
+func signInIPKey(ip string) string {
+	return fmt.Sprintf("sign-in:ip:%s", ip)
+}
+
+// Reserve counts a sign in attempt with the email from the ip before the
+// password is compared, so concurrent attempts can't all slip under the
+// limits. It returns how long the caller has to wait, zero means the attempt
+// is allowed.
+func (s LockoutService) Reserve(ctx context.Context, email string, ip string) (time.Duration, error) {
+	for _, key := range []string{signInKey("lock", email), signInKey("backoff", email)} {
+		ttl, err := s.RedisClient.PTTL(ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("error reading lockout from redis: %s", err.Error())
+		}
+
+		if ttl > 0 {
+			return ttl, nil
+		}
+	}
+
+	failuresKey := signInKey("failures", email)
+
+	var count, ipCount *redis.IntCmd
+	_, err := s.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, failuresKey)
+		pipe.ExpireNX(ctx, failuresKey, signInFailureWindow)
+		ipCount = pipe.Incr(ctx, signInIPKey(ip))
+		pipe.ExpireNX(ctx, signInIPKey(ip), signInIPFailureWindow)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error counting attempts in redis: %s", err.Error())
+	}
+
+	var exceeded []string
+	if count.Val() > signInMaxFailures {
+		exceeded = append(exceeded, failuresKey)
+	}
+	if ipCount.Val() > signInMaxIPFailures {
+		exceeded = append(exceeded, signInIPKey(ip))
+	}
+
+	for _, key := range exceeded {
+		ttl, err := s.RedisClient.PTTL(ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("error reading lockout from redis: %s", err.Error())
+		}
+
+		if ttl > 0 {
+			return ttl, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// RegisterFailure turns the attempt reserved for the email into a failure.
+// Every failure doubles the wait before the next attempt, after too many
+// failures the email is locked and locked is true for the attempt that
+// caused it.
+func (s LockoutService) RegisterFailure(ctx context.Context, email string) (bool, error) {
+	failuresKey := signInKey("failures", email)
+	count, err := s.RedisClient.Get(ctx, failuresKey).Int64()
+	if err != nil && err != redis.Nil {
+		return false, fmt.Errorf("error counting failures in redis: %s", err.Error())
+	}
+
+	if count >= signInMaxFailures {
+		locked, err := s.RedisClient.SetNX(ctx, signInKey("lock", email), 1, SignInLockDuration).Result()
+		if err != nil {
+			return false, fmt.Errorf("error storing lockout in redis: %s", err.Error())
+		}
+
+		// the counter starts over once the lock expires
+		s.RedisClient.Del(ctx, failuresKey, signInKey("backoff", email))
+
+		return locked, nil
+	}
+
+	backoff := time.Duration(math.Pow(2, float64(max(count, 1)-1))) * time.Second
+	backoff = min(backoff, signInMaxBackoff)
+
+	err = s.RedisClient.Set(ctx, signInKey("backoff", email), 1, backoff).Err()
+	if err != nil {
+		return false, fmt.Errorf("error storing backoff in redis: %s", err.Error())
+	}
+
+	return false, nil
+}
+
+// Reset clears the failed attempts of the email after a successful sign in
+// and gives back the attempt reserved for the ip.
+func (s LockoutService) Reset(ctx context.Context, email string, ip string) error {
+	_, err := s.RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, signInKey("failures", email), signInKey("backoff", email))
+		pipe.Decr(ctx, signInIPKey(ip))
+		pipe.ExpireNX(ctx, signInIPKey(ip), signInIPFailureWindow)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting failures from redis: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Unlock lifts the lock of the email and clears its failed attempts, the
+// per-ip counters are left to expire on their own.
+func (s LockoutService) Unlock(ctx context.Context, email string) error {
+	err := s.RedisClient.Del(ctx,
+		signInKey("lock", email),
+		signInKey("failures", email),
+		signInKey("backoff", email),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("error deleting lockout from redis: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gofi/internal/lib"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type MagicLinkService struct {
+	RedisClient *redis.Client
+}
+
+const (
+	MagicLinkTTL = 15 * time.Minute
+
+	magicLinkRateLimit       = 3
+	magicLinkRateLimitWindow = 15 * time.Minute
+)
+
+var (
+	ErrInvalidMagicLink = errors.New("invalid or expired magic link")
+)
+
+// Allow counts a magic link request for the email and reports whether it is
+// still within the rate limit. Unknown emails are counted as well, so the
+// limit doesn't reveal which accounts exist.
+func (s MagicLinkService) Allow(ctx context.Context, email string) (bool, error) {
+	key := fmt.Sprintf("magic-link:rate:%s", lib.HashToken(strings.ToLower(strings.TrimSpace(email))))
+
+	count, err := s.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("error counting requests in redis: %s", err.Error())
+	}
+
+	if count == 1 {
+		s.RedisClient.Expire(ctx, key, magicLinkRateLimitWindow)
+	}
+
+	return count <= magicLinkRateLimit, nil
+}
+
+// CreateToken returns a single-use token for the user, only its hash is
+// kept in Redis.
+func (s MagicLinkService) CreateToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := lib.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("magic-link:%s", lib.HashToken(token))
+
+	err = s.RedisClient.Set(ctx, key, userID.String(), MagicLinkTTL).Err()
+	if err != nil {
+		return "", fmt.Errorf("error storing magic link in redis: %s", err.Error())
+	}
+
+	return token, nil
+}
+
+// ConsumeToken returns the user of the token and deletes it (one-time use).
+func (s MagicLinkService) ConsumeToken(ctx context.Context, token string) (uuid.UUID, error) {
+	key := fmt.Sprintf("magic-link:%s", lib.HashToken(token))
+
+	val, err := s.RedisClient.GetDel(ctx, key).Result()
+	if err != nil || val == "" {
+		return uuid.Nil, ErrInvalidMagicLink
+	}
+
+	userID, err := uuid.Parse(val)
+	if err != nil {
+		return uuid.Nil, ErrInvalidMagicLink
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gofi/internal/lib"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type MFAService struct {
+	RedisClient *redis.Client
+}
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+)
+
+var (
+	ErrInvalidChallenge = errors.New("invalid or expired mfa token")
+	ErrTooManyAttempts  = errors.New("too many attempts")
+)
+
+// CreateChallenge stores a short-lived token proving the user passed the
+// password step, it has to be exchanged with a valid code to get a session.
+func (s MFAService) CreateChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := lib.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("mfa:challenge:%s", lib.HashToken(token))
+
+	err = s.RedisClient.Set(ctx, key, userID.String(), mfaChallengeTTL).Err()
+	if err != nil {
+		return "", fmt.Errorf("error storing challenge in redis: %s", err.Error())
+	}
+
+	return token, nil
+}
+
+// GetChallenge returns the user of the challenge and counts the attempt, the
+// challenge is dropped once the attempts are exhausted.
+func (s MFAService) GetChallenge(ctx context.Context, token string) (uuid.UUID, error) {
+	key := fmt.Sprintf("mfa:challenge:%s", lib.HashToken(token))
+	attemptsKey := fmt.Sprintf("%s:attempts", key)
+
+	val, err := s.RedisClient.Get(ctx, key).Result()
+	if err != nil || val == "" {
+		return uuid.Nil, ErrInvalidChallenge
+	}
+
+	attempts, err := s.RedisClient.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error counting attempts in redis: %s", err.Error())
+	}
+	s.RedisClient.Expire(ctx, attemptsKey, mfaChallengeTTL)
+
+	if attempts > mfaChallengeMaxAttempts {
+		s.RedisClient.Del(ctx, key, attemptsKey)
+		return uuid.Nil, ErrTooManyAttempts
+	}
+
+	userID, err := uuid.Parse(val)
+	if err != nil {
+		return uuid.Nil, ErrInvalidChallenge
+	}
+
+	return userID, nil
+}
+
+// ConsumeChallenge drops the challenge once its code was accepted, only one
+// of concurrent requests consumes it, the others get ErrInvalidChallenge.
+func (s MFAService) ConsumeChallenge(ctx context.Context, token string) error {
+	key := fmt.Sprintf("mfa:challenge:%s", lib.HashToken(token))
+
+	deleted, err := s.RedisClient.Del(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("error deleting challenge in redis: %s", err.Error())
+	}
+
+	s.RedisClient.Del(ctx, fmt.Sprintf("%s:attempts", key))
+
+	if deleted == 0 {
+		return ErrInvalidChallenge
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/oauth2"
+)
+
+// OAuthProvider is an identity provider users can sign in with, e.g. Google,
+// GitHub or any OpenID Connect issuer.
+type OAuthProvider interface {
+	OAuth2Config() *oauth2.Config
+	UserInfo(ctx context.Context, token *oauth2.Token) (*OAuthUserInfo, error)
+}
+
+// OAuthUserInfo is the identity returned by a provider, ID is the stable
+// identifier of the user at the provider.
+type OAuthUserInfo struct {
+	ID            string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	Picture       string
+}
+
+type AuthenticateResponse struct {
+	Token    *oauth2.Token
+	UserInfo *OAuthUserInfo
+	// LinkUserID is the user linking the identity to their account, it is
+	// uuid.Nil when the user signs in.
+	LinkUserID uuid.UUID
+}
+
+// OAuthService is the registry of the enabled providers, keyed by the name
+// used in the routes and stored in user_oauths.provider.
+type OAuthService struct {
+	Providers   map[string]OAuthProvider
+	RedisClient *redis.Client
+}
+
+var (
+	ErrGenerateState         = errors.New("error generate state token")
+	ErrInvalidStateToken     = errors.New("invalid state token")
+	ErrInvalidRefreshToken   = errors.New("invalid refresh token")
+	ErrUnknownOAuthProvider  = errors.New("unknown oauth provider")
+	ErrOAuthEmailUnavailable = errors.New("oauth provider did not return an email")
+	ErrOAuthLinkMismatch     = errors.New("oauth link must be completed by the user who started it")
+)
+
+func (s OAuthService) Provider(name string) (OAuthProvider, error) {
+	provider, ok := s.Providers[name]
+	if !ok {
+		return nil, ErrUnknownOAuthProvider
+	}
+
+	return provider, nil
+}
+
+// generateStateToken stores the state with the user linking the identity, or
+// uuid.Nil on sign in, so the callback knows which flow it completes.
+func (s OAuthService) generateStateToken(ctx context.Context, name string, linkUserID uuid.UUID) (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("error base64 rand: %s", err.Error())
+	}
+
+	state := base64.URLEncoding.EncodeToString(b)
+
+	// Store state in Redis with 5 minute expiration
+	key := fmt.Sprintf("oauth:%s:%s", name, state)
+
+	err = s.RedisClient.Set(ctx, key, linkUserID.String(), 5*time.Minute).Err()
+	if err != nil {
+		return "", fmt.Errorf("error storing state in redis: %s", err.Error())
+	}
+
+	return state, nil
+}
+
+func (s OAuthService) verifyStateToken(ctx context.Context, name string, state string) (uuid.UUID, bool) {
+	key := fmt.Sprintf("oauth:%s:%s", name, state)
+
+	// Delete the state on verification (one-time use)
+	val, err := s.RedisClient.GetDel(ctx, key).Result()
+	if err != nil || val == "" {
+		return uuid.Nil, false
+	}
+
+	linkUserID, err := uuid.Parse(val)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return linkUserID, true
+}
+
+func (s OAuthService) AuthCodeURL(ctx context.Context, name string) (string, error) {
+	return s.authCodeURL(ctx, name, uuid.Nil)
+}
+
+// LinkCodeURL is the authorization URL for a signed in user linking the
+// provider to their account.
+func (s OAuthService) LinkCodeURL(ctx context.Context, name string, userID uuid.UUID) (string, error) {
+	return s.authCodeURL(ctx, name, userID)
+}
+
+func (s OAuthService) authCodeURL(ctx context.Context, name string, linkUserID uuid.UUID) (string, error) {
+	provider, err := s.Provider(name)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := s.generateStateToken(ctx, name, linkUserID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrGenerateState, err.Error())
+	}
+
+	return provider.OAuth2Config().AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+}
+
+// Authenticate completes the flow started with AuthCodeURL or LinkCodeURL.
+// userID is the signed in user completing it, uuid.Nil when anonymous. A link
+// is only completed by the user who started it, otherwise anyone handed the
+// authorization URL would link their identity to that account.
+func (s OAuthService) Authenticate(ctx context.Context, name string, state string, code string, userID uuid.UUID) (*AuthenticateResponse, error) {
+	provider, err := s.Provider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	linkUserID, ok := s.verifyStateToken(ctx, name, state)
+	if !ok {
+		return nil, ErrInvalidStateToken
+	}
+
+	if linkUserID != uuid.Nil && linkUserID != userID {
+		return nil, ErrOAuthLinkMismatch
+	}
+
+	token, err := provider.OAuth2Config().Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("error exchange code: %s", err.Error())
+	}
+
+	userInfo, err := provider.UserInfo(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if userInfo.Email == "" {
+		return nil, ErrOAuthEmailUnavailable
+	}
+
+	return &AuthenticateResponse{
+		Token:      token,
+		UserInfo:   userInfo,
+		LinkUserID: linkUserID,
+	}, nil
+}
+
+// RefreshAccessToken gets a new access token using a refresh token
+// Returns a new token with updated AccessToken and Expiry
+// The RefreshToken in the response may be the same or a new one (depending on provider)
+func (s OAuthService) RefreshAccessToken(ctx context.Context, name string, refreshToken string) (*oauth2.Token, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	provider, err := s.Provider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a token with only the refresh token
+	token := &oauth2.Token{
+		RefreshToken: refreshToken,
+	}
+
+	// Use TokenSource to automatically refresh the token
+	tokenSource := provider.OAuth2Config().TokenSource(ctx, token)
+
+	// Get the new token (this will use the refresh token to get a new access token)
+	newToken, err := tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error refreshing token: %s", err.Error())
+	}
+
+	// Verify that we got a valid access token
+	if newToken.AccessToken == "" {
+		return nil, fmt.Errorf("received empty access token")
+	}
+
+	return newToken, nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var ErrInvalidOIDCDiscovery = errors.New("invalid openid connect discovery document")
+
+// OIDCProvider is an OpenID Connect issuer, the identity is read from its
+// userinfo endpoint.
+type OIDCProvider struct {
+	Config      *oauth2.Config
+	UserInfoURL string
+}
+
+type oidcUserInfo struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+}
+
+func (p OIDCProvider) OAuth2Config() *oauth2.Config {
+	return p.Config
+}
+
+func (p OIDCProvider) UserInfo(ctx context.Context, token *oauth2.Token) (*OAuthUserInfo, error) {
+	var userInfo oidcUserInfo
+	if err := getJSON(ctx, p.Config.Client(ctx, token), p.UserInfoURL, &userInfo); err != nil {
+		return nil, fmt.Errorf("error get user info: %s", err.Error())
+	}
+
+	givenName, familyName := userInfo.GivenName, userInfo.FamilyName
+	if givenName == "" {
+		givenName, familyName = splitName(userInfo.Name)
+	}
+
+	return &OAuthUserInfo{
+		ID:            userInfo.Sub,
+		Email:         userInfo.Email,
+		EmailVerified: userInfo.EmailVerified,
+		Name:          userInfo.Name,
+		GivenName:     givenName,
+		FamilyName:    familyName,
+		Picture:       userInfo.Picture,
+	}, nil
+}
+
+// DiscoverOIDCProvider reads the endpoints of an issuer from its discovery
+// document, config only needs the client credentials and redirect URL.
+func DiscoverOIDCProvider(ctx context.Context, issuer string, config *oauth2.Config) (*OIDCProvider, error) {
+	var discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, http.DefaultClient, url, &discovery); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOIDCDiscovery, err.Error())
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserInfoEndpoint == "" {
+		return nil, fmt.Errorf("%w: missing endpoints", ErrInvalidOIDCDiscovery)
+	}
+
+	config.Endpoint = oauth2.Endpoint{
+		AuthURL:  discovery.AuthorizationEndpoint,
+		TokenURL: discovery.TokenEndpoint,
+	}
+
+	return &OIDCProvider{
+		Config:      config,
+		UserInfoURL: discovery.UserInfoEndpoint,
+	}, nil
+}
+
+// GitHubProvider signs users in with GitHub, which is OAuth 2.0 only. The
+// email is the primary verified address of the account.
+type GitHubProvider struct {
+	Config *oauth2.Config
+}
+
+func (p GitHubProvider) OAuth2Config() *oauth2.Config {
+	return p.Config
+}
+
+func (p GitHubProvider) UserInfo(ctx context.Context, token *oauth2.Token) (*OAuthUserInfo, error) {
+	client := p.Config.Client(ctx, token)
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user", &user); err != nil {
+		return nil, fmt.Errorf("error get user info: %s", err.Error())
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user/emails", &emails); err != nil {
+		return nil, fmt.Errorf("error get user emails: %s", err.Error())
+	}
+
+	userInfo := &OAuthUserInfo{
+		ID:      strconv.FormatInt(user.ID, 10),
+		Name:    user.Name,
+		Picture: user.AvatarURL,
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			userInfo.Email = email.Email
+			userInfo.EmailVerified = email.Verified
+		}
+	}
+
+	if userInfo.Name == "" {
+		userInfo.Name = user.Login
+	}
+
+	userInfo.GivenName, userInfo.FamilyName = splitName(userInfo.Name)
+
+	return userInfo, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// splitName splits a display name into given and family name, providers
+// without structured names only return the former.
+func splitName(name string) (string, string) {
+	givenName, familyName, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return givenName, strings.TrimSpace(familyName)
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gofi/internal/lib"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type OIDCService struct {
+	RedisClient *redis.Client
+}
+
+// AuthorizationCode is what an authorization code stands for until the
+// client exchanges it at the token endpoint.
+type AuthorizationCode struct {
+	ClientID      string    `json:"client_id"`
+	UserID        uuid.UUID `json:"user_id"`
+	RedirectURI   string    `json:"redirect_uri"`
+	Scopes        []string  `json:"scopes"`
+	CodeChallenge string    `json:"code_challenge"`
+	Nonce         string    `json:"nonce,omitempty"`
+	AuthTime      int64     `json:"auth_time"`
+}
+
+// AccessToken is what an access token issued to a client grants.
+type AccessToken struct {
+	ClientID string    `json:"client_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Scopes   []string  `json:"scopes"`
+}
+
+const (
+	AuthorizationCodeTTL = 5 * time.Minute
+	AccessTokenTTL       = time.Hour
+)
+
+var (
+	ErrInvalidAuthorizationCode = errors.New("invalid or expired authorization code")
+	ErrInvalidAccessToken       = errors.New("invalid or expired access token")
+)
+
+func (s OIDCService) CreateAuthorizationCode(ctx context.Context, value *AuthorizationCode) (string, error) {
+	return s.store(ctx, "oidc:code", value, AuthorizationCodeTTL)
+}
+
+// ConsumeAuthorizationCode returns the authorization and deletes the code,
+// a code can only be exchanged once.
+func (s OIDCService) ConsumeAuthorizationCode(ctx context.Context, code string) (*AuthorizationCode, error) {
+	key := fmt.Sprintf("oidc:code:%s", lib.HashToken(code))
+
+	data, err := s.RedisClient.GetDel(ctx, key).Bytes()
+	if err != nil {
+		return nil, ErrInvalidAuthorizationCode
+	}
+
+	value := &AuthorizationCode{}
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, ErrInvalidAuthorizationCode
+	}
+
+	return value, nil
+}
+
+func (s OIDCService) CreateAccessToken(ctx context.Context, value *AccessToken) (string, error) {
+	return s.store(ctx, "oidc:access-token", value, AccessTokenTTL)
+}
+
+func (s OIDCService) GetAccessToken(ctx context.Context, token string) (*AccessToken, error) {
+	key := fmt.Sprintf("oidc:access-token:%s", lib.HashToken(token))
+
+	data, err := s.RedisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	value := &AccessToken{}
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, ErrInvalidAccessToken
+	}
+
+	return value, nil
+}
+
+// store saves value under a random token, only the hash of the token is
+// used as key.
+func (s OIDCService) store(ctx context.Context, prefix string, value any, ttl time.Duration) (string, error) {
+	token, err := lib.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s:%s", prefix, lib.HashToken(token))
+
+	err = s.RedisClient.Set(ctx, key, data, ttl).Err()
+	if err != nil {
+		return "", fmt.Errorf("error storing %s in redis: %s", prefix, err.Error())
+	}
+
+	return token, nil
+}
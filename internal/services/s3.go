@@ -2,17 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 type S3Service struct {
 	Client *s3.Client
+	Bucket string
 }
 
-func (s S3Service) CreateBucket(name string) (string, error) {
-	ctx := context.Background()
+func (s S3Service) CreateBucket(ctx context.Context, name string) (string, error) {
 
 	bucket, err := s.Client.CreateBucket(ctx, &s3.CreateBucketInput{
 		Bucket: &name,
@@ -25,3 +29,282 @@ func (s S3Service) CreateBucket(name string) (string, error) {
 
 	return *bucket.Location, nil
 }
+
+// KeyFile returns the key_file of an object of the bucket, in the
+// `/bucket/key` format stored in the uploads table.
+func (s S3Service) KeyFile(key string) string {
+	return fmt.Sprintf("/%s/%s", s.Bucket, key)
+}
+
+func (s S3Service) Put(ctx context.Context, keyFile string, body io.Reader, size int64, contentType string) error {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	_, err = s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &bucket,
+		Key:           &key,
+		Body:          body,
+		ContentLength: &size,
+		ContentType:   &contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("error putting object: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s S3Service) PresignPut(ctx context.Context, keyFile string, size int64, contentType string, ttl time.Duration) (string, time.Time, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	expiresAt := time.Now().Add(ttl)
+
+	request, err := s3.NewPresignClient(s.Client).PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        &bucket,
+		Key:           &key,
+		ContentLength: &size,
+		ContentType:   &contentType,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error presigning object: %s", err.Error())
+	}
+
+	return request.URL, expiresAt, nil
+}
+
+func (s S3Service) CreateMultipartUpload(ctx context.Context, keyFile string, contentType string) (string, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, err := s.Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      &bucket,
+		Key:         &key,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creating multipart upload: %s", err.Error())
+	}
+
+	return *output.UploadId, nil
+}
+
+func (s S3Service) PresignUploadPart(ctx context.Context, keyFile string, uploadID string, partNumber int32, ttl time.Duration) (string, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	request, err := s3.NewPresignClient(s.Client).PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     &bucket,
+		Key:        &key,
+		UploadId:   &uploadID,
+		PartNumber: &partNumber,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("error presigning upload part: %s", err.Error())
+	}
+
+	return request.URL, nil
+}
+
+func (s S3Service) CompleteMultipartUpload(ctx context.Context, keyFile string, uploadID string, parts []S3Part) error {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return err
+	}
+
+	completedParts := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completedParts = append(completedParts, types.CompletedPart{
+			PartNumber: &part.PartNumber,
+			ETag:       &part.ETag,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	_, err = s.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &key,
+		UploadId:        &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
+	})
+	if err != nil {
+		return fmt.Errorf("error completing multipart upload: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s S3Service) AbortMultipartUpload(ctx context.Context, keyFile string, uploadID string) error {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err = s.Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+	if err != nil {
+		return fmt.Errorf("error aborting multipart upload: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s S3Service) Stat(ctx context.Context, keyFile string) (ObjectInfo, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, err := s.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return ObjectInfo{}, ErrObjectNotFound
+		}
+
+		return ObjectInfo{}, fmt.Errorf("error reading object: %s", err.Error())
+	}
+
+	return s3ObjectInfo(keyFile, output.ContentLength, output.ContentType, output.LastModified), nil
+}
+
+func (s S3Service) Get(ctx context.Context, keyFile string) (io.ReadCloser, ObjectInfo, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	// no timeout, the body is read after returning
+	output, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ObjectInfo{}, ErrObjectNotFound
+		}
+
+		return nil, ObjectInfo{}, fmt.Errorf("error getting object: %s", err.Error())
+	}
+
+	return output.Body, s3ObjectInfo(keyFile, output.ContentLength, output.ContentType, output.LastModified), nil
+}
+
+func (s S3Service) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var objects []ObjectInfo
+
+	paginator := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: &s.Bucket,
+		Prefix: &prefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing objects: %s", err.Error())
+		}
+
+		for _, object := range page.Contents {
+			objects = append(objects, s3ObjectInfo(s.KeyFile(*object.Key), object.Size, nil, object.LastModified))
+		}
+	}
+
+	return objects, nil
+}
+
+func s3ObjectInfo(keyFile string, size *int64, contentType *string, modifiedAt *time.Time) ObjectInfo {
+	info := ObjectInfo{KeyFile: keyFile}
+
+	if size != nil {
+		info.Size = *size
+	}
+
+	if contentType != nil {
+		info.ContentType = *contentType
+	}
+
+	if modifiedAt != nil {
+		info.ModifiedAt = *modifiedAt
+	}
+
+	return info
+}
+
+func (s S3Service) SignedURL(ctx context.Context, keyFile string, ttl time.Duration) (string, time.Time, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	expiresAt := time.Now().Add(ttl)
+
+	request, err := s3.NewPresignClient(s.Client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error presigning object: %s", err.Error())
+	}
+
+	return request.URL, expiresAt, nil
+}
+
+func (s S3Service) Delete(ctx context.Context, keyFile string) error {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err = s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting object: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	StorageDriverS3    = "s3"
+	StorageDriverLocal = "local"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object, KeyFile is in the `/bucket/key`
+// format stored in the uploads table.
+type ObjectInfo struct {
+	KeyFile     string
+	Size        int64
+	ContentType string
+	ModifiedAt  time.Time
+}
+
+// Storage stores the uploaded files. Objects are addressed by key file so
+// uploads keep working when the bucket changes.
+type Storage interface {
+	// KeyFile returns the key file of key in the current bucket.
+	KeyFile(key string) string
+	// Put streams body under key, size is required as the body isn't
+	// buffered.
+	Put(ctx context.Context, keyFile string, body io.Reader, size int64, contentType string) error
+	// Get returns the content of the object, the caller closes it.
+	Get(ctx context.Context, keyFile string) (io.ReadCloser, ObjectInfo, error)
+	Delete(ctx context.Context, keyFile string) error
+	// Stat returns ErrObjectNotFound when the object doesn't exist.
+	Stat(ctx context.Context, keyFile string) (ObjectInfo, error)
+	// SignedURL returns a URL to download the object until the returned
+	// expiry.
+	SignedURL(ctx context.Context, keyFile string, ttl time.Duration) (string, time.Time, error)
+	// List returns the objects of the current bucket whose key starts with
+	// prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// S3Part is an uploaded part of a multipart upload, ETag is returned by S3
+// to the client uploading the part.
+type S3Part struct {
+	PartNumber int32
+	ETag       string
+}
+
+// DirectUploader is implemented by the storages clients can upload to
+// without going through the API.
+type DirectUploader interface {
+	// PresignPut returns a URL the client can upload the object to, size
+	// and contentType are part of the signature so the client can't send
+	// anything else.
+	PresignPut(ctx context.Context, keyFile string, size int64, contentType string, ttl time.Duration) (string, time.Time, error)
+	// CreateMultipartUpload starts an upload in parts, the returned id
+	// identifies the upload in the other multipart calls.
+	CreateMultipartUpload(ctx context.Context, keyFile string, contentType string) (string, error)
+	PresignUploadPart(ctx context.Context, keyFile string, uploadID string, partNumber int32, ttl time.Duration) (string, error)
+	CompleteMultipartUpload(ctx context.Context, keyFile string, uploadID string, parts []S3Part) error
+	AbortMultipartUpload(ctx context.Context, keyFile string, uploadID string) error
+}
+
+// SignedURLVerifier is implemented by the storages serving their signed URLs
+// through the API.
+type SignedURLVerifier interface {
+	Verify(keyFile string, expires string, signature string) error
+}
+
+func splitKeyFile(keyFile string) (string, string, error) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(keyFile, "/"), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid key file: %s", keyFile)
+	}
+
+	return bucket, key, nil
+}
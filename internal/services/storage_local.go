@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localStorageBucket is the bucket of the key files stored on disk, it is a
+// directory of LocalStorage.Dir.
+const localStorageBucket = "local"
+
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalStorage stores the objects on disk, for development and tests. Signed
+// URLs point to the storage route of the API and are signed with Secret.
+type LocalStorage struct {
+	Dir       string
+	Secret    string
+	ServerURL string
+}
+
+func (s LocalStorage) KeyFile(key string) string {
+	return fmt.Sprintf("/%s/%s", localStorageBucket, key)
+}
+
+// path returns the file of keyFile, keys escaping the directory are
+// rejected.
+func (s LocalStorage) path(keyFile string) (string, error) {
+	bucket, key, err := splitKeyFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsLocal(bucket) || !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid key file: %s", keyFile)
+	}
+
+	return filepath.Join(s.Dir, bucket, filepath.FromSlash(key)), nil
+}
+
+func (s LocalStorage) Put(ctx context.Context, keyFile string, body io.Reader, size int64, contentType string) error {
+	path, err := s.path(keyFile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating directory: %s", err.Error())
+	}
+
+	// write aside and rename so readers never see a partial file
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error creating file: %s", err.Error())
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, io.LimitReader(body, size+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing file: %s", err.Error())
+	}
+
+	if written != size {
+		return fmt.Errorf("error writing file: expected %d bytes, got %d", size, written)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("error writing file: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s LocalStorage) Get(ctx context.Context, keyFile string) (io.ReadCloser, ObjectInfo, error) {
+	path, err := s.path(keyFile)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ObjectInfo{}, ErrObjectNotFound
+		}
+
+		return nil, ObjectInfo{}, fmt.Errorf("error opening file: %s", err.Error())
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, ObjectInfo{}, fmt.Errorf("error reading file: %s", err.Error())
+	}
+
+	return file, localObjectInfo(keyFile, stat), nil
+}
+
+func (s LocalStorage) Delete(ctx context.Context, keyFile string) error {
+	path, err := s.path(keyFile)
+	if err != nil {
+		return err
+	}
+
+	// deleting a missing object succeeds, like on S3
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting file: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s LocalStorage) Stat(ctx context.Context, keyFile string) (ObjectInfo, error) {
+	path, err := s.path(keyFile)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ObjectInfo{}, ErrObjectNotFound
+		}
+
+		return ObjectInfo{}, fmt.Errorf("error reading file: %s", err.Error())
+	}
+
+	return localObjectInfo(keyFile, stat), nil
+}
+
+func (s LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	root := filepath.Join(s.Dir, localStorageBucket)
+
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, localObjectInfo(s.KeyFile(key), stat))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing files: %s", err.Error())
+	}
+
+	return objects, nil
+}
+
+// SignedURL returns a URL of the storage route of the API, the expiry is
+// signed along with the key file.
+func (s LocalStorage) SignedURL(ctx context.Context, keyFile string, ttl time.Duration) (string, time.Time, error) {
+	if _, err := s.path(keyFile); err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(keyFile, expires))
+
+	u := &url.URL{Path: "/v1/storage" + keyFile, RawQuery: query.Encode()}
+
+	return strings.TrimSuffix(s.ServerURL, "/") + u.String(), expiresAt, nil
+}
+
+// Verify checks a signed URL of keyFile has been issued by SignedURL and
+// hasn't expired.
+func (s LocalStorage) Verify(keyFile string, expires string, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if time.Now().After(time.Unix(unix, 0)) {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(keyFile, expires))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s LocalStorage) sign(keyFile string, expires string) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(keyFile + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// localObjectInfo guesses the content type from the extension, the disk
+// doesn't keep the one the object was put with.
+func localObjectInfo(keyFile string, stat fs.FileInfo) ObjectInfo {
+	contentType := mime.TypeByExtension(filepath.Ext(keyFile))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return ObjectInfo{
+		KeyFile:     keyFile,
+		Size:        stat.Size(),
+		ContentType: contentType,
+		ModifiedAt:  stat.ModTime(),
+	}
+}
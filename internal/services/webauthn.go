@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gofi/internal/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+type WebAuthnService struct {
+	WebAuthn    *webauthn.WebAuthn
+	RedisClient *redis.Client
+}
+
+// WebAuthnUser adapts a user and its passkeys to webauthn.User.
+type WebAuthnUser struct {
+	User        *models.User
+	Credentials []*models.UserCredential
+}
+
+func (u WebAuthnUser) WebAuthnID() []byte {
+	return u.User.ID[:]
+}
+
+func (u WebAuthnUser) WebAuthnName() string {
+	return u.User.Email
+}
+
+func (u WebAuthnUser) WebAuthnDisplayName() string {
+	if u.User.LastName != nil && *u.User.LastName != "" {
+		return strings.Join([]string{u.User.FirstName, *u.User.LastName}, " ")
+	}
+
+	return u.User.FirstName
+}
+
+func (u WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+
+	for _, c := range u.Credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, t := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				UserPresent:    c.UserPresent,
+				UserVerified:   c.UserVerified,
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: uint32(c.SignCount),
+			},
+		})
+	}
+
+	return credentials
+}
+
+// PasskeyUserHandler loads the owner of the passkey used in a login ceremony.
+type PasskeyUserHandler func(userID uuid.UUID, credentialID []byte) (*WebAuthnUser, error)
+
+const webAuthnSessionTTL = 5 * time.Minute
+
+var (
+	ErrInvalidWebAuthnSession = errors.New("invalid or expired webauthn session")
+	ErrInvalidPasskey         = errors.New("invalid passkey")
+)
+
+func (s WebAuthnService) BeginRegistration(ctx context.Context, user *WebAuthnUser) (*protocol.CredentialCreation, error) {
+	excludeList := make([]protocol.CredentialDescriptor, 0, len(user.Credentials))
+	for _, credential := range user.WebAuthnCredentials() {
+		excludeList = append(excludeList, credential.Descriptor())
+	}
+
+	creation, session, err := s.WebAuthn.BeginRegistration(user, webauthn.WithExclusions(excludeList))
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("webauthn:registration:%s", user.User.ID.String())
+	if err := s.storeSession(ctx, key, session); err != nil {
+		return nil, err
+	}
+
+	return creation, nil
+}
+
+func (s WebAuthnService) FinishRegistration(ctx context.Context, user *WebAuthnUser, body []byte) (*webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPasskey, err.Error())
+	}
+
+	key := fmt.Sprintf("webauthn:registration:%s", user.User.ID.String())
+	session, err := s.takeSession(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := s.WebAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPasskey, err.Error())
+	}
+
+	return credential, nil
+}
+
+// BeginLogin starts a discoverable login, the session is keyed by its
+// challenge since the user is only known once the assertion comes back.
+func (s WebAuthnService) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, error) {
+	assertion, session, err := s.WebAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("webauthn:login:%s", session.Challenge)
+	if err := s.storeSession(ctx, key, session); err != nil {
+		return nil, err
+	}
+
+	return assertion, nil
+}
+
+func (s WebAuthnService) FinishLogin(ctx context.Context, body []byte, handler PasskeyUserHandler) (*WebAuthnUser, *webauthn.Credential, error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidPasskey, err.Error())
+	}
+
+	key := fmt.Sprintf("webauthn:login:%s", parsed.Response.CollectedClientData.Challenge)
+	session, err := s.takeSession(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var user *WebAuthnUser
+	credential, err := s.WebAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, ErrInvalidPasskey
+		}
+
+		user, err = handler(userID, rawID)
+		if err != nil {
+			return nil, err
+		}
+
+		return user, nil
+	}, *session, parsed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidPasskey, err.Error())
+	}
+
+	return user, credential, nil
+}
+
+func (s WebAuthnService) storeSession(ctx context.Context, key string, session *webauthn.SessionData) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	err = s.RedisClient.Set(ctx, key, value, webAuthnSessionTTL).Err()
+	if err != nil {
+		return fmt.Errorf("error storing webauthn session in redis: %s", err.Error())
+	}
+
+	return nil
+}
+
+// takeSession returns and deletes the session, so a challenge can only be
+// answered once.
+func (s WebAuthnService) takeSession(ctx context.Context, key string) (*webauthn.SessionData, error) {
+
+	value, err := s.RedisClient.GetDel(ctx, key).Bytes()
+	if err != nil {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	session := &webauthn.SessionData{}
+	if err := json.Unmarshal(value, session); err != nil {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	return session, nil
+}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_password_resets_id;
+DROP INDEX IF EXISTS idx_password_resets_user_id;
+DROP INDEX IF EXISTS idx_password_resets_token;
+DROP INDEX IF EXISTS idx_password_resets_expires_at;
+DROP INDEX IF EXISTS idx_password_resets_used_at;
+
+DROP TABLE IF EXISTS public."password_resets";
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "password_resets" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "user_id" UUID NOT NULL,
+  "token" TEXT NOT NULL, -- SHA-256 hash of the token sent by email
+  "expires_at" TIMESTAMP NOT NULL,
+  "created_at" TIMESTAMP DEFAULT now(),
+  "used_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_id ON "password_resets" ("id");
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON "password_resets" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_resets_token ON "password_resets" ("token");
+CREATE INDEX IF NOT EXISTS idx_password_resets_expires_at ON "password_resets" ("expires_at");
+CREATE INDEX IF NOT EXISTS idx_password_resets_used_at ON "password_resets" ("used_at");
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
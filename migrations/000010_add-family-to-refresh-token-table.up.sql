@@ -0,0 +1,10 @@
+ALTER TABLE "refresh_tokens" ADD COLUMN IF NOT EXISTS "family_id" UUID NOT NULL DEFAULT uuidv7();
+ALTER TABLE "refresh_tokens" ADD COLUMN IF NOT EXISTS "session_id" UUID;
+
+-- Refresh tokens are stored as SHA-256 hash, see lib.HashToken
+UPDATE "refresh_tokens" SET "token" = encode(sha256("token"::bytea), 'hex');
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON "refresh_tokens" ("family_id");
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON "refresh_tokens" ("session_id");
+
+ALTER TABLE "refresh_tokens" ADD FOREIGN KEY ("session_id") REFERENCES "sessions" ("id") ON DELETE SET NULL;
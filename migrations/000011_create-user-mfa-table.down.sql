@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_user_mfas_id;
+DROP INDEX IF EXISTS idx_user_mfas_user_id;
+DROP INDEX IF EXISTS idx_user_mfas_confirmed_at;
+
+DROP TABLE IF EXISTS public."user_mfas";
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "user_mfas" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL UNIQUE,
+  "secret" TEXT NOT NULL, -- encrypted with the app secret
+  "last_used_step" BIGINT NOT NULL DEFAULT 0, -- last accepted TOTP time step, prevents replay
+  "confirmed_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_mfas_id ON "user_mfas" ("id");
+CREATE INDEX IF NOT EXISTS idx_user_mfas_user_id ON "user_mfas" ("user_id");
+CREATE INDEX IF NOT EXISTS idx_user_mfas_confirmed_at ON "user_mfas" ("confirmed_at");
+
+ALTER TABLE "user_mfas" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
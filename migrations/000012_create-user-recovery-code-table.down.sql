@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_user_recovery_codes_id;
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+DROP INDEX IF EXISTS idx_user_recovery_codes_used_at;
+
+DROP TABLE IF EXISTS public."user_recovery_codes";
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "user_recovery_codes" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "code" TEXT NOT NULL, -- argon2 hash
+  "used_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_id ON "user_recovery_codes" ("id");
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON "user_recovery_codes" ("user_id");
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_used_at ON "user_recovery_codes" ("used_at");
+
+ALTER TABLE "user_recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_user_credentials_id;
+DROP INDEX IF EXISTS idx_user_credentials_user_id;
+DROP INDEX IF EXISTS idx_user_credentials_credential_id;
+
+DROP TABLE IF EXISTS public."user_credentials";
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS "user_credentials" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "name" VARCHAR(255) NOT NULL,
+  "credential_id" BYTEA NOT NULL UNIQUE,
+  "public_key" BYTEA NOT NULL,
+  "attestation_type" VARCHAR(255) NOT NULL,
+  "transports" TEXT[] NOT NULL DEFAULT '{}',
+  "aaguid" BYTEA,
+  "sign_count" BIGINT NOT NULL DEFAULT 0,
+  "user_present" BOOLEAN NOT NULL DEFAULT false,
+  "user_verified" BOOLEAN NOT NULL DEFAULT false,
+  "backup_eligible" BOOLEAN NOT NULL DEFAULT false,
+  "backup_state" BOOLEAN NOT NULL DEFAULT false,
+  "last_used_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_credentials_id ON "user_credentials" ("id");
+CREATE INDEX IF NOT EXISTS idx_user_credentials_user_id ON "user_credentials" ("user_id");
+CREATE INDEX IF NOT EXISTS idx_user_credentials_credential_id ON "user_credentials" ("credential_id");
+
+ALTER TABLE "user_credentials" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_oauth_clients_id;
+DROP INDEX IF EXISTS idx_oauth_clients_created_at;
+DROP INDEX IF EXISTS idx_oauth_clients_deleted_at;
+DROP INDEX IF EXISTS idx_oauth_clients_client_id;
+
+DROP TABLE IF EXISTS public."oauth_clients";
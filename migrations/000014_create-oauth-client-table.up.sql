@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "oauth_clients" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "deleted_at" TIMESTAMP,
+  "name" VARCHAR(255) NOT NULL,
+  "client_id" VARCHAR(255) NOT NULL UNIQUE,
+  "client_secret" TEXT, -- argon2 hash, NULL for public clients
+  "redirect_uris" TEXT[] NOT NULL DEFAULT '{}',
+  "scopes" TEXT[] NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_id ON "oauth_clients" ("id");
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_created_at ON "oauth_clients" ("created_at");
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_deleted_at ON "oauth_clients" ("deleted_at");
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_client_id ON "oauth_clients" ("client_id");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_oauth_consents_id;
+DROP INDEX IF EXISTS idx_oauth_consents_user_id;
+DROP INDEX IF EXISTS idx_oauth_consents_client_id;
+
+DROP TABLE IF EXISTS public."oauth_consents";
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "oauth_consents" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "client_id" UUID NOT NULL,
+  "scopes" TEXT[] NOT NULL DEFAULT '{}',
+  UNIQUE ("user_id", "client_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_oauth_consents_id ON "oauth_consents" ("id");
+CREATE INDEX IF NOT EXISTS idx_oauth_consents_user_id ON "oauth_consents" ("user_id");
+CREATE INDEX IF NOT EXISTS idx_oauth_consents_client_id ON "oauth_consents" ("client_id");
+
+ALTER TABLE "oauth_consents" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+ALTER TABLE "oauth_consents" ADD FOREIGN KEY ("client_id") REFERENCES "oauth_clients" ("id") ON DELETE CASCADE;
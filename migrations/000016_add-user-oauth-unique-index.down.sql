@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_oauths_provider_identity_id;
+DROP INDEX IF EXISTS idx_user_oauths_user_id_provider;
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_oauths_provider_identity_id ON "user_oauths" ("provider", "identity_id");
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_oauths_user_id_provider ON "user_oauths" ("user_id", "provider");
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_api_keys_id;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP INDEX IF EXISTS idx_api_keys_key;
+
+DROP TABLE IF EXISTS public."api_keys";
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "api_keys" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "user_id" UUID NOT NULL,
+  "name" VARCHAR(255) NOT NULL,
+  "prefix" VARCHAR(32) NOT NULL,
+  "key" VARCHAR(64) NOT NULL UNIQUE,
+  "scopes" TEXT[] NOT NULL DEFAULT '{}',
+  "expires_at" TIMESTAMP,
+  "last_used_at" TIMESTAMP,
+  "last_used_ip" VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_id ON "api_keys" ("id");
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON "api_keys" ("user_id");
+CREATE INDEX IF NOT EXISTS idx_api_keys_key ON "api_keys" ("key");
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
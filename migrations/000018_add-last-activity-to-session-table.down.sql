@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sessions_last_activity_at;
+
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "last_activity_at";
@@ -0,0 +1,3 @@
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "last_activity_at" TIMESTAMP DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS idx_sessions_last_activity_at ON "sessions" ("last_activity_at");
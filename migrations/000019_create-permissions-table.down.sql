@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_permissions_id;
+DROP INDEX IF EXISTS idx_permissions_created_at;
+DROP INDEX IF EXISTS idx_permissions_deleted_at;
+DROP INDEX IF EXISTS idx_permissions_name;
+
+DROP TABLE IF EXISTS public."permissions";
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "permissions" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "deleted_at" TIMESTAMP,
+  "name" VARCHAR(255) NOT NULL UNIQUE,
+  "description" TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_permissions_id ON "permissions" ("id");
+CREATE INDEX IF NOT EXISTS idx_permissions_created_at ON "permissions" ("created_at");
+CREATE INDEX IF NOT EXISTS idx_permissions_deleted_at ON "permissions" ("deleted_at");
+CREATE INDEX IF NOT EXISTS idx_permissions_name ON "permissions" ("name");
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_role_permissions_role_id;
+DROP INDEX IF EXISTS idx_role_permissions_permission_id;
+
+DROP TABLE IF EXISTS public."role_permissions";
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+  "role_id" UUID NOT NULL,
+  "permission_id" UUID NOT NULL,
+  "created_at" TIMESTAMP DEFAULT now(),
+  PRIMARY KEY ("role_id", "permission_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_permissions_role_id ON "role_permissions" ("role_id");
+CREATE INDEX IF NOT EXISTS idx_role_permissions_permission_id ON "role_permissions" ("permission_id");
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON DELETE CASCADE;
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id") ON DELETE CASCADE;
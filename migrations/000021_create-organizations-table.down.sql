@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_organizations_id;
+DROP INDEX IF EXISTS idx_organizations_created_at;
+DROP INDEX IF EXISTS idx_organizations_deleted_at;
+DROP INDEX IF EXISTS idx_organizations_slug;
+DROP INDEX IF EXISTS idx_organizations_owner_id;
+
+DROP TABLE IF EXISTS public."organizations";
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "organizations" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "deleted_at" TIMESTAMP,
+  "name" VARCHAR(255) NOT NULL,
+  "slug" VARCHAR(255) NOT NULL UNIQUE,
+  "owner_id" UUID NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_id ON "organizations" ("id");
+CREATE INDEX IF NOT EXISTS idx_organizations_created_at ON "organizations" ("created_at");
+CREATE INDEX IF NOT EXISTS idx_organizations_deleted_at ON "organizations" ("deleted_at");
+CREATE INDEX IF NOT EXISTS idx_organizations_slug ON "organizations" ("slug");
+CREATE INDEX IF NOT EXISTS idx_organizations_owner_id ON "organizations" ("owner_id");
+
+ALTER TABLE "organizations" ADD FOREIGN KEY ("owner_id") REFERENCES "users" ("id");
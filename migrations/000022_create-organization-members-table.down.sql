@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_organization_members_id;
+DROP INDEX IF EXISTS idx_organization_members_organization_id;
+DROP INDEX IF EXISTS idx_organization_members_user_id;
+
+DROP TABLE IF EXISTS public."organization_members";
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "organization_members" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "updated_at" TIMESTAMP DEFAULT now(),
+  "organization_id" UUID NOT NULL,
+  "user_id" UUID NOT NULL,
+  "role" VARCHAR(32) NOT NULL DEFAULT 'member',
+  UNIQUE ("organization_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_members_id ON "organization_members" ("id");
+CREATE INDEX IF NOT EXISTS idx_organization_members_organization_id ON "organization_members" ("organization_id");
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON "organization_members" ("user_id");
+
+ALTER TABLE "organization_members" ADD FOREIGN KEY ("organization_id") REFERENCES "organizations" ("id") ON DELETE CASCADE;
+ALTER TABLE "organization_members" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_organization_invitations_id;
+DROP INDEX IF EXISTS idx_organization_invitations_organization_id;
+DROP INDEX IF EXISTS idx_organization_invitations_token;
+
+DROP TABLE IF EXISTS public."organization_invitations";
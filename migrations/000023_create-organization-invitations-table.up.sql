@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS "organization_invitations" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "organization_id" UUID NOT NULL,
+  "invited_by" UUID NOT NULL,
+  "email" VARCHAR(255) NOT NULL,
+  "role" VARCHAR(32) NOT NULL DEFAULT 'member',
+  "token" VARCHAR(64) NOT NULL UNIQUE,
+  "expires_at" TIMESTAMP NOT NULL,
+  "accepted_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_id ON "organization_invitations" ("id");
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_organization_id ON "organization_invitations" ("organization_id");
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_token ON "organization_invitations" ("token");
+
+ALTER TABLE "organization_invitations" ADD FOREIGN KEY ("organization_id") REFERENCES "organizations" ("id") ON DELETE CASCADE;
+ALTER TABLE "organization_invitations" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE "sessions" ADD COLUMN IF NOT EXISTS "organization_id" UUID;
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("organization_id") REFERENCES "organizations" ("id") ON DELETE SET NULL;
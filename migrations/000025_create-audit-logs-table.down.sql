@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS audit_logs_append_only ON "audit_logs";
+DROP FUNCTION IF EXISTS audit_logs_append_only;
+
+DROP INDEX IF EXISTS idx_audit_logs_id;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+DROP INDEX IF EXISTS idx_audit_logs_action;
+DROP INDEX IF EXISTS idx_audit_logs_target;
+
+DROP TABLE IF EXISTS public."audit_logs";
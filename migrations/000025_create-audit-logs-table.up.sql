@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+  "id" UUID PRIMARY KEY NOT NULL DEFAULT uuidv7(),
+  "created_at" TIMESTAMP DEFAULT now(),
+  "actor_id" UUID,
+  "organization_id" UUID,
+  "action" VARCHAR(64) NOT NULL,
+  "target_type" VARCHAR(64) NOT NULL,
+  "target_id" UUID,
+  "before" JSONB,
+  "after" JSONB,
+  "ip_address" VARCHAR NOT NULL,
+  "user_agent" TEXT NOT NULL,
+  "request_id" VARCHAR(64)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_id ON "audit_logs" ("id");
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON "audit_logs" ("created_at");
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON "audit_logs" ("actor_id");
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON "audit_logs" ("action");
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON "audit_logs" ("target_type", "target_id");
+
+-- the actor and the target are kept without foreign keys, the log has to
+-- outlive the rows it talks about.
+
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+  BEFORE UPDATE OR DELETE ON "audit_logs"
+  FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
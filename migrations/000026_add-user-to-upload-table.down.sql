@@ -0,0 +1,6 @@
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_upload_id_fkey";
+ALTER TABLE "users" ADD FOREIGN KEY ("upload_id") REFERENCES "uploads" ("id") ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_uploads_user_id;
+
+ALTER TABLE "uploads" DROP COLUMN IF EXISTS "user_id";
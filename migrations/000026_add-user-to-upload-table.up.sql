@@ -0,0 +1,9 @@
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "user_id" UUID;
+
+CREATE INDEX IF NOT EXISTS idx_uploads_user_id ON "uploads" ("user_id");
+
+ALTER TABLE "uploads" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+-- deleting an upload must not delete the users using it
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_upload_id_fkey";
+ALTER TABLE "users" ADD FOREIGN KEY ("upload_id") REFERENCES "uploads" ("id") ON DELETE SET NULL;
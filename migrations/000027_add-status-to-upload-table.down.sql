@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_uploads_status;
+
+ALTER TABLE "uploads" DROP COLUMN IF EXISTS "multipart_upload_id";
+ALTER TABLE "uploads" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,4 @@
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "status" VARCHAR(16) NOT NULL DEFAULT 'ready';
+ALTER TABLE "uploads" ADD COLUMN IF NOT EXISTS "multipart_upload_id" TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_uploads_status ON "uploads" ("status");
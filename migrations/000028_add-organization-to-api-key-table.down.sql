@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_api_keys_organization_id;
+
+ALTER TABLE "api_keys" DROP COLUMN IF EXISTS "organization_id";
@@ -0,0 +1,6 @@
+ALTER TABLE "api_keys" ADD COLUMN IF NOT EXISTS "organization_id" UUID;
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_organization_id ON "api_keys" ("organization_id");
+
+-- a key scoped to an organization has no use once it is gone
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("organization_id") REFERENCES "organizations" ("id") ON DELETE CASCADE;